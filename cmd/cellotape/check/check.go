@@ -0,0 +1,260 @@
+// Package check runs the validation of an OpenAPIRouter with its spec without starting a server.
+//
+// It is used by the programs generated by the "cellotape check" command to validate the router returned by a function
+// of the checked package, but it can also be called directly from a main package or a test.
+package check
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/piiano/cellotape/router"
+)
+
+// Exit codes returned by Run.
+const (
+	// ExitOK is returned when the router is compatible with the spec.
+	ExitOK = 0
+	// ExitValidationFailed is returned when the validation of the router with the spec failed.
+	ExitValidationFailed = 1
+	// ExitUsageError is returned when the arguments are invalid or the router can't be created.
+	ExitUsageError = 2
+)
+
+// Output formats of the validation report.
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatSARIF = "sarif"
+)
+
+// DefaultFunction is the name of the function returning the router that is used when the -func flag is not set.
+const DefaultFunction = "NewRouter"
+
+// Config is the configuration of a check parsed from the command line arguments.
+type Config struct {
+	// Package is the package exposing the function that returns the router.
+	Package string
+	// Function is the name of the function that returns the router.
+	Function string
+	// OptionsFile is a path to a JSON or YAML file with the router options loaded with router.LoadOptionsFromFile.
+	OptionsFile string
+	// Format is the output format of the validation report printed to the standard output.
+	Format string
+	// SourceRoot is the root that handler positions are relative to in SARIF reports.
+	SourceRoot string
+
+	// optionFlags are the options set explicitly by flags, applied on top of the options loaded from OptionsFile.
+	optionFlags []func(*router.Options)
+}
+
+// ParseArgs parses the command line arguments of a check.
+// The arguments are flags followed by the package to check.
+func ParseArgs(args []string, output io.Writer) (Config, error) {
+	config := Config{}
+	flags := flag.NewFlagSet("cellotape check", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(output, "usage: cellotape check [flags] <package>")
+		_, _ = fmt.Fprintln(output, "")
+		_, _ = fmt.Fprintln(output, "Validates the OpenAPIRouter returned by a function of the package with its spec.")
+		_, _ = fmt.Fprintln(output, "The function must have one of the following signatures:")
+		_, _ = fmt.Fprintln(output, "  func() router.OpenAPIRouter")
+		_, _ = fmt.Fprintln(output, "  func() (router.OpenAPIRouter, error)")
+		_, _ = fmt.Fprintln(output, "  func(router.Options) router.OpenAPIRouter")
+		_, _ = fmt.Fprintln(output, "  func(router.Options) (router.OpenAPIRouter, error)")
+		_, _ = fmt.Fprintln(output, "Options flags and options files require a function that accepts router.Options.")
+		_, _ = fmt.Fprintln(output, "")
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&config.Function, "func", DefaultFunction, "name of the function returning the router")
+	flags.StringVar(&config.OptionsFile, "options", "", "path to a JSON or YAML file with the router options")
+	flags.StringVar(&config.Format, "format", FormatText, "report format printed to stdout: text, json or sarif")
+	flags.StringVar(&config.SourceRoot, "source-root", "", "root that handler positions are relative to in SARIF reports (defaults to the working directory)")
+
+	flagOptions := router.DefaultOptions()
+	optionFlags := map[string]func(*router.Options){
+		"log-level": func(o *router.Options) { o.LogLevel = flagOptions.LogLevel },
+		"must-handle-all-operations": func(o *router.Options) {
+			o.MustHandleAllOperations = flagOptions.MustHandleAllOperations
+		},
+		"handle-all-content-types": func(o *router.Options) { o.HandleAllContentTypes = flagOptions.HandleAllContentTypes },
+		"handle-all-security-schemes": func(o *router.Options) {
+			o.HandleAllSecuritySchemes = flagOptions.HandleAllSecuritySchemes
+		},
+		"exclude-operations": func(o *router.Options) { o.ExcludeOperations = flagOptions.ExcludeOperations },
+		"validate-request-body": func(o *router.Options) {
+			o.DefaultOperationValidation.ValidateRequestBody = flagOptions.DefaultOperationValidation.ValidateRequestBody
+		},
+		"validate-path-params": func(o *router.Options) {
+			o.DefaultOperationValidation.ValidatePathParams = flagOptions.DefaultOperationValidation.ValidatePathParams
+		},
+		"validate-query-params": func(o *router.Options) {
+			o.DefaultOperationValidation.ValidateQueryParams = flagOptions.DefaultOperationValidation.ValidateQueryParams
+		},
+		"validate-responses": func(o *router.Options) {
+			o.DefaultOperationValidation.ValidateResponses = flagOptions.DefaultOperationValidation.ValidateResponses
+		},
+		"handle-all-path-params": func(o *router.Options) {
+			o.DefaultOperationValidation.HandleAllPathParams = flagOptions.DefaultOperationValidation.HandleAllPathParams
+		},
+		"handle-all-query-params": func(o *router.Options) {
+			o.DefaultOperationValidation.HandleAllQueryParams = flagOptions.DefaultOperationValidation.HandleAllQueryParams
+		},
+		"handle-all-operation-responses": func(o *router.Options) {
+			o.DefaultOperationValidation.HandleAllOperationResponses = flagOptions.DefaultOperationValidation.HandleAllOperationResponses
+		},
+	}
+	flags.TextVar(&flagOptions.LogLevel, "log-level", flagOptions.LogLevel, "log level: error, warn, info or off")
+	flags.Func("exclude-operations", "comma separated operation ids excluded from the implementation", func(value string) error {
+		flagOptions.ExcludeOperations = strings.Split(value, ",")
+		return nil
+	})
+	behaviourFlag := func(behaviour *router.Behaviour, name string, usage string) {
+		flags.TextVar(behaviour, name, *behaviour, usage+": propagate-error, print-warning or ignore")
+	}
+	behaviourFlag(&flagOptions.MustHandleAllOperations, "must-handle-all-operations", "behaviour for operations without a handler")
+	behaviourFlag(&flagOptions.HandleAllContentTypes, "handle-all-content-types", "behaviour for content types without an implementation")
+	behaviourFlag(&flagOptions.HandleAllSecuritySchemes, "handle-all-security-schemes", "behaviour for security schemes without an authenticator")
+	operationOptions := &flagOptions.DefaultOperationValidation
+	behaviourFlag(&operationOptions.ValidateRequestBody, "validate-request-body", "behaviour for request body types incompatible with the spec")
+	behaviourFlag(&operationOptions.ValidatePathParams, "validate-path-params", "behaviour for path params types incompatible with the spec")
+	behaviourFlag(&operationOptions.ValidateQueryParams, "validate-query-params", "behaviour for query params types incompatible with the spec")
+	behaviourFlag(&operationOptions.ValidateResponses, "validate-responses", "behaviour for response types incompatible with the spec")
+	behaviourFlag(&operationOptions.HandleAllPathParams, "handle-all-path-params", "behaviour for path params not handled by any handler")
+	behaviourFlag(&operationOptions.HandleAllQueryParams, "handle-all-query-params", "behaviour for query params not handled by any handler")
+	behaviourFlag(&operationOptions.HandleAllOperationResponses, "handle-all-operation-responses", "behaviour for responses not handled by any handler")
+
+	if err := flags.Parse(args); err != nil {
+		return Config{}, err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return Config{}, errors.New("expected exactly one package to check")
+	}
+	config.Package = flags.Arg(0)
+	switch config.Format {
+	case FormatText, FormatJSON, FormatSARIF:
+	default:
+		return Config{}, fmt.Errorf("%s is an invalid format. expected one of text, json or sarif", config.Format)
+	}
+	flags.Visit(func(f *flag.Flag) {
+		if apply, ok := optionFlags[f.Name]; ok {
+			config.optionFlags = append(config.optionFlags, apply)
+		}
+	})
+	return config, nil
+}
+
+// customOptions returns true if router options are set with flags or with an options file.
+func (c Config) customOptions() bool {
+	return c.OptionsFile != "" || len(c.optionFlags) > 0
+}
+
+// Options loads the router options from the options file, or from the CELLOTAPE_* environment variables if no file is
+// set, and applies the options set with flags on top of them.
+// The default options are used as the baseline.
+func (c Config) Options() (router.Options, error) {
+	var options router.Options
+	var err error
+	if c.OptionsFile != "" {
+		options, err = router.LoadOptionsFromFile(c.OptionsFile)
+	} else {
+		options, err = router.LoadOptionsFromEnv()
+	}
+	if err != nil {
+		return router.Options{}, err
+	}
+	for _, apply := range c.optionFlags {
+		apply(&options)
+	}
+	return options, nil
+}
+
+// Run validates the router returned by routerFunc with its spec according to the command line arguments.
+// The validation logs are printed to stderr and the report is printed to stdout in the requested format.
+//
+// Returns ExitOK if the router is compatible with the spec, ExitValidationFailed if the validation failed, and
+// ExitUsageError if the arguments are invalid or the router can't be created.
+func Run(routerFunc any, args []string, stdout io.Writer, stderr io.Writer) int {
+	config, err := ParseArgs(args, stderr)
+	if err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			_, _ = fmt.Fprintln(stderr, err)
+		}
+		return ExitUsageError
+	}
+	options, err := config.Options()
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return ExitUsageError
+	}
+	options.LogOutput = stderr
+	openAPIRouter, err := newRouter(routerFunc, options, config.customOptions())
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "failed creating the router with %s.%s: %s\n", config.Package, config.Function, err)
+		return ExitUsageError
+	}
+
+	report, validationErr := openAPIRouter.Validate()
+	if err = writeReport(stdout, config, report); err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return ExitUsageError
+	}
+	if validationErr != nil {
+		_, _ = fmt.Fprintln(stderr, validationErr)
+		return ExitValidationFailed
+	}
+	return ExitOK
+}
+
+// newRouter calls the function returning the router with the options if it accepts them.
+func newRouter(routerFunc any, options router.Options, customOptions bool) (router.OpenAPIRouter, error) {
+	switch fn := routerFunc.(type) {
+	case func(router.Options) router.OpenAPIRouter:
+		return fn(options), nil
+	case func(router.Options) (router.OpenAPIRouter, error):
+		return fn(options)
+	}
+	if customOptions {
+		return nil, errors.New("options are set but the function doesn't accept router.Options")
+	}
+	switch fn := routerFunc.(type) {
+	case func() router.OpenAPIRouter:
+		return fn(), nil
+	case func() (router.OpenAPIRouter, error):
+		return fn()
+	}
+	return nil, fmt.Errorf("unsupported function type %T", routerFunc)
+}
+
+// writeReport writes the validation report to the output in the configured format.
+// Text reports are not written as the validation results are already printed to the log.
+func writeReport(output io.Writer, config Config, report router.ValidationReport) error {
+	var data []byte
+	var err error
+	switch config.Format {
+	case FormatJSON:
+		data, err = json.MarshalIndent(report, "", "  ")
+	case FormatSARIF:
+		sourceRoot := config.SourceRoot
+		if sourceRoot == "" {
+			if sourceRoot, err = os.Getwd(); err != nil {
+				return err
+			}
+		}
+		data, err = report.SARIF(sourceRoot)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(output, string(data))
+	return err
+}
@@ -0,0 +1,147 @@
+package check
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/piiano/cellotape/router"
+)
+
+const testSpec = `openapi: 3.0.3
+info:
+  title: test
+  version: 1.0.0
+paths:
+  /greet:
+    get:
+      operationId: greet
+      responses:
+        "200":
+          description: ok
+`
+
+type okResponse struct {
+	OK router.Nil `status:"200"`
+}
+
+func testRouter(t *testing.T, options router.Options, implemented bool) router.OpenAPIRouter {
+	spec, err := router.NewSpecFromData([]byte(testSpec))
+	require.NoError(t, err)
+	openAPIRouter := router.NewOpenAPIRouterWithOptions(spec, options)
+	if implemented {
+		openAPIRouter.WithOperation("greet", router.HandlerFunc[router.Nil, router.Nil, router.Nil, okResponse](
+			func(_ *router.Context, _ router.Request[router.Nil, router.Nil, router.Nil]) (router.Response[okResponse], error) {
+				return router.SendOK(okResponse{}), nil
+			}))
+	}
+	return openAPIRouter
+}
+
+func TestParseArgs(t *testing.T) {
+	config, err := ParseArgs([]string{"-func", "Routes", "-format", "sarif", "-must-handle-all-operations", "print-warning",
+		"-validate-responses", "ignore", "-exclude-operations", "a,b", "./api"}, &strings.Builder{})
+	require.NoError(t, err)
+	assert.Equal(t, "./api", config.Package)
+	assert.Equal(t, "Routes", config.Function)
+	assert.Equal(t, FormatSARIF, config.Format)
+
+	options, err := config.Options()
+	require.NoError(t, err)
+	assert.Equal(t, router.PrintWarning, options.MustHandleAllOperations)
+	assert.Equal(t, router.Ignore, options.DefaultOperationValidation.ValidateResponses)
+	assert.Equal(t, []string{"a", "b"}, options.ExcludeOperations)
+	assert.Equal(t, router.PropagateError, options.HandleAllContentTypes)
+	assert.Equal(t, router.LogLevelInfo, options.LogLevel)
+}
+
+func TestParseArgsErrors(t *testing.T) {
+	for name, args := range map[string][]string{
+		"missing package":  {},
+		"many packages":    {"./a", "./b"},
+		"invalid format":   {"-format", "xml", "./api"},
+		"invalid behavior": {"-validate-responses", "fail", "./api"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseArgs(args, &strings.Builder{})
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	var stdout, stderr strings.Builder
+	exitCode := Run(func(options router.Options) router.OpenAPIRouter {
+		return testRouter(t, options, true)
+	}, []string{"-log-level", "off", "./api"}, &stdout, &stderr)
+
+	assert.Equal(t, ExitOK, exitCode)
+	assert.Empty(t, stdout.String())
+	assert.Empty(t, stderr.String())
+}
+
+func TestRunValidationFailed(t *testing.T) {
+	var stdout, stderr strings.Builder
+	exitCode := Run(func() (router.OpenAPIRouter, error) {
+		options := router.DefaultOptions()
+		options.LogLevel = router.LogLevelOff
+		return testRouter(t, options, false), nil
+	}, []string{"-format", "json", "./api"}, &stdout, &stderr)
+
+	assert.Equal(t, ExitValidationFailed, exitCode)
+	var report router.ValidationReport
+	require.NoError(t, json.Unmarshal([]byte(stdout.String()), &report))
+	require.Len(t, report.Results, 1)
+	assert.Equal(t, router.CheckMustHandleAllOperations, report.Results[0].Check)
+	assert.Contains(t, stderr.String(), "failed validating the router with the spec")
+}
+
+func TestRunWithOptionsFlags(t *testing.T) {
+	var stdout, stderr strings.Builder
+	exitCode := Run(func(options router.Options) router.OpenAPIRouter {
+		return testRouter(t, options, false)
+	}, []string{"-log-level", "off", "-must-handle-all-operations", "ignore", "./api"}, &stdout, &stderr)
+
+	assert.Equal(t, ExitOK, exitCode)
+}
+
+func TestRunWithOptionsForFunctionWithoutOptions(t *testing.T) {
+	var stdout, stderr strings.Builder
+	exitCode := Run(func() router.OpenAPIRouter {
+		return testRouter(t, router.DefaultOptions(), true)
+	}, []string{"-must-handle-all-operations", "ignore", "./api"}, &stdout, &stderr)
+
+	assert.Equal(t, ExitUsageError, exitCode)
+	assert.Contains(t, stderr.String(), "the function doesn't accept router.Options")
+}
+
+func TestRunWithUnsupportedFunction(t *testing.T) {
+	var stdout, stderr strings.Builder
+	exitCode := Run(func() {}, []string{"./api"}, &stdout, &stderr)
+
+	assert.Equal(t, ExitUsageError, exitCode)
+	assert.Contains(t, stderr.String(), "unsupported function type func()")
+}
+
+func TestRunWithOptionsFile(t *testing.T) {
+	optionsFile := filepath.Join(t.TempDir(), "options.yaml")
+	require.NoError(t, os.WriteFile(optionsFile, []byte("logLevel: off\nmustHandleAllOperations: ignore\n"), 0o600))
+
+	var stdout, stderr strings.Builder
+	exitCode := Run(func(options router.Options) router.OpenAPIRouter {
+		return testRouter(t, options, false)
+	}, []string{"-options", optionsFile, "./api"}, &stdout, &stderr)
+	assert.Equal(t, ExitOK, exitCode)
+
+	require.NoError(t, os.WriteFile(optionsFile, []byte("unknown: true\n"), 0o600))
+	exitCode = Run(func(options router.Options) router.OpenAPIRouter {
+		return testRouter(t, options, false)
+	}, []string{"-options", optionsFile, "./api"}, &stdout, &stderr)
+	assert.Equal(t, ExitUsageError, exitCode)
+	assert.Contains(t, stderr.String(), `"unknown": unknown key`)
+}
@@ -0,0 +1,169 @@
+// Command cellotape verifies that an OpenAPIRouter implementation is compatible with its OpenAPI spec.
+//
+// Usage:
+//
+//	cellotape check [flags] <package>
+//
+// The check command builds a temporary program in the current module that imports the package, calls the function
+// returning the router (NewRouter by default, see the -func flag) and validates the router with its spec the same way
+// OpenAPIRouter.AsHandler does, without starting a server.
+// It exits with a non-zero status if the validation fails, so it can gate merges on spec compliance like go vet.
+//
+// Run "cellotape check -h" for the full list of flags.
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"text/template"
+
+	"github.com/piiano/cellotape/cmd/cellotape/check"
+)
+
+func main() {
+	// interrupting the command cancels the context to stop the go commands and remove the temporary directory
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	exitCode := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(exitCode)
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "check" {
+		_, _ = fmt.Fprintln(stderr, "usage: cellotape check [flags] <package>")
+		return check.ExitUsageError
+	}
+	checkArgs := args[1:]
+	config, err := check.ParseArgs(checkArgs, stderr)
+	if err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			_, _ = fmt.Fprintln(stderr, err)
+		}
+		return check.ExitUsageError
+	}
+	importPath, err := resolveImportPath(config.Package)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return check.ExitUsageError
+	}
+	source, err := checkProgramSource(importPath, config.Function)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return check.ExitUsageError
+	}
+
+	// the program is generated in the current module to build it with the module dependencies.
+	// the directory is removed when the command returns, including when it is interrupted.
+	dir, err := os.MkdirTemp(".", ".cellotape-check-")
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return check.ExitUsageError
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	if err = os.WriteFile(filepath.Join(dir, "main.go"), source, 0o600); err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return check.ExitUsageError
+	}
+
+	program := filepath.Join(dir, "check")
+	build := exec.CommandContext(ctx, "go", "build", "-o", program, "./"+filepath.ToSlash(dir))
+	build.Stdout = stderr
+	build.Stderr = stderr
+	if err = build.Run(); err != nil {
+		if ctx.Err() != nil {
+			_, _ = fmt.Fprintln(stderr, "interrupted")
+			return check.ExitUsageError
+		}
+		_, _ = fmt.Fprintf(stderr, "failed building the check program for %s.%s: %s\n", config.Package, config.Function, err)
+		return check.ExitUsageError
+	}
+
+	cmd := exec.CommandContext(ctx, program, checkArgs...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err = cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			_, _ = fmt.Fprintln(stderr, "interrupted")
+			return check.ExitUsageError
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		_, _ = fmt.Fprintln(stderr, err)
+		return check.ExitUsageError
+	}
+	return check.ExitOK
+}
+
+// resolveImportPath resolves the import path of a package pattern such as a relative directory.
+func resolveImportPath(pkg string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("go", "list", "-f", "{{.ImportPath}} {{.Name}}", pkg)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed resolving package %s: %s", pkg, strings.TrimSpace(stderr.String()))
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) != 1 {
+		return "", fmt.Errorf("%s matches %d packages. expected a single package", pkg, len(lines))
+	}
+	importPath, name, _ := strings.Cut(lines[0], " ")
+	if name == "main" {
+		return "", fmt.Errorf("%s is a main package and can't be imported. move the function returning the router to another package", pkg)
+	}
+	return importPath, nil
+}
+
+var checkProgramTemplate = template.Must(template.New("check").Parse(`// Code generated by cellotape check. DO NOT EDIT.
+
+package main
+
+import (
+	"os"
+
+	"github.com/piiano/cellotape/cmd/cellotape/check"
+	target {{ printf "%q" .ImportPath }}
+)
+
+func main() {
+	os.Exit(check.Run(target.{{ .Function }}, os.Args[1:], os.Stdout, os.Stderr))
+}
+`))
+
+// checkProgramSource generates the source of the program that validates the router returned by the function.
+func checkProgramSource(importPath string, function string) ([]byte, error) {
+	if !isIdentifier(function) {
+		return nil, fmt.Errorf("%q is not a valid function name", function)
+	}
+	var source bytes.Buffer
+	err := checkProgramTemplate.Execute(&source, struct {
+		ImportPath string
+		Function   string
+	}{ImportPath: importPath, Function: function})
+	return source.Bytes(), err
+}
+
+// isIdentifier checks that the name is an exported Go identifier.
+func isIdentifier(name string) bool {
+	if name == "" || name[0] < 'A' || name[0] > 'Z' {
+		return false
+	}
+	for _, r := range name {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
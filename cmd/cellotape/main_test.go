@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/piiano/cellotape/cmd/cellotape/check"
+)
+
+func TestCheckCommand(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the check program with the go command")
+	}
+	testCases := []struct {
+		name     string
+		args     []string
+		exitCode int
+		stdout   string
+		stderr   string
+	}{
+		{
+			name:     "compatible router",
+			args:     []string{"check", "-log-level", "off", "./testdata/checked"},
+			exitCode: check.ExitOK,
+		},
+		{
+			name:     "incompatible router",
+			args:     []string{"check", "-func", "NewIncompatibleRouter", "-format", "sarif", "./testdata/checked"},
+			exitCode: check.ExitValidationFailed,
+			stdout:   `"uri": "testdata/checked/checked.go"`,
+			stderr:   "failed validating the router with the spec",
+		},
+		{
+			name:     "missing function",
+			args:     []string{"check", "-func", "Missing", "./testdata/checked"},
+			exitCode: check.ExitUsageError,
+			stderr:   "failed building the check program",
+		},
+		{
+			name:     "main package",
+			args:     []string{"check", "."},
+			exitCode: check.ExitUsageError,
+			stderr:   "is a main package",
+		},
+		{
+			name:     "unknown command",
+			args:     []string{"run"},
+			exitCode: check.ExitUsageError,
+			stderr:   "usage: cellotape check",
+		},
+	}
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			exitCode := run(context.Background(), test.args, &stdout, &stderr)
+			assert.Equal(t, test.exitCode, exitCode, stderr.String())
+			assert.Contains(t, stdout.String(), test.stdout)
+			assert.Contains(t, stderr.String(), test.stderr)
+		})
+	}
+}
+
+func TestCheckCommandInterrupted(t *testing.T) {
+	if testing.Short() {
+		t.Skip("resolves the package with the go command")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var stdout, stderr strings.Builder
+	exitCode := run(ctx, []string{"check", "./testdata/checked"}, &stdout, &stderr)
+	assert.Equal(t, check.ExitUsageError, exitCode)
+	assert.Contains(t, stderr.String(), "interrupted")
+
+	leftovers, err := filepath.Glob(".cellotape-check-*")
+	require.NoError(t, err)
+	assert.Empty(t, leftovers)
+}
+
+func TestCheckProgramSource(t *testing.T) {
+	source, err := checkProgramSource("example.com/api", "NewRouter")
+	assert.NoError(t, err)
+	assert.Contains(t, string(source), `target "example.com/api"`)
+	assert.Contains(t, string(source), `check.Run(target.NewRouter, os.Args[1:], os.Stdout, os.Stderr)`)
+
+	_, err = checkProgramSource("example.com/api", "newRouter")
+	assert.Error(t, err)
+	_, err = checkProgramSource("example.com/api", "New()")
+	assert.Error(t, err)
+}
@@ -0,0 +1,53 @@
+// Package checked is checked by the tests of the cellotape check command.
+package checked
+
+import (
+	"github.com/piiano/cellotape/router"
+)
+
+const spec = `openapi: 3.0.3
+info:
+  title: checked
+  version: 1.0.0
+paths:
+  /greet:
+    get:
+      operationId: greet
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: string
+`
+
+// NewRouter returns a router that implements the spec.
+func NewRouter(options router.Options) (router.OpenAPIRouter, error) {
+	return newRouter(options, func(_ *router.Context, _ router.Request[router.Nil, router.Nil, router.Nil]) (router.Response[greetResponse], error) {
+		return router.SendOK(greetResponse{OK: "hello"}), nil
+	})
+}
+
+// NewIncompatibleRouter returns a router with a response type that is incompatible with the spec.
+func NewIncompatibleRouter() (router.OpenAPIRouter, error) {
+	return newRouter(router.DefaultOptions(), func(_ *router.Context, _ router.Request[router.Nil, router.Nil, router.Nil]) (router.Response[incompatibleGreetResponse], error) {
+		return router.SendOK(incompatibleGreetResponse{OK: 42}), nil
+	})
+}
+
+type greetResponse struct {
+	OK string `status:"200"`
+}
+
+type incompatibleGreetResponse struct {
+	OK int `status:"200"`
+}
+
+func newRouter[R any](options router.Options, handler router.HandlerFunc[router.Nil, router.Nil, router.Nil, R]) (router.OpenAPIRouter, error) {
+	spec, err := router.NewSpecFromData([]byte(spec))
+	if err != nil {
+		return nil, err
+	}
+	return router.NewOpenAPIRouterWithOptions(spec, options).WithOperation("greet", handler), nil
+}
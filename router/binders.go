@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"mime"
 	"net/http"
 	"net/url"
@@ -15,7 +14,6 @@ import (
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/getkin/kin-openapi/openapi3filter"
 	"github.com/getkin/kin-openapi/routers"
-	"github.com/julienschmidt/httprouter"
 	"golang.org/x/exp/slices"
 
 	"github.com/piiano/cellotape/router/ginbinders"
@@ -37,10 +35,10 @@ type requestBinder[B, P, Q any] func(*Context) (Request[B, P, Q], error)
 type responseBinder[R any] func(*Context, Response[R]) (RawResponse, error)
 
 // produce the binder function that can be called at runtime to create the httpRequest object for the handler.
-func requestBinderFactory[B, P, Q any](oa openapi, types requestTypes) requestBinder[B, P, Q] {
-	requestBodyBinder := requestBodyBinderFactory[B](types.requestBody, oa.contentTypes, oa.options)
-	pathParamsBinder := pathBinderFactory[P](types.pathParams)
-	queryParamsBinder := queryBinderFactory[Q](types.queryParams)
+func requestBinderFactory[B, P, Q any](oa openapi, operationID string, types requestTypes) requestBinder[B, P, Q] {
+	requestBodyBinder := requestBodyBinderFactory[B](types.requestBody, oa.contentTypes, oa.options, operationID)
+	pathParamsBinder := pathBinderFactory[P](types.pathParams, oa.options, operationID)
+	queryParamsBinder := queryBinderFactory[Q](types.queryParams, oa.options, operationID)
 
 	// this is what actually build the httpRequest object at runtime for the handler.
 	return func(ctx *Context) (Request[B, P, Q], error) {
@@ -61,34 +59,84 @@ func requestBinderFactory[B, P, Q any](oa openapi, types requestTypes) requestBi
 }
 
 // produce the httpRequest Body binder that can be used in runtime
-func requestBodyBinderFactory[B any](requestBodyType reflect.Type, contentTypes ContentTypes, options Options) binder[B] {
+func requestBodyBinderFactory[B any](requestBodyType reflect.Type, contentTypes ContentTypes, options Options, operationID string) binder[B] {
 	if requestBodyType == utils.NilType {
 		return nilBinder[B]
 	}
+	operationValidation := options.operationValidationOptions(operationID)
 	return func(ctx *Context, body *B) error {
 		contentType, err := requestContentType(ctx.Request, contentTypes, JSONContentType{})
 		if err != nil {
 			return err
 		}
 
-		contentTypesToIgnore := options.operationValidationOptions(ctx.Operation.OperationID).ContentTypesToSkipRuntimeValidation
-		bodyBytes, err := readBodyBytes(ctx, contentTypesToIgnore, contentType)
+		ctx.requestBodyValue, ctx.requestBodyDecoded = nil, false
+		bodyBytes, err := readValidatedBodyBytes(ctx, options, operationValidation, contentType)
 		if err != nil {
 			return err
 		}
 
+		// bind the value decoded for validating the body instead of decoding the body again
+		if ctx.requestBodyDecoded {
+			return bindGenericValue(ctx.requestBodyValue, body)
+		}
 		return contentType.Decode(bodyBytes, body)
 	}
 }
 
+// readValidatedBodyBytes reads the request body and validates it according to the runtime request validation behaviour
+// of the operation.
+// When the validation fails without propagating the error, the body is returned as received.
+func readValidatedBodyBytes(ctx *Context, options Options, operationValidation OperationValidationOptions, contentType ContentType) ([]byte, error) {
+	behaviour := operationValidation.runtimeValidateRequests()
+	contentTypesToIgnore := operationValidation.ContentTypesToSkipRuntimeValidation
+	if behaviour == Ignore {
+		return readBody(ctx)
+	}
+	if behaviour == PropagateError && options.RuntimeValidationViolationHandler == nil {
+		return readBodyBytes(ctx, contentTypesToIgnore, contentType)
+	}
+
+	// read the body first to tell reading errors apart from violations and to keep the body as received
+	data, err := readBody(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(data))
+	bodyBytes, err := readBodyBytes(ctx, contentTypesToIgnore, contentType)
+	if err != nil {
+		return data, options.handleRuntimeValidationViolation(behaviour, RuntimeValidationViolation{
+			Context: ctx,
+			In:      InBody,
+			Err:     err,
+		})
+	}
+	return bodyBytes, nil
+}
+
 func readBodyBytes(ctx *Context, contentTypesToIgnoreBody []string, contentType ContentType) ([]byte, error) {
 	skipValidation := ctx.Operation.RequestBody == nil || contentTypeValidationIsSkipped(contentTypesToIgnoreBody, contentType)
 	if skipValidation {
 		return readBody(ctx)
 	}
+	switch typedContentType := contentType.(type) {
+	case JSONContentType:
+		// kin-openapi decodes the body with the decoder of the request mime type which may differ from the default
+		if isJSONRequest(ctx.Request) {
+			return validateDecodedBodyAndPopulateDefaults(ctx, decodeJSONBody)
+		}
+	case GenericValueContentType:
+		return validateDecodedBodyAndPopulateDefaults(ctx, typedContentType.DecodeGenericValue)
+	}
 	return validateBodyAndPopulateDefaults(ctx)
 }
 
+// isJSONRequest checks if the "Content-Type" request header is the JSON mime type.
+func isJSONRequest(r *http.Request) bool {
+	mimeType, _, err := mime.ParseMediaType(r.Header.Get(contentTypeHeader))
+	return err == nil && mimeType == jsonMimeType
+}
+
 func contentTypeValidationIsSkipped(contentTypesToIgnore []string, contentType ContentType) bool {
 	return contentTypesToIgnore != nil && slices.Contains(contentTypesToIgnore, contentType.Mime())
 }
@@ -121,13 +169,83 @@ func validateBodyAndPopulateDefaults(ctx *Context) ([]byte, error) {
 	return io.ReadAll(input.Request.Body)
 }
 
+// validateDecodedBodyAndPopulateDefaults validate the request body with the openapi spec and populate the default values
+// like validateBodyAndPopulateDefaults.
+// The body is read and decoded once with the decode function. The decoded value is validated with the schema compiled
+// for the operation when there is one, and visited with kin-openapi otherwise or when it is invalid to report the same
+// errors.
+// The validated value is kept on the Context for binding it without decoding the body again, and the body bytes are
+// returned as received.
+func validateDecodedBodyAndPopulateDefaults(ctx *Context, decode func([]byte) (any, error)) ([]byte, error) {
+	requestBody := ctx.Operation.RequestBody.Value
+	requestError := func(reason string, err error) error {
+		return &openapi3filter.RequestError{
+			Input:       requestValidationInput(ctx),
+			RequestBody: requestBody,
+			Reason:      reason,
+			Err:         err,
+		}
+	}
+
+	data, err := readBody(ctx)
+	if err != nil {
+		return nil, requestError("reading failed", err)
+	}
+	if len(data) == 0 {
+		if requestBody.Required {
+			return nil, requestError("", openapi3filter.ErrInvalidRequired)
+		}
+		return data, nil
+	}
+	if len(requestBody.Content) == 0 {
+		return data, nil
+	}
+	inputMIME := ctx.Request.Header.Get(contentTypeHeader)
+	mediaType := requestBody.Content.Get(inputMIME)
+	if mediaType == nil {
+		return nil, requestError(fmt.Sprintf("header Content-Type has unexpected value %q", inputMIME), nil)
+	}
+	if mediaType.Schema == nil {
+		return data, nil
+	}
+
+	value, err := decode(data)
+	if err != nil {
+		return nil, requestError("failed to decode request body", err)
+	}
+	if schema := ctx.requestBodyValidator.schema(mediaType); schema == nil || !schema.isValid(value, new(bool)) {
+		if err = mediaType.Schema.Value.VisitJSON(value,
+			openapi3.VisitAsRequest(),
+			openapi3.DefaultsSet(func() {}),
+			openapi3.SetSchemaErrorMessageCustomizer(schemaErrorMessage),
+		); err != nil {
+			return nil, requestError(fmt.Sprintf("doesn't match schema%s", schemaIdentifier(mediaType.Schema)), err)
+		}
+	}
+	ctx.requestBodyValue, ctx.requestBodyDecoded = value, true
+	return data, nil
+}
+
+// schemaIdentifier returns the identifier kin-openapi reports for a schema in request errors prefixed with a space.
+func schemaIdentifier(schema *openapi3.SchemaRef) string {
+	schemaID := strings.TrimSpace(schema.Ref)
+	if schemaID == "" {
+		schemaID = strings.TrimSpace(schema.Value.Title)
+	}
+	if schemaID != "" {
+		schemaID = " " + schemaID
+	}
+	return schemaID
+}
+
 // produce the pathParamInValue pathParams binder that can be used in runtime
-func pathBinderFactory[P any](pathParamsType reflect.Type) binder[P] {
+func pathBinderFactory[P any](pathParamsType reflect.Type, options Options, operationID string) binder[P] {
 	if pathParamsType == utils.NilType {
 		return nilBinder[P]
 	}
+	behaviour := options.operationValidationOptions(operationID).runtimeValidateRequests()
 	return func(ctx *Context, target *P) error {
-		defaults, err := validateParamsAndPopulateDefaults(ctx, "path")
+		defaults, err := validateParams(ctx, options, behaviour, InPathParams)
 		if err != nil {
 			return err
 		}
@@ -145,10 +263,11 @@ func pathBinderFactory[P any](pathParamsType reflect.Type) binder[P] {
 }
 
 // produce the queryParamInValue pathParams binder that can be used in runtime
-func queryBinderFactory[Q any](queryParamsType reflect.Type) binder[Q] {
+func queryBinderFactory[Q any](queryParamsType reflect.Type, options Options, operationID string) binder[Q] {
 	if queryParamsType == utils.NilType {
 		return nilBinder[Q]
 	}
+	behaviour := options.operationValidationOptions(operationID).runtimeValidateRequests()
 	paramFields := utils.StructKeys(queryParamsType, "form")
 	nonArrayParams := utils.NewSet[string]()
 	for param, paramType := range paramFields {
@@ -164,7 +283,7 @@ func queryBinderFactory[Q any](queryParamsType reflect.Type) binder[Q] {
 	}
 
 	return func(ctx *Context, queryParams *Q) error {
-		defaults, err := validateParamsAndPopulateDefaults(ctx, "query")
+		defaults, err := validateParams(ctx, options, behaviour, InQueryParams)
 		if err != nil {
 			return err
 		}
@@ -183,25 +302,30 @@ func queryBinderFactory[Q any](queryParamsType reflect.Type) binder[Q] {
 }
 
 // responseBinderFactory creates a responseBinder that can be used in runtime
-func responseBinderFactory[R any](responses handlerResponses, contentTypes ContentTypes, runtimeValidateReponse Behaviour) responseBinder[R] {
+func responseBinderFactory[R any](responses handlerResponses, contentTypes ContentTypes, options Options, operationID string) responseBinder[R] {
+	// precompute the accessors of the responses to avoid looking up the response fields in every response
+	accessors := make(map[int]responseAccessor[R], len(responses))
+	for status, response := range responses {
+		accessors[status] = newResponseAccessor[R](response)
+	}
+	logger := options.runtimeLogger()
+	operationValidation := options.operationValidationOptions(operationID)
 	return func(ctx *Context, r Response[R]) (RawResponse, error) {
 		if ctx.RawResponse.Status != 0 {
 			return *ctx.RawResponse, nil
 		}
 		contentType, err := responseContentType(r.contentType, contentTypes, JSONContentType{})
 		if err != nil {
-			log.Printf("[WARNING] %s. fallback to %s\n", err, contentType.Mime())
+			logger.Warn(fmt.Sprintf("unsupported response content type, fallback to %s", contentType.Mime()),
+				runtimeLogFields(ctx, "status", r.status, "error", err)...)
 		}
-		responseType, exist := responses[r.status]
+		accessor, exist := accessors[r.status]
 		if !exist {
 			return RawResponse{}, fmt.Errorf("%w: %d", UnsupportedResponseStatusErr, r.status)
 		}
 		var responseBytes []byte
-		if !responseType.isNilType {
-			// this reflection call can not be avoided. we need some way to define multiple response types per handler
-			// and struct fields is the only way to achieve that.
-			responseField := reflect.ValueOf(r.response).FieldByIndex(responseType.fieldIndex).Interface()
-			responseBytes, err = contentType.Encode(responseField)
+		if accessor.field != nil {
+			responseBytes, err = encodeResponse(contentType, accessor.field(r.response).Interface())
 			if err != nil {
 				return RawResponse{}, err
 			}
@@ -218,13 +342,15 @@ func responseBinderFactory[R any](responses handlerResponses, contentTypes Conte
 			return *ctx.RawResponse, err
 		}
 
-		// validate response against spec. Only validate if not explicitly ignored as the validation may be expensive
-		// for large responses.
-		if runtimeValidateReponse != Ignore {
+		// validate response against spec. Only validate if not explicitly ignored or not sampled as the validation may
+		// be expensive for large responses.
+		if operationValidation.sampleRuntimeResponseValidation() {
 			if err := validateResponse(ctx, r, responseBytes); err != nil {
-				if runtimeValidateReponse == PrintWarning {
-					log.Printf("[WARNING] %s. response violates the spec\n", err)
-				} else {
+				if err = options.handleRuntimeValidationViolation(operationValidation.runtimeValidateResponses(), RuntimeValidationViolation{
+					Context:  ctx,
+					Response: true,
+					Err:      err,
+				}); err != nil {
 					return RawResponse{}, err
 				}
 			}
@@ -234,6 +360,40 @@ func responseBinderFactory[R any](responses handlerResponses, contentTypes Conte
 	}
 }
 
+// responseAccessor reads the response of a single status from the responses struct of a handler.
+type responseAccessor[R any] struct {
+	// field returns the response field. It is nil for responses with no content.
+	field func(R) reflect.Value
+}
+
+// newResponseAccessor creates the responseAccessor of the response from its field index.
+// Reflection can not be avoided as struct fields are the only way to define multiple response types per handler, but
+// the field lookup is done once when creating the router.
+func newResponseAccessor[R any](response httpResponse) responseAccessor[R] {
+	if response.isNilType {
+		return responseAccessor[R]{}
+	}
+	if len(response.fieldIndex) == 1 {
+		index := response.fieldIndex[0]
+		return responseAccessor[R]{field: func(responses R) reflect.Value {
+			return reflect.ValueOf(responses).Field(index)
+		}}
+	}
+	fieldIndex := response.fieldIndex
+	return responseAccessor[R]{field: func(responses R) reflect.Value {
+		return reflect.ValueOf(responses).FieldByIndex(fieldIndex)
+	}}
+}
+
+// encodeResponse encodes the response value with the content type.
+// Content types implementing BufferEncoderContentType encode to a pooled buffer.
+func encodeResponse(contentType ContentType, value any) ([]byte, error) {
+	if bufferEncoder, ok := contentType.(BufferEncoderContentType); ok {
+		return encodeToPooledBuffer(bufferEncoder, value)
+	}
+	return contentType.Encode(value)
+}
+
 // validateResponse validates the response against the spec. It logs a warning if the response violates the spec.
 func validateResponse[R any](ctx *Context, r Response[R], responseBytes []byte) error {
 	input := &openapi3filter.ResponseValidationInput{
@@ -241,7 +401,7 @@ func validateResponse[R any](ctx *Context, r Response[R], responseBytes []byte)
 		Status:                 r.status,
 		Header:                 r.headers,
 		Body:                   io.NopCloser(bytes.NewReader(responseBytes)),
-		Options:                validationOptions(),
+		Options:                kinValidationOptions,
 	}
 
 	return openapi3filter.ValidateResponse(ctx.Request.Context(), input)
@@ -291,6 +451,31 @@ func responseContentType(responseContentType string, supportedTypes ContentTypes
 	return defaultContentType, fmt.Errorf("%w: %s", UnsupportedResponseContentTypeErr, responseContentType)
 }
 
+// validateParams validates the params according to the runtime request validation behaviour of the operation.
+// Returns the validation input with the params as received when the validation is skipped or fails without
+// propagating the error.
+func validateParams(ctx *Context, options Options, behaviour Behaviour, in In) (*openapi3filter.RequestValidationInput, error) {
+	if behaviour == Ignore {
+		return requestValidationInput(ctx), nil
+	}
+	paramsIn := pathParamInValue
+	if in == InQueryParams {
+		paramsIn = queryParamInValue
+	}
+	input, err := validateParamsAndPopulateDefaults(ctx, paramsIn)
+	if err != nil {
+		if err = options.handleRuntimeValidationViolation(behaviour, RuntimeValidationViolation{
+			Context: ctx,
+			In:      in,
+			Err:     err,
+		}); err != nil {
+			return nil, err
+		}
+		return requestValidationInput(ctx), nil
+	}
+	return input, nil
+}
+
 func validateParamsAndPopulateDefaults(ctx *Context, in string) (*openapi3filter.RequestValidationInput, error) {
 	input := requestValidationInput(ctx)
 	parameters := utils.Filter(utils.Map(ctx.Operation.Parameters, func(p *openapi3.ParameterRef) *openapi3.Parameter {
@@ -312,7 +497,7 @@ func requestValidationInput(ctx *Context) *openapi3filter.RequestValidationInput
 		Request:     &http.Request{},
 		PathParams:  make(map[string]string),
 		QueryParams: url.Values{},
-		Options:     validationOptions(),
+		Options:     kinValidationOptions,
 		Route: &routers.Route{
 			Operation: ctx.Operation.Operation,
 		},
@@ -327,7 +512,7 @@ func requestValidationInput(ctx *Context) *openapi3filter.RequestValidationInput
 	}
 
 	if ctx.Params != nil {
-		input.PathParams = utils.FromEntries(utils.Map(*ctx.Params, func(p httprouter.Param) utils.Entry[string, string] {
+		input.PathParams = utils.FromEntries(utils.Map(ctx.Params, func(p PathParam) utils.Entry[string, string] {
 			return utils.Entry[string, string]{
 				Key:   p.Key,
 				Value: p.Value,
@@ -338,12 +523,18 @@ func requestValidationInput(ctx *Context) *openapi3filter.RequestValidationInput
 	return &input
 }
 
+// kinValidationOptions are the options shared by all the runtime validations with kin-openapi.
+var kinValidationOptions = validationOptions()
+
 func validationOptions() *openapi3filter.Options {
 	options := openapi3filter.Options{}
 	// Customize the error message returned by the kin-openapi library to be more user-friendly.
-	options.WithCustomSchemaErrorFunc(func(err *openapi3.SchemaError) string {
-		p := err.JSONPointer()
-		return fmt.Sprintf("Error at \"%s\": %s", "/"+strings.Join(p, "/"), err.Reason)
-	})
+	options.WithCustomSchemaErrorFunc(schemaErrorMessage)
 	return &options
 }
+
+// schemaErrorMessage formats schema errors with the JSON pointer of the invalid value.
+func schemaErrorMessage(err *openapi3.SchemaError) string {
+	p := err.JSONPointer()
+	return fmt.Sprintf("Error at \"%s\": %s", "/"+strings.Join(p, "/"), err.Reason)
+}
@@ -4,16 +4,13 @@ import (
 	"bytes"
 	"errors"
 	"io"
-	"log"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"reflect"
 	"testing"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/getkin/kin-openapi/openapi3filter"
-	"github.com/julienschmidt/httprouter"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -37,7 +34,7 @@ type StructType struct {
 }
 
 func TestQueryBinderFactory(t *testing.T) {
-	queryBinder := queryBinderFactory[StructType](reflect.TypeOf(StructType{}))
+	queryBinder := queryBinderFactory[StructType](reflect.TypeOf(StructType{}), DefaultOptions(), "")
 	var params StructType
 	err := queryBinder(testContext(withURL(t, "http:0.0.0.0:90/abc?Foo=42")), &params)
 	require.NoError(t, err)
@@ -49,7 +46,7 @@ type StructWithArrayType struct {
 }
 
 func TestQueryBinderFactoryWithArrayType(t *testing.T) {
-	queryBinder := queryBinderFactory[StructWithArrayType](reflect.TypeOf(StructWithArrayType{}))
+	queryBinder := queryBinderFactory[StructWithArrayType](reflect.TypeOf(StructWithArrayType{}), DefaultOptions(), "")
 	var params StructWithArrayType
 	err := queryBinder(testContext(withURL(t, "http:0.0.0.0:90/abc?Foo=42&Foo=6&Foo=7")), &params)
 	require.NoError(t, err)
@@ -57,23 +54,23 @@ func TestQueryBinderFactoryWithArrayType(t *testing.T) {
 }
 
 func TestQueryBinderFactoryMultipleParamToNonArrayError(t *testing.T) {
-	queryBinder := queryBinderFactory[StructType](reflect.TypeOf(StructType{}))
+	queryBinder := queryBinderFactory[StructType](reflect.TypeOf(StructType{}), DefaultOptions(), "")
 	var params StructType
 	err := queryBinder(testContext(withURL(t, "http:0.0.0.0:90/abc?Foo=42&Foo=6&Foo=7")), &params)
 	require.Error(t, err)
 }
 
 func TestQueryBinderFactoryError(t *testing.T) {
-	queryBinder := queryBinderFactory[StructType](reflect.TypeOf(StructType{}))
+	queryBinder := queryBinderFactory[StructType](reflect.TypeOf(StructType{}), DefaultOptions(), "")
 	var params StructType
 	err := queryBinder(testContext(withURL(t, "http:0.0.0.0:90/abc?Foo=abc")), &params)
 	require.Error(t, err)
 }
 
 func TestPathBinderFactory(t *testing.T) {
-	pathBinder := pathBinderFactory[StructType](reflect.TypeOf(StructType{}))
+	pathBinder := pathBinderFactory[StructType](reflect.TypeOf(StructType{}), DefaultOptions(), "")
 	var params StructType
-	err := pathBinder(testContext(withParams(&httprouter.Params{{
+	err := pathBinder(testContext(withParams(PathParams{{
 		Key:   "Foo",
 		Value: "42",
 	}})), &params)
@@ -82,9 +79,9 @@ func TestPathBinderFactory(t *testing.T) {
 }
 
 func TestPathBinderFactoryError(t *testing.T) {
-	pathBinder := pathBinderFactory[StructType](reflect.TypeOf(StructType{}))
+	pathBinder := pathBinderFactory[StructType](reflect.TypeOf(StructType{}), DefaultOptions(), "")
 	var params StructType
-	err := pathBinder(testContext(withParams(&httprouter.Params{{
+	err := pathBinder(testContext(withParams(PathParams{{
 		Key:   "Foo",
 		Value: "bar",
 	}})), &params)
@@ -92,7 +89,7 @@ func TestPathBinderFactoryError(t *testing.T) {
 }
 
 func TestRequestBodyBinderFactory(t *testing.T) {
-	requestBodyBinder := requestBodyBinderFactory[int](reflect.TypeOf(0), DefaultContentTypes(), DefaultOptions())
+	requestBodyBinder := requestBodyBinderFactory[int](reflect.TypeOf(0), DefaultContentTypes(), DefaultOptions(), "")
 	var param int
 	err := requestBodyBinder(testContext(withBody("42")), &param)
 	require.NoError(t, err)
@@ -104,7 +101,7 @@ func TestRequestBodyBinderFactoryWithSchema(t *testing.T) {
 	testOp.RequestBody = &openapi3.RequestBodyRef{
 		Value: openapi3.NewRequestBody().WithJSONSchema(openapi3.NewIntegerSchema()),
 	}
-	requestBodyBinder := requestBodyBinderFactory[int](reflect.TypeOf(0), DefaultContentTypes(), DefaultOptions())
+	requestBodyBinder := requestBodyBinderFactory[int](reflect.TypeOf(0), DefaultContentTypes(), DefaultOptions(), "")
 	var param int
 	err := requestBodyBinder(testContext(
 		withBody("42"),
@@ -114,8 +111,56 @@ func TestRequestBodyBinderFactoryWithSchema(t *testing.T) {
 	assert.Equal(t, 42, param)
 }
 
+func TestRequestBodyBinderFactoryBindsValidatedValue(t *testing.T) {
+	type body struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+	}
+	operation := requestBodyValidatorTestOperation(t, `{
+		"type": "object",
+		"properties": { "name": { "type": "string" }, "status": { "type": "string", "default": "active" } }
+	}`)
+	requestBodyBinder := requestBodyBinderFactory[body](reflect.TypeOf(body{}), DefaultContentTypes(), DefaultOptions(), "")
+
+	for name, validator := range map[string]*requestBodyValidator{
+		"kin-openapi":     nil,
+		"compiled schema": newRequestBodyValidator(SpecOperation{Operation: operation}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var value body
+			ctx := testContext(
+				withBody(`{"name":"foo"}`),
+				withHeader(contentTypeHeader, "application/json"),
+				withOperation(operation),
+				withRequestBodyValidator(validator))
+			require.NoError(t, requestBodyBinder(ctx, &value))
+			assert.Equal(t, body{Name: "foo", Status: "active"}, value)
+			assert.True(t, ctx.requestBodyDecoded)
+
+			_, kinErr := validateBodyAndPopulateDefaults(testContext(
+				withBody(`{"name":1}`),
+				withHeader(contentTypeHeader, "application/json"),
+				withOperation(operation)))
+			err := requestBodyBinder(testContext(
+				withBody(`{"name":1}`),
+				withHeader(contentTypeHeader, "application/json"),
+				withOperation(operation),
+				withRequestBodyValidator(validator)), &value)
+			require.Error(t, err)
+			assert.Equal(t, kinErr.Error(), err.Error())
+
+			err = requestBodyBinder(testContext(
+				withBody(`{"name":"foo"} {}`),
+				withHeader(contentTypeHeader, "application/json"),
+				withOperation(operation),
+				withRequestBodyValidator(validator)), &value)
+			require.Error(t, err)
+		})
+	}
+}
+
 func TestRequestBodyBinderFactoryError(t *testing.T) {
-	requestBodyBinder := requestBodyBinderFactory[int](reflect.TypeOf(0), DefaultContentTypes(), DefaultOptions())
+	requestBodyBinder := requestBodyBinderFactory[int](reflect.TypeOf(0), DefaultContentTypes(), DefaultOptions(), "")
 	var param int
 
 	err := requestBodyBinder(testContext(withBody(`"foo"`)), &param)
@@ -131,7 +176,7 @@ func (r readerWithError) Read(_ []byte) (int, error) {
 }
 
 func TestRequestBodyBinderFactoryReaderError(t *testing.T) {
-	requestBodyBinder := requestBodyBinderFactory[int](reflect.TypeOf(0), DefaultContentTypes(), DefaultOptions())
+	requestBodyBinder := requestBodyBinderFactory[int](reflect.TypeOf(0), DefaultContentTypes(), DefaultOptions(), "")
 	var param int
 	err := requestBodyBinder(testContext(
 		withBodyReader(io.NopCloser(readerWithError(`42`)))), &param)
@@ -139,7 +184,7 @@ func TestRequestBodyBinderFactoryReaderError(t *testing.T) {
 }
 
 func TestRequestBodyBinderFactoryContentTypeError(t *testing.T) {
-	requestBodyBinder := requestBodyBinderFactory[int](reflect.TypeOf(0), DefaultContentTypes(), DefaultOptions())
+	requestBodyBinder := requestBodyBinderFactory[int](reflect.TypeOf(0), DefaultContentTypes(), DefaultOptions(), "")
 	var param int
 
 	err := requestBodyBinder(testContext(
@@ -149,7 +194,7 @@ func TestRequestBodyBinderFactoryContentTypeError(t *testing.T) {
 }
 
 func TestRequestBodyBinderFactoryContentTypeWithCharset(t *testing.T) {
-	requestBodyBinder := requestBodyBinderFactory[int](reflect.TypeOf(0), DefaultContentTypes(), DefaultOptions())
+	requestBodyBinder := requestBodyBinderFactory[int](reflect.TypeOf(0), DefaultContentTypes(), DefaultOptions(), "")
 	var param int
 	err := requestBodyBinder(testContext(
 		withBody("42"),
@@ -159,7 +204,7 @@ func TestRequestBodyBinderFactoryContentTypeWithCharset(t *testing.T) {
 }
 
 func TestRequestBodyBinderFactoryInvalidContentType(t *testing.T) {
-	requestBodyBinder := requestBodyBinderFactory[int](reflect.TypeOf(0), DefaultContentTypes(), DefaultOptions())
+	requestBodyBinder := requestBodyBinderFactory[int](reflect.TypeOf(0), DefaultContentTypes(), DefaultOptions(), "")
 	var param int
 	err := requestBodyBinder(testContext(
 		withBody("42"),
@@ -168,7 +213,7 @@ func TestRequestBodyBinderFactoryInvalidContentType(t *testing.T) {
 }
 
 func TestRequestBodyBinderFactoryContentTypeAnyWithCharset(t *testing.T) {
-	requestBodyBinder := requestBodyBinderFactory[int](reflect.TypeOf(0), DefaultContentTypes(), DefaultOptions())
+	requestBodyBinder := requestBodyBinderFactory[int](reflect.TypeOf(0), DefaultContentTypes(), DefaultOptions(), "")
 	var param int
 	err := requestBodyBinder(testContext(
 		withBody("42"),
@@ -189,7 +234,7 @@ type CollidingFieldsParams struct {
 }
 
 func TestBindingEmbeddedQueryParamsCollidingFields(t *testing.T) {
-	requestBodyBinder := queryBinderFactory[CollidingFieldsParams](reflect.TypeOf(CollidingFieldsParams{}))
+	requestBodyBinder := queryBinderFactory[CollidingFieldsParams](reflect.TypeOf(CollidingFieldsParams{}), DefaultOptions(), "")
 	var param CollidingFieldsParams
 
 	ctx := testContext(withURL(t, "http://http:0.0.0.0:8080/path?param1=foo&param2=bar"))
@@ -212,7 +257,7 @@ type CollidingParams struct {
 }
 
 func TestBindingEmbeddedQueryParamsCollidingParams(t *testing.T) {
-	requestBodyBinder := queryBinderFactory[CollidingParams](reflect.TypeOf(CollidingParams{}))
+	requestBodyBinder := queryBinderFactory[CollidingParams](reflect.TypeOf(CollidingParams{}), DefaultOptions(), "")
 
 	var param CollidingParams
 	err := requestBodyBinder(testContext(
@@ -233,7 +278,7 @@ func (e errWriter) Write(i []byte) (int, error) {
 func TestErrOnWriterError(t *testing.T) {
 	type R = OKResponse[string]
 	responses := extractResponses(utils.GetType[R]())
-	binder := responseBinderFactory[R](responses, DefaultContentTypes(), DefaultOptions().DefaultOperationValidation.RuntimeValidateResponses)
+	binder := responseBinderFactory[R](responses, DefaultContentTypes(), DefaultOptions(), "")
 	response := SendOK(R{OK: "foo"}).ContentType("unknown")
 
 	testCases := []struct {
@@ -268,13 +313,7 @@ func TestErrOnWriterError(t *testing.T) {
 }
 
 func TestRuntimeValidateResponseSchema(t *testing.T) {
-	// Override global logger to capture warnings. Restore at the end of the test.
-	logger := log.Default()
 	var b bytes.Buffer
-	logger.SetOutput(&b)
-	defer func() {
-		logger.SetOutput(os.Stderr)
-	}()
 
 	type R = OKResponse[string]
 	responses := extractResponses(utils.GetType[R]())
@@ -337,7 +376,9 @@ func TestRuntimeValidateResponseSchema(t *testing.T) {
 				withOperation(testOp),
 			)
 
-			binder := responseBinderFactory[R](responses, DefaultContentTypes(), test.runtimeValidateResponseSchema)
+			options := runtimeValidateResponsesOptions(test.runtimeValidateResponseSchema)
+			options.LogOutput = &b
+			binder := responseBinderFactory[R](responses, DefaultContentTypes(), options, "")
 			_, err := binder(ctx, response)
 
 			if test.err {
@@ -354,15 +395,80 @@ func TestRuntimeValidateResponseSchema(t *testing.T) {
 			} else {
 				assertion = require.NotContains
 			}
-			assertion(t, b.String(), "[WARNING] response body doesn't match schema")
+			assertion(t, b.String(), "[Warning] response violates the spec")
+			assertion(t, b.String(), "response body doesn't match schema")
 
 			b.Reset()
 		})
 	}
 }
 
+func runtimeValidateResponsesOptions(behaviour Behaviour) Options {
+	options := DefaultOptions()
+	options.DefaultOperationValidation.RuntimeValidateResponses = utils.Ptr(behaviour)
+	return options
+}
+
+func TestRuntimeValidateResponseSchemaPerOperation(t *testing.T) {
+	type R = OKResponse[string]
+	responses := extractResponses(utils.GetType[R]())
+
+	options := runtimeValidateResponsesOptions(Ignore)
+	options.OperationValidations = map[string]OperationValidationOptions{
+		"validated": {RuntimeValidateResponses: utils.Ptr(PropagateError)},
+	}
+	for id, shouldErr := range map[string]bool{"validated": true, "ignored": false} {
+		binder := responseBinderFactory[R](responses, DefaultContentTypes(), options, id)
+		testOp := openapi3.NewOperation()
+		testOp.OperationID = id
+		testOp.Responses = openapi3.NewResponses(openapi3.WithStatus(200, &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().WithJSONSchema(openapi3.NewBoolSchema()),
+		}))
+		_, err := binder(testContext(withOperation(testOp)), SendOK(R{OK: "foo"}))
+		if shouldErr {
+			require.Error(t, err, id)
+		} else {
+			require.NoError(t, err, id)
+		}
+	}
+}
+
+func TestRuntimeValidateResponseSchemaSampling(t *testing.T) {
+	type R = OKResponse[string]
+	responses := extractResponses(utils.GetType[R]())
+	testOp := openapi3.NewOperation()
+	testOp.Responses = openapi3.NewResponses(openapi3.WithStatus(200, &openapi3.ResponseRef{
+		Value: openapi3.NewResponse().WithJSONSchema(openapi3.NewBoolSchema()),
+	}))
+
+	testCases := []struct {
+		percentage float64
+		minErrors  int
+		maxErrors  int
+	}{
+		{percentage: 0, minErrors: 1000, maxErrors: 1000},
+		{percentage: 100, minErrors: 1000, maxErrors: 1000},
+		{percentage: 0.0001, minErrors: 0, maxErrors: 10},
+		{percentage: 50, minErrors: 350, maxErrors: 650},
+	}
+	for _, test := range testCases {
+		options := runtimeValidateResponsesOptions(PropagateError)
+		options.DefaultOperationValidation.RuntimeValidateResponsesSamplePercentage = test.percentage
+		binder := responseBinderFactory[R](responses, DefaultContentTypes(), options, "")
+
+		errs := 0
+		for i := 0; i < 1000; i++ {
+			if _, err := binder(testContext(withOperation(testOp)), SendOK(R{OK: "foo"})); err != nil {
+				errs++
+			}
+		}
+		assert.GreaterOrEqual(t, errs, test.minErrors, test.percentage)
+		assert.LessOrEqual(t, errs, test.maxErrors, test.percentage)
+	}
+}
+
 func TestRequestBodyWithContentLengthFactory(t *testing.T) {
-	requestBodyBinder := requestBodyBinderFactory[int](reflect.TypeOf(0), DefaultContentTypes(), DefaultOptions())
+	requestBodyBinder := requestBodyBinderFactory[int](reflect.TypeOf(0), DefaultContentTypes(), DefaultOptions(), "")
 
 	type test struct {
 		name           string
@@ -437,3 +543,90 @@ func TestRequestBodyWithContentLengthFactory(t *testing.T) {
 		})
 	}
 }
+
+func TestResponseBinderWithEmbeddedResponses(t *testing.T) {
+	type responses struct {
+		EmbeddedResponse
+		NotFound int `status:"404"`
+	}
+	binder := responseBinderFactory[responses](extractResponses(utils.GetType[responses]()), DefaultContentTypes(), runtimeValidateResponsesOptions(Ignore), "")
+
+	ctx := testContext(withResponseWriter(httptest.NewRecorder()))
+	rawResponse, err := binder(ctx, SendOKJSON(responses{EmbeddedResponse: EmbeddedResponse{OK: "foo"}}))
+	require.NoError(t, err)
+	assert.Equal(t, `"foo"`, string(rawResponse.Body))
+
+	ctx = testContext(withResponseWriter(httptest.NewRecorder()))
+	rawResponse, err = binder(ctx, SendJSON(responses{NotFound: 42}).Status(http.StatusNotFound))
+	require.NoError(t, err)
+	assert.Equal(t, `42`, string(rawResponse.Body))
+}
+
+func TestResponseBinderWithPooledEncodeBuffer(t *testing.T) {
+	type responses struct {
+		OK map[string]string `status:"200"`
+	}
+	binder := responseBinderFactory[responses](extractResponses(utils.GetType[responses]()), DefaultContentTypes(), runtimeValidateResponsesOptions(Ignore), "")
+	response := map[string]string{"html": "<b>&</b>"}
+	expected, err := JSONContentType{}.Encode(response)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	rawResponse, err := binder(testContext(withResponseWriter(recorder)), SendOKJSON(responses{OK: response}))
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(rawResponse.Body))
+	assert.Equal(t, string(expected), recorder.Body.String())
+
+	// the body is not reused when encoding other responses
+	for i := 0; i < 10; i++ {
+		_, err = binder(testContext(withResponseWriter(httptest.NewRecorder())), SendOKJSON(responses{OK: map[string]string{"other": "value"}}))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, string(expected), string(rawResponse.Body))
+}
+
+// discardResponseWriter is a http.ResponseWriter that discards the written response for benchmarks.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w discardResponseWriter) Header() http.Header         { return w.header }
+func (w discardResponseWriter) WriteHeader(int)             {}
+func (w discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+
+func benchmarkResponseBinder(b *testing.B, items int) {
+	type item struct {
+		ID   int      `json:"id"`
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+	}
+	type responses struct {
+		OK       []item `status:"200"`
+		NotFound string `status:"404"`
+	}
+	binder := responseBinderFactory[responses](extractResponses(utils.GetType[responses]()), DefaultContentTypes(), runtimeValidateResponsesOptions(Ignore), "")
+	body := make([]item, items)
+	for i := range body {
+		body[i] = item{ID: i, Name: "foo", Tags: []string{"a", "b"}}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx := &Context{
+			Operation:   SpecOperation{Operation: openapi3.NewOperation()},
+			Writer:      discardResponseWriter{header: http.Header{}},
+			RawResponse: &RawResponse{},
+		}
+		if _, err := binder(ctx, SendOKJSON(responses{OK: body})); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkResponseBinderSmallResponse(b *testing.B) {
+	benchmarkResponseBinder(b, 2)
+}
+
+func BenchmarkResponseBinderLargeResponse(b *testing.B) {
+	benchmarkResponseBinder(b, 100)
+}
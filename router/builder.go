@@ -22,6 +22,20 @@ type OpenAPIRouter interface {
 	// deserialization.
 	WithContentType(ContentType) OpenAPIRouter
 
+	// WithAuthenticator adds the Authenticator of a security scheme defined in the spec components by its name.
+	//
+	// The router authenticates the requests of every operation with the security requirements of the operation (or
+	// the spec security requirements if the operation doesn't define its own).
+	// A request is authenticated if it satisfies any of the security requirements, and it satisfies a security
+	// requirement if all its schemes authenticate it.
+	// Requests that are not authenticated are rejected with an UnauthorizedErr and status 401 before reaching the
+	// handlers chain. Requests authenticated by a principal that is not granted the required scopes are rejected with a
+	// ForbiddenErr and status 403.
+	//
+	// By default, AsHandler fails if a security scheme required by an implemented operation has no authenticator.
+	// Check Options.HandleAllSecuritySchemes to change this behaviour.
+	WithAuthenticator(string, Authenticator) OpenAPIRouter
+
 	// AsHandler validates the validity of the specified implementation with the registered OpenAPISpec.
 	//
 	// Returns a http.Handler and nil error if all checks passed correctly
@@ -30,6 +44,13 @@ type OpenAPIRouter interface {
 	// http.Handler interface.
 	AsHandler() (http.Handler, error)
 
+	// Validate validates the specified implementation with the registered OpenAPISpec the same way AsHandler does.
+	//
+	// Returns a ValidationReport with all the errors and warnings found by the validation, and the same error AsHandler
+	// returns if the validation failed.
+	// The report can be serialized to JSON or to SARIF to annotate the implementation with the differences from the spec.
+	Validate() (ValidationReport, error)
+
 	// Spec returns the OpenAPI spec used by the router.
 	Spec() OpenAPISpec
 }
@@ -73,9 +94,10 @@ func NewOpenAPIRouter(spec OpenAPISpec) OpenAPIRouter {
 // If you want to use the OpenAPIRouter with the DefaultOptions, you can use the shorter NewOpenAPIRouter function instead.
 func NewOpenAPIRouterWithOptions(spec OpenAPISpec, options Options) OpenAPIRouter {
 	return &openapi{
-		spec:         spec,
-		options:      options,
-		contentTypes: DefaultContentTypes(),
+		spec:           spec,
+		options:        options,
+		contentTypes:   DefaultContentTypes(),
+		authenticators: make(map[string]Authenticator),
 	}
 }
 
@@ -97,9 +119,17 @@ func (oa *openapi) WithContentType(contentType ContentType) OpenAPIRouter {
 	oa.contentTypes[contentType.Mime()] = contentType
 	return oa
 }
+func (oa *openapi) WithAuthenticator(schemeName string, authenticator Authenticator) OpenAPIRouter {
+	oa.authenticators[schemeName] = authenticator
+	return oa
+}
 func (oa *openapi) AsHandler() (http.Handler, error) {
 	return createMainRouterHandler(oa)
 }
+func (oa *openapi) Validate() (ValidationReport, error) {
+	err := validateOpenAPIRouter(oa, flattenOperations(oa.group))
+	return *oa.report, err
+}
 func (oa *openapi) Spec() OpenAPISpec {
 	return oa.spec
 }
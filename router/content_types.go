@@ -1,6 +1,7 @@
 package router
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -20,6 +21,68 @@ type ContentType interface {
 
 type ContentTypes map[string]ContentType
 
+// SchemaValidatorContentType is an optional interface for ContentType implementations that validate types using a
+// schema_validator.TypeSchemaValidator.
+// When implemented, the router configures the returned validator with the context of the validated type (e.g. whether
+// it is used for a request body or a response) before validating it.
+type SchemaValidatorContentType interface {
+	ContentType
+	TypeSchemaValidator(reflect.Type, openapi3.Schema) schema_validator.TypeSchemaValidator
+}
+
+// GenericValueContentType is an optional interface for ContentType implementations that can decode data directly to
+// the generic form used for validating values with the spec.
+// The generic form is the value produced by json.Unmarshal to an any (e.g. map[string]any, []any, string, float64).
+// When implemented, the router decodes request bodies once to the generic form for validating them instead of decoding
+// them to a Go value and converting it with JSON.
+// The validated generic value is bound to the request body type with the rules of json.Unmarshal instead of decoding
+// the body again with Decode.
+type GenericValueContentType interface {
+	ContentType
+	DecodeGenericValue([]byte) (any, error)
+}
+
+// decodeGenericValue decodes the data with the content type to the generic form used for validating values with the
+// spec.
+func decodeGenericValue(contentType ContentType, data []byte) (any, error) {
+	if genericContentType, ok := contentType.(GenericValueContentType); ok {
+		return genericContentType.DecodeGenericValue(data)
+	}
+
+	var target any
+	if err := contentType.Decode(data, &target); err != nil {
+		return nil, err
+	}
+
+	// For kin-openapi to be able to validate a request it requires that the decoded value will be one of
+	// the values received when decoding JSON to any.
+	// e.g. any, []any, []map[string]any, etc.
+	//
+	// After using the custom decoder we get a value of the type of the target struct.
+	// To overcome this we marshal the target to JSON and then unmarshal it to any.
+
+	jsonBytes, err := json.Marshal(target)
+	if err != nil {
+		return nil, err
+	}
+
+	var jsonValue any
+	if err = json.Unmarshal(jsonBytes, &jsonValue); err != nil {
+		return nil, err
+	}
+
+	return jsonValue, nil
+}
+
+// BufferEncoderContentType is an optional interface for ContentType implementations that can encode values to a
+// given buffer.
+// When implemented, the router encodes responses to pooled buffers and copies the encoded bytes once instead of
+// growing new bytes while encoding every response.
+type BufferEncoderContentType interface {
+	ContentType
+	EncodeToBuffer(*bytes.Buffer, any) error
+}
+
 type OctetStreamContentType struct{}
 
 func (t OctetStreamContentType) Mime() string { return "application/octet-stream" }
@@ -102,14 +165,54 @@ type JSONContentType struct{}
 func (t JSONContentType) Mime() string                        { return "application/json" }
 func (t JSONContentType) Encode(value any) ([]byte, error)    { return json.Marshal(value) }
 func (t JSONContentType) Decode(data []byte, value any) error { return json.Unmarshal(data, value) }
+func (t JSONContentType) EncodeToBuffer(buffer *bytes.Buffer, value any) error {
+	if err := json.NewEncoder(buffer).Encode(value); err != nil {
+		return err
+	}
+	// trim the newline added by the encoder to produce the same bytes as Encode
+	buffer.Truncate(buffer.Len() - 1)
+	return nil
+}
 func (t JSONContentType) ValidateTypeSchema(
 	logger utils.Logger, level utils.LogLevel, goType reflect.Type, schema openapi3.Schema) error {
-	validator := schema_validator.NewTypeSchemaValidator(goType, schema)
+	return logTypeSchemaValidation(logger, level, t.TypeSchemaValidator(goType, schema))
+}
+func (t JSONContentType) TypeSchemaValidator(goType reflect.Type, schema openapi3.Schema) schema_validator.TypeSchemaValidator {
+	return schema_validator.NewTypeSchemaValidator(goType, schema)
+}
+
+// validateContentTypeSchema validates the type with the schema using the ContentType.
+// If the ContentType implements SchemaValidatorContentType the validation considers the Direction of the type and
+// returns the structured errors found relative to the schema JSON pointer. Warnings of the validation are logged
+// as warnings regardless of the level.
+func validateContentTypeSchema(logger utils.Logger, level utils.LogLevel, contentType ContentType, goType reflect.Type,
+	schema openapi3.Schema, schemaPath string, direction schema_validator.Direction, forbidWriteOnlyFields bool) ([]schema_validator.SchemaError, error) {
+	schemaValidatorContentType, ok := contentType.(SchemaValidatorContentType)
+	if !ok {
+		return nil, contentType.ValidateTypeSchema(logger, level, goType, schema)
+	}
+	validator := schemaValidatorContentType.TypeSchemaValidator(goType, schema)
+	configurableValidator, ok := validator.(schema_validator.ConfigurableTypeSchemaValidator)
+	if !ok {
+		return nil, logTypeSchemaValidation(logger, level, validator)
+	}
+	configurableValidator = configurableValidator.
+		WithDirection(direction).
+		WithForbiddenWriteOnlyFields(forbidWriteOnlyFields).
+		WithSchemaPath(schemaPath)
+	err := logTypeSchemaValidation(logger, level, configurableValidator)
+	for _, warning := range configurableValidator.Warnings() {
+		logger.Log(utils.Warn, warning.Error())
+	}
+	return configurableValidator.SchemaErrors(), err
+}
+
+// logTypeSchemaValidation runs the validator and logs all validation errors with the given level.
+func logTypeSchemaValidation(logger utils.Logger, level utils.LogLevel, validator schema_validator.TypeSchemaValidator) error {
 	err := validator.Validate()
 	for _, errMessage := range validator.Errors() {
 		logger.Log(level, errMessage)
 	}
-
 	return err
 }
 
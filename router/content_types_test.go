@@ -3,18 +3,23 @@ package router
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
 	"testing/iotest"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/piiano/cellotape/router/schema_validator"
 	"github.com/piiano/cellotape/router/utils"
 )
 
@@ -178,6 +183,67 @@ paths:
 	}
 }
 
+// basicSchemaValidatorContentType is a JSON content type with a TypeSchemaValidator that doesn't implement
+// schema_validator.ConfigurableTypeSchemaValidator.
+type basicSchemaValidatorContentType struct {
+	JSONContentType
+}
+
+type basicTypeSchemaValidator struct {
+	schema_validator.TypeSchemaValidator
+}
+
+func (basicSchemaValidatorContentType) TypeSchemaValidator(goType reflect.Type, schema openapi3.Schema) schema_validator.TypeSchemaValidator {
+	return basicTypeSchemaValidator{TypeSchemaValidator: schema_validator.NewTypeSchemaValidator(goType, schema)}
+}
+
+func TestValidateContentTypeSchemaWithBasicTypeSchemaValidator(t *testing.T) {
+	logger := utils.NewLoggerWithLevel(io.Discard, utils.Off)
+	schemaErrors, err := validateContentTypeSchema(logger, utils.Error, basicSchemaValidatorContentType{}, utils.GetType[string](),
+		*openapi3.NewStringSchema(), "#/components/schemas/Name", schema_validator.RequestDirection, false)
+	require.NoError(t, err)
+	assert.Empty(t, schemaErrors)
+
+	schemaErrors, err = validateContentTypeSchema(logger, utils.Error, basicSchemaValidatorContentType{}, utils.GetType[int](),
+		*openapi3.NewStringSchema(), "#/components/schemas/Name", schema_validator.RequestDirection, false)
+	require.Error(t, err)
+	assert.Empty(t, schemaErrors)
+
+	schemaErrors, err = validateContentTypeSchema(logger, utils.Error, JSONContentType{}, utils.GetType[int](),
+		*openapi3.NewStringSchema(), "#/components/schemas/Name", schema_validator.RequestDirection, false)
+	require.Error(t, err)
+	require.NotEmpty(t, schemaErrors)
+	assert.Equal(t, "#/components/schemas/Name", schemaErrors[0].SchemaPath)
+}
+
+func TestValidateContentTypeSchemaLogsWarnings(t *testing.T) {
+	schema := openapi3.NewOneOfSchema()
+	schema.OneOf = openapi3.SchemaRefs{
+		{Ref: "#/components/schemas/Cat", Value: openapi3.NewObjectSchema().WithProperty("kind", openapi3.NewStringSchema()).
+			WithProperty("meow", openapi3.NewBoolSchema())},
+		{Ref: "#/components/schemas/Dog", Value: openapi3.NewObjectSchema().WithProperty("kind", openapi3.NewStringSchema()).
+			WithProperty("bark", openapi3.NewBoolSchema())},
+	}
+	schema.Discriminator = &openapi3.Discriminator{PropertyName: "kind"}
+	type cat struct {
+		Kind string `json:"kind"`
+		Meow bool   `json:"meow"`
+	}
+	type dog struct {
+		Kind string `json:"kind"`
+		Bark bool   `json:"bark"`
+	}
+	logger := utils.NewLoggerWithLevel(io.Discard, utils.Off)
+	schemaErrors, err := validateContentTypeSchema(logger, utils.Error, JSONContentType{}, utils.GetType[*utils.MultiType[struct {
+		Cat *cat
+		Dog *dog
+	}]](), *schema, "#/components/schemas/Pet", schema_validator.RequestDirection, false)
+	require.NoError(t, err)
+	assert.Empty(t, schemaErrors)
+	assert.Equal(t, 1, logger.Warnings())
+	assert.Equal(t, 0, logger.Errors())
+}
+
 func TestOctetStreamContentTypeBytesSlice(t *testing.T) {
 	encodedBytes, err := OctetStreamContentType{}.Encode([]byte("foo"))
 	require.NoError(t, err)
@@ -289,3 +355,126 @@ func TestPlainTextContentTypeSchemaCompatability(t *testing.T) {
 	assert.Equal(t, 1, l.Counters().Errors)
 	assert.Equal(t, 0, l.Counters().Warnings)
 }
+
+// keyValueContentType decodes bodies of "key=value" lines to a map of strings.
+type keyValueContentType struct{}
+
+func (k keyValueContentType) Mime() string { return "application/x-key-value" }
+
+func (k keyValueContentType) Encode(value any) ([]byte, error) {
+	var lines []string
+	for key, v := range value.(map[string]any) {
+		lines = append(lines, fmt.Sprintf("%s=%s", key, v))
+	}
+	sort.Strings(lines)
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+func (k keyValueContentType) Decode(data []byte, value any) error {
+	values := map[string]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if key, v, found := strings.Cut(line, "="); found {
+			values[key] = v
+		}
+	}
+	switch typedValue := value.(type) {
+	case *map[string]string:
+		*typedValue = values
+	case *any:
+		*typedValue = values
+	default:
+		return fmt.Errorf("type %T is incompatible with content type %q", value, k.Mime())
+	}
+	return nil
+}
+
+func (k keyValueContentType) ValidateTypeSchema(_ utils.Logger, _ utils.LogLevel, _ reflect.Type, _ openapi3.Schema) error {
+	return nil
+}
+
+// genericKeyValueContentType is a keyValueContentType that decodes bodies directly to their generic value.
+type genericKeyValueContentType struct {
+	keyValueContentType
+	decodedGenericValues *int
+	decodedValues        *int
+}
+
+func (k genericKeyValueContentType) Decode(data []byte, value any) error {
+	*k.decodedValues++
+	return k.keyValueContentType.Decode(data, value)
+}
+
+func (k genericKeyValueContentType) DecodeGenericValue(data []byte) (any, error) {
+	*k.decodedGenericValues++
+	var values map[string]string
+	if err := k.keyValueContentType.Decode(data, &values); err != nil {
+		return nil, err
+	}
+	return utils.FromEntries(utils.Map(utils.Entries(values), func(e utils.Entry[string, string]) utils.Entry[string, any] {
+		return utils.Entry[string, any]{Key: e.Key, Value: e.Value}
+	})), nil
+}
+
+func TestGenericValueContentType(t *testing.T) {
+	schema := openapi3.NewObjectSchema().
+		WithProperty("name", openapi3.NewStringSchema().WithMinLength(2)).
+		WithProperty("status", &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeString}, Default: "active"})
+	schema.Required = []string{"name"}
+	testOp := openapi3.NewOperation()
+	testOp.RequestBody = &openapi3.RequestBodyRef{
+		Value: openapi3.NewRequestBody().WithRequired(true).WithSchema(schema, []string{keyValueContentType{}.Mime()}),
+	}
+	if openapi3filter.RegisteredBodyDecoder(keyValueContentType{}.Mime()) == nil {
+		openapi3filter.RegisterBodyDecoder(keyValueContentType{}.Mime(), createDecoder(keyValueContentType{}))
+		openapi3filter.RegisterBodyEncoder(keyValueContentType{}.Mime(), keyValueContentType{}.Encode)
+	}
+
+	decodedGenericValues, decodedValues := 0, 0
+	genericContentType := genericKeyValueContentType{
+		decodedGenericValues: &decodedGenericValues,
+		decodedValues:        &decodedValues,
+	}
+
+	testCases := []struct {
+		body     string
+		expected map[string]string
+	}{
+		{body: "name=foo", expected: map[string]string{"name": "foo", "status": "active"}},
+		{body: "name=foo\nstatus=inactive", expected: map[string]string{"name": "foo", "status": "inactive"}},
+		{body: "name=f"},
+		{body: "status=active"},
+		{body: ""},
+	}
+
+	for _, test := range testCases {
+		kinBinder := requestBodyBinderFactory[map[string]string](reflect.TypeOf(map[string]string{}),
+			ContentTypes{keyValueContentType{}.Mime(): keyValueContentType{}}, DefaultOptions(), "")
+		var kinBody map[string]string
+		kinErr := kinBinder(testContext(
+			withBody(test.body),
+			withHeader(contentTypeHeader, keyValueContentType{}.Mime()),
+			withOperation(testOp)), &kinBody)
+
+		genericBinder := requestBodyBinderFactory[map[string]string](reflect.TypeOf(map[string]string{}),
+			ContentTypes{keyValueContentType{}.Mime(): genericContentType}, DefaultOptions(), "")
+		var body map[string]string
+		err := genericBinder(testContext(
+			withBody(test.body),
+			withHeader(contentTypeHeader, keyValueContentType{}.Mime()),
+			withOperation(testOp)), &body)
+
+		if test.expected == nil {
+			require.Error(t, kinErr)
+			require.Error(t, err)
+			assert.Equal(t, kinErr.Error(), err.Error())
+			continue
+		}
+		require.NoError(t, kinErr)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, kinBody)
+		assert.Equal(t, test.expected, body)
+	}
+	assert.Equal(t, 4, decodedGenericValues)
+	// valid bodies are bound from their validated generic value
+	assert.Equal(t, 0, decodedValues)
+}
@@ -1,17 +1,13 @@
 package router
 
 import (
-	"encoding/json"
 	"errors"
 	"io"
-	"log"
 	"net/http"
-	"regexp"
 	"runtime/debug"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/getkin/kin-openapi/openapi3filter"
-	"github.com/julienschmidt/httprouter"
 
 	"github.com/piiano/cellotape/router/utils"
 )
@@ -21,19 +17,38 @@ func createMainRouterHandler(oa *openapi) (http.Handler, error) {
 	if err := validateOpenAPIRouter(oa, flatOperations); err != nil {
 		return nil, err
 	}
-	router := httprouter.New()
+	router := newRoutesHandler(oa.options.newRouteMatcher())
 
 	logger := oa.logger()
-	pathParamsMatcher := regexp.MustCompile(`\{([^/}]*)}`)
+	corsRoutes := make([]corsRoute, 0, len(flatOperations))
 
 	specOperations := oa.spec.Operations()
 	for _, flatOp := range flatOperations {
 		specOp := specOperations[flatOp.id]
-		path := pathParamsMatcher.ReplaceAllString(specOp.Path, ":$1")
-		chainHead := chainHandlers(*oa, append(flatOp.handlers, flatOp.handler)...)
-		httpRouterHandler := asHttpRouterHandler(*oa, specOp, chainHead)
-		router.Handle(specOp.Method, path, httpRouterHandler)
-		logger.Infof("register handler for operation %q - %s %s", flatOp.id, specOp.Method, specOp.Path)
+		handlers := append(flatOp.handlers, flatOp.handler)
+		if securityHandler := oa.securityHandler(specOp); securityHandler != nil {
+			handlers = append([]handler{asHandlerModel(securityHandler)}, handlers...)
+		}
+		chainHead := chainHandlers(*oa, flatOp.id, handlers...)
+		routeHandler := asRouteHandler(*oa, specOp, chainHead)
+		basePaths, err := oa.operationBasePaths(specOp)
+		if err != nil {
+			return nil, err
+		}
+		for _, basePath := range basePaths {
+			r := route{method: specOp.Method, path: basePath + specOp.Path, operationID: flatOp.id}
+			if err = router.handle(r.method, r.path, routeHandler); err != nil {
+				return nil, errors.New(invalidRoute(r.method, r.path, r.operationID, err))
+			}
+			logger.Infof("register handler for operation %q - %s %s", flatOp.id, r.method, r.path)
+			corsRoutes = append(corsRoutes, corsRoute{route: r, specOp: specOp})
+		}
+	}
+
+	if oa.options.ServeSpec != nil {
+		if err := registerSpecRoutes(*oa, router); err != nil {
+			return nil, err
+		}
 	}
 
 	setGlobalHandlers(router, oa)
@@ -52,15 +67,20 @@ func createMainRouterHandler(oa *openapi) (http.Handler, error) {
 
 	registerAdditionalOpenAPIFormatValidations()
 
+	if oa.options.CORS != nil {
+		return newCORSHandler(*oa, router, corsRoutes)
+	}
 	return router, nil
 }
 
 // setGlobalHandlers sets the OPTIONS handlers for the router.
-func setGlobalHandlers(router *httprouter.Router, oa *openapi) {
-	router.HandleMethodNotAllowed = false
-	router.NotFound = http.HandlerFunc(notFoundHandler)
-	router.HandleOPTIONS = oa.options.OptionsHandler != nil
-	router.GlobalOPTIONS = oa.options.OptionsHandler
+func setGlobalHandlers(router *routesHandler, oa *openapi) {
+	router.notFoundHandler = oa.options.NotFoundHandler
+	if router.notFoundHandler == nil {
+		router.notFoundHandler = http.HandlerFunc(DefaultNotFoundHandler)
+	}
+	router.methodNotAllowedHandler = oa.options.MethodNotAllowedHandler
+	router.optionsHandler = oa.options.OptionsHandler
 }
 
 func createDecoder(contentType ContentType) func(reader io.Reader, _ http.Header, schema *openapi3.SchemaRef, enc openapi3filter.EncodingFn) (any, error) {
@@ -70,29 +90,7 @@ func createDecoder(contentType ContentType) func(reader io.Reader, _ http.Header
 			return nil, err
 		}
 
-		var target any
-		if err = contentType.Decode(bytes, &target); err != nil {
-			return nil, err
-		}
-
-		// For kin-openapi to be able to validate a request it requires that the decoded value will be one of
-		// the values received when decoding JSON to any.
-		// e.g. any, []any, []map[string]any, etc.
-		//
-		// After using the custom decoder we get a value of the type of the target struct.
-		// To overcome this we marshal the target to JSON and then unmarshal it to any.
-
-		jsonBytes, err := json.Marshal(target)
-		if err != nil {
-			return nil, err
-		}
-
-		var jsonValue any
-		if err = json.Unmarshal(jsonBytes, &jsonValue); err != nil {
-			return nil, err
-		}
-
-		return jsonValue, nil
+		return decodeGenericValue(contentType, bytes)
 	}
 }
 
@@ -113,10 +111,10 @@ func flattenOperations(g group) []operation {
 	})
 }
 
-func chainHandlers(oa openapi, handlers ...handler) (head BoundHandlerFunc) {
+func chainHandlers(oa openapi, operationID string, handlers ...handler) (head BoundHandlerFunc) {
 	var next BoundHandlerFunc
 	for i := len(handlers) - 1; i >= 0; i-- {
-		next = handlers[i].handlerFunc.handlerFactory(oa, next)
+		next = handlers[i].handlerFunc.handlerFactory(oa, operationID, next)
 	}
 	next = ErrorHandler(func(c *Context, err error) (Response[any], error) {
 		var badRequestError BadRequestErr
@@ -126,27 +124,50 @@ func chainHandlers(oa openapi, handlers ...handler) (head BoundHandlerFunc) {
 			_, writeErr := c.Writer.Write([]byte(err.Error()))
 			return Error[any](writeErr)
 		}
+		var forbiddenErr ForbiddenErr
+		if err != nil && c.RawResponse.Status == 0 && errors.As(err, &forbiddenErr) {
+			c.Writer.Header().Add("Content-Type", "text/plain")
+			c.Writer.Header().Set("WWW-Authenticate", insufficientScopeChallenge(forbiddenErr.Scopes))
+			c.Writer.WriteHeader(http.StatusForbidden)
+			_, writeErr := c.Writer.Write([]byte(http.StatusText(http.StatusForbidden)))
+			return Error[any](writeErr)
+		}
+		var unauthorizedErr UnauthorizedErr
+		if err != nil && c.RawResponse.Status == 0 && errors.As(err, &unauthorizedErr) {
+			// the reasons of the authenticators are not exposed to the client
+			c.Writer.Header().Add("Content-Type", "text/plain")
+			for _, challenge := range unauthorizedErr.Challenges {
+				c.Writer.Header().Add("WWW-Authenticate", challenge)
+			}
+			c.Writer.WriteHeader(http.StatusUnauthorized)
+			_, writeErr := c.Writer.Write([]byte(http.StatusText(http.StatusUnauthorized)))
+			return Error[any](writeErr)
+		}
 		return Error[any](err)
-	}).handlerFactory(oa, next)
+	}).handlerFactory(oa, operationID, next)
 	return next
 }
 
-func asHttpRouterHandler(oa openapi, specOp SpecOperation, head BoundHandlerFunc) httprouter.Handle {
-	return func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+func asRouteHandler(oa openapi, specOp SpecOperation, head BoundHandlerFunc) RouteHandler {
+	bodyValidator := newRequestBodyValidator(specOp)
+	logger := oa.options.runtimeLogger()
+	return func(writer http.ResponseWriter, request *http.Request, params PathParams) {
 		monitoredHTTPIO := NewMonitoredHTTP(writer, request.Body)
 
 		request.Body = monitoredHTTPIO
 
-		if oa.options.RecoverOnPanic {
-			defer defaultRecoverBehaviour(writer)
-		}
 		ctx := &Context{
 			Operation:   specOp,
 			Writer:      monitoredHTTPIO,
 			Request:     request,
-			Params:      &params,
+			Params:      params,
 			RawResponse: &RawResponse{Status: 0},
 			Durations:   monitoredHTTPIO,
+
+			requestBodyValidator: bodyValidator,
+		}
+		if oa.options.RecoverOnPanic {
+			defer defaultRecoverBehaviour(writer, logger, ctx)
 		}
 
 		_, err := head(ctx)
@@ -157,20 +178,44 @@ func asHttpRouterHandler(oa openapi, specOp SpecOperation, head BoundHandlerFunc
 	}
 }
 
-func defaultRecoverBehaviour(writer http.ResponseWriter) {
+func defaultRecoverBehaviour(writer http.ResponseWriter, logger RuntimeLogger, ctx *Context) {
 	if r := recover(); r != nil {
 		writer.WriteHeader(500)
-		log.Printf("[Error] recovered from panic. %v. respond with status 500\n", r)
-		debug.PrintStack()
+		logger.Error("recovered from panic. respond with status 500", runtimeLogFields(ctx,
+			"status", 500, "error", r, "stack", string(debug.Stack()))...)
 	}
 }
 
+// DefaultMethodNotAllowedHandler is the handler of requests with a method that is not defined for their path provided
+// when using DefaultOptions. It responds with status 405 and the "Allow" header with the methods defined for the path.
+func DefaultMethodNotAllowedHandler(writer http.ResponseWriter, _ *http.Request) {
+	writer.WriteHeader(http.StatusMethodNotAllowed)
+}
+
+// NewErrorResponseHandler returns a handler that responds with the status and the body returned by the body function
+// serialized with the content type. It can be used as Options.NotFoundHandler or Options.MethodNotAllowedHandler to
+// respond with an error body defined in the spec.
+func NewErrorResponseHandler[R any](status int, contentType ContentType, body func(request *http.Request) R) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		bytes, err := contentType.Encode(body(request))
+		if err != nil {
+			writer.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		writer.Header().Set("Content-Type", contentType.Mime())
+		writer.WriteHeader(status)
+		_, _ = writer.Write(bytes)
+	})
+}
+
 // DefaultOptionsHandler This handler is the default OPTIONS handler provided when using DefaultOptions.
 // It will respond to each OPTIONS request with an "Allow" header that will include all the methods that are defined for the path and respond with 204 status.
 func DefaultOptionsHandler(writer http.ResponseWriter, _ *http.Request) {
 	writer.WriteHeader(http.StatusNoContent)
 }
 
-func notFoundHandler(writer http.ResponseWriter, _ *http.Request) {
+// DefaultNotFoundHandler is the handler of requests that don't match any path defined in the spec provided when using
+// DefaultOptions. It responds with status 404 and an empty body.
+func DefaultNotFoundHandler(writer http.ResponseWriter, _ *http.Request) {
 	writer.WriteHeader(http.StatusNotFound)
 }
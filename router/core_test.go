@@ -7,7 +7,6 @@ import (
 	"testing"
 
 	"github.com/getkin/kin-openapi/openapi3"
-	"github.com/julienschmidt/httprouter"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -16,19 +15,24 @@ import (
 
 func TestDefaultRecoverFromError(t *testing.T) {
 	writer := httptest.ResponseRecorder{}
+	var logs strings.Builder
+	logger := defaultRuntimeLogger{output: &logs, level: utils.Error}
+	ctx := testContext(withOperation(&openapi3.Operation{OperationID: "test"}))
 	func() {
-		defer defaultRecoverBehaviour(&writer)
+		defer defaultRecoverBehaviour(&writer, logger, ctx)
 		panic("unexpected error")
 	}()
 	assert.Equal(t, 500, writer.Code)
+	assert.Contains(t, logs.String(), `[Error] recovered from panic. respond with status 500 operationId=test`)
+	assert.Contains(t, logs.String(), `status=500 error="unexpected error" stack=`)
 }
 
 func TestName(t *testing.T) {
 	writer := httptest.ResponseRecorder{}
-	handlerFunc := asHttpRouterHandler(openapi{}, SpecOperation{}, func(_ *Context) (RawResponse, error) {
+	handlerFunc := asRouteHandler(openapi{}, SpecOperation{}, func(_ *Context) (RawResponse, error) {
 		return RawResponse{}, nil
 	})
-	handlerFunc(&writer, &http.Request{}, httprouter.Params{})
+	handlerFunc(&writer, &http.Request{}, PathParams{})
 
 	assert.Equal(t, 500, writer.Code)
 }
@@ -67,8 +71,7 @@ func TestFailStartOnValidationError(t *testing.T) {
 }
 
 func TestOptionsHandler(t *testing.T) {
-	router := httprouter.New()
-	router.HandleMethodNotAllowed = false
+	router := newRoutesHandler(NewHTTPRouterMatcher())
 	options := DefaultOptions()
 
 	setGlobalHandlers(router, &openapi{
@@ -91,14 +94,14 @@ func TestOptionsHandler(t *testing.T) {
 		options: options,
 	})
 
-	router.GET("/foo/:param1/bar/:param2", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {})
-	router.POST("/foo/:param1/bar/:param2", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {})
-	router.DELETE("/foo/:param1/bar", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {})
-	router.PATCH("/foo/:param1/bar", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {})
-	router.GET("/foo/:param1", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {})
-	router.OPTIONS("/foo/:param1", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	require.NoError(t, router.handle("GET", "/foo/{param1}/bar/{param2}", func(writer http.ResponseWriter, request *http.Request, params PathParams) {}))
+	require.NoError(t, router.handle("POST", "/foo/{param1}/bar/{param2}", func(writer http.ResponseWriter, request *http.Request, params PathParams) {}))
+	require.NoError(t, router.handle("DELETE", "/foo/{param1}/bar", func(writer http.ResponseWriter, request *http.Request, params PathParams) {}))
+	require.NoError(t, router.handle("PATCH", "/foo/{param1}/bar", func(writer http.ResponseWriter, request *http.Request, params PathParams) {}))
+	require.NoError(t, router.handle("GET", "/foo/{param1}", func(writer http.ResponseWriter, request *http.Request, params PathParams) {}))
+	require.NoError(t, router.handle("OPTIONS", "/foo/{param1}", func(writer http.ResponseWriter, request *http.Request, params PathParams) {
 		writer.WriteHeader(200)
-	})
+	}))
 
 	writer := httptest.NewRecorder()
 	router.ServeHTTP(writer, httptest.NewRequest("OPTIONS", "/foo/1/bar/2", nil))
@@ -139,8 +142,7 @@ func TestOptionsHandler(t *testing.T) {
 }
 
 func TestOptionsHandlerIsNil(t *testing.T) {
-	router := httprouter.New()
-	router.HandleMethodNotAllowed = false
+	router := newRoutesHandler(NewHTTPRouterMatcher())
 	options := DefaultOptions()
 	options.OptionsHandler = nil
 
@@ -160,12 +162,12 @@ func TestOptionsHandlerIsNil(t *testing.T) {
 		options: options,
 	})
 
-	router.GET("/foo/:param1/bar", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {})
-	router.POST("/foo/:param1/bar", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {})
-	router.GET("/foo/:param1", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {})
-	router.OPTIONS("/foo/:param1", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	require.NoError(t, router.handle("GET", "/foo/{param1}/bar", func(writer http.ResponseWriter, request *http.Request, params PathParams) {}))
+	require.NoError(t, router.handle("POST", "/foo/{param1}/bar", func(writer http.ResponseWriter, request *http.Request, params PathParams) {}))
+	require.NoError(t, router.handle("GET", "/foo/{param1}", func(writer http.ResponseWriter, request *http.Request, params PathParams) {}))
+	require.NoError(t, router.handle("OPTIONS", "/foo/{param1}", func(writer http.ResponseWriter, request *http.Request, params PathParams) {
 		writer.WriteHeader(200)
-	})
+	}))
 
 	writer := httptest.NewRecorder()
 	router.ServeHTTP(writer, httptest.NewRequest("OPTIONS", "/foo/1/bar", nil))
@@ -189,3 +191,68 @@ func TestOptionsHandlerIsNil(t *testing.T) {
 	require.Equal(t, 404, response.StatusCode)
 	require.ElementsMatch(t, []string{}, utils.Keys(response.Header))
 }
+
+func TestMethodNotAllowedHandler(t *testing.T) {
+	router := newRoutesHandler(NewHTTPRouterMatcher())
+	setGlobalHandlers(router, &openapi{options: DefaultOptions()})
+
+	noop := func(writer http.ResponseWriter, request *http.Request, params PathParams) {}
+	require.NoError(t, router.handle("GET", "/foo/{param1}", noop))
+	require.NoError(t, router.handle("DELETE", "/foo/{param1}", noop))
+	require.NoError(t, router.handle("POST", "/foo", noop))
+
+	writer := httptest.NewRecorder()
+	router.ServeHTTP(writer, httptest.NewRequest("PUT", "/foo/1", nil))
+	response := writer.Result()
+
+	require.Equal(t, 405, response.StatusCode)
+	require.ElementsMatch(t, []string{"Allow"}, utils.Keys(response.Header))
+	require.Equal(t, "DELETE, GET, OPTIONS", response.Header.Get("Allow"))
+
+	writer = httptest.NewRecorder()
+	router.ServeHTTP(writer, httptest.NewRequest("GET", "/bar", nil))
+	require.Equal(t, 404, writer.Code)
+}
+
+func TestMethodNotAllowedHandlerIsNil(t *testing.T) {
+	router := newRoutesHandler(NewHTTPRouterMatcher())
+	options := DefaultOptions()
+	options.MethodNotAllowedHandler = nil
+	setGlobalHandlers(router, &openapi{options: options})
+	require.NoError(t, router.handle("GET", "/foo", func(writer http.ResponseWriter, request *http.Request, params PathParams) {}))
+
+	writer := httptest.NewRecorder()
+	router.ServeHTTP(writer, httptest.NewRequest("PUT", "/foo", nil))
+	response := writer.Result()
+
+	require.Equal(t, 404, response.StatusCode)
+	require.ElementsMatch(t, []string{}, utils.Keys(response.Header))
+}
+
+func TestErrorResponseHandlers(t *testing.T) {
+	type errorBody struct {
+		Message string `json:"message"`
+	}
+	router := newRoutesHandler(NewHTTPRouterMatcher())
+	options := DefaultOptions()
+	options.NotFoundHandler = NewErrorResponseHandler(http.StatusNotFound, JSONContentType{}, func(request *http.Request) errorBody {
+		return errorBody{Message: "no route for " + request.URL.Path}
+	})
+	options.MethodNotAllowedHandler = NewErrorResponseHandler(http.StatusMethodNotAllowed, JSONContentType{}, func(request *http.Request) errorBody {
+		return errorBody{Message: request.Method + " is not allowed"}
+	})
+	setGlobalHandlers(router, &openapi{options: options})
+	require.NoError(t, router.handle("GET", "/foo", func(writer http.ResponseWriter, request *http.Request, params PathParams) {}))
+
+	writer := httptest.NewRecorder()
+	router.ServeHTTP(writer, httptest.NewRequest("GET", "/bar", nil))
+	assert.Equal(t, 404, writer.Code)
+	assert.Equal(t, "application/json", writer.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"message":"no route for /bar"}`, writer.Body.String())
+
+	writer = httptest.NewRecorder()
+	router.ServeHTTP(writer, httptest.NewRequest("DELETE", "/foo", nil))
+	assert.Equal(t, 405, writer.Code)
+	assert.Equal(t, "GET, OPTIONS", writer.Header().Get("Allow"))
+	assert.JSONEq(t, `{"message":"DELETE is not allowed"}`, writer.Body.String())
+}
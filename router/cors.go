@@ -0,0 +1,153 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/piiano/cellotape/router/utils"
+)
+
+// corsHandler handles the cross-origin requests of the allowed origins and passes all requests to the routes handler.
+type corsHandler struct {
+	options CORSOptions
+	routes  *routesHandler
+	// preflight matches preflight requests to a handler that responds with the headers allowed for the path.
+	preflight RouteMatcher
+}
+
+// corsRoute is a route with the spec operation it is registered for.
+type corsRoute struct {
+	route
+	specOp SpecOperation
+}
+
+// newCORSHandler creates a corsHandler for the routes with the headers derived from the spec for each path.
+// Returns an error if credentials are allowed for any origin.
+func newCORSHandler(oa openapi, routes *routesHandler, corsRoutes []corsRoute) (http.Handler, error) {
+	if oa.options.CORS.AllowCredentials && utils.NewSet(oa.options.CORS.AllowedOrigins...).Has("*") {
+		return nil, errors.New(wildcardOriginWithCredentials())
+	}
+	h := corsHandler{options: *oa.options.CORS, routes: routes, preflight: oa.options.newRouteMatcher()}
+	pathsHeaders := make(map[string]utils.Set[string])
+	for _, r := range corsRoutes {
+		if pathsHeaders[r.path] == nil {
+			pathsHeaders[r.path] = utils.NewSet[string]()
+		}
+		for _, header := range oa.spec.operationRequestHeaders(r.specOp) {
+			pathsHeaders[r.path].Add(header)
+		}
+	}
+	for _, r := range corsRoutes {
+		allowedHeaders := utils.Keys(pathsHeaders[r.path])
+		sort.Strings(allowedHeaders)
+		if err := h.preflight.Handle(r.method, r.path, h.preflightHandler(allowedHeaders)); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// operationRequestHeaders returns the canonical names of the request headers the operation is defined with in the
+// spec: header parameters, headers of its security schemes and Content-Type if it has a request body.
+func (s OpenAPISpec) operationRequestHeaders(specOp SpecOperation) []string {
+	headers := make([]string, 0)
+	var parameters openapi3.Parameters
+	if s.Paths != nil {
+		if pathItem := s.Paths.Value(specOp.Path); pathItem != nil {
+			parameters = append(parameters, pathItem.Parameters...)
+		}
+	}
+	parameters = append(parameters, specOp.Parameters...)
+	for _, parameter := range parameters {
+		if parameter != nil && parameter.Value != nil && parameter.Value.In == "header" {
+			headers = append(headers, http.CanonicalHeaderKey(parameter.Value.Name))
+		}
+	}
+	schemes := s.securitySchemes()
+	for _, requirement := range s.operationSecurityRequirements(specOp) {
+		for name := range requirement {
+			scheme := schemes[name]
+			if scheme == nil || scheme.Value == nil {
+				continue
+			}
+			switch {
+			case scheme.Value.Type == "apiKey" && scheme.Value.In == "header":
+				headers = append(headers, http.CanonicalHeaderKey(scheme.Value.Name))
+			case scheme.Value.Type != "apiKey":
+				headers = append(headers, "Authorization")
+			}
+		}
+	}
+	if specOp.RequestBody != nil {
+		headers = append(headers, "Content-Type")
+	}
+	return headers
+}
+
+// allowedOrigin returns the value of the Access-Control-Allow-Origin header for the origin.
+// Returns false if the origin is not allowed.
+func (h corsHandler) allowedOrigin(origin string) (string, bool) {
+	for _, allowedOrigin := range h.options.AllowedOrigins {
+		if allowedOrigin == "*" {
+			return "*", true
+		}
+		if strings.EqualFold(allowedOrigin, origin) {
+			return origin, true
+		}
+	}
+	return "", false
+}
+
+func (h corsHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	origin := request.Header.Get("Origin")
+	if origin == "" {
+		h.routes.ServeHTTP(writer, request)
+		return
+	}
+	writer.Header().Add("Vary", "Origin")
+	allowedOrigin, allowed := h.allowedOrigin(origin)
+	if !allowed {
+		h.routes.ServeHTTP(writer, request)
+		return
+	}
+	if requestMethod := request.Header.Get("Access-Control-Request-Method"); request.Method == http.MethodOptions && requestMethod != "" {
+		if handler, params, found := h.preflight.Match(requestMethod, request.URL.Path); found {
+			h.setAllowOrigin(writer, allowedOrigin)
+			handler(writer, request, params)
+			return
+		}
+		h.routes.ServeHTTP(writer, request)
+		return
+	}
+	h.setAllowOrigin(writer, allowedOrigin)
+	if len(h.options.ExposedHeaders) > 0 {
+		writer.Header().Set("Access-Control-Expose-Headers", strings.Join(h.options.ExposedHeaders, ", "))
+	}
+	h.routes.ServeHTTP(writer, request)
+}
+
+func (h corsHandler) setAllowOrigin(writer http.ResponseWriter, allowedOrigin string) {
+	writer.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
+	if h.options.AllowCredentials {
+		writer.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// preflightHandler responds to preflight requests with the methods of the path and the allowed headers.
+func (h corsHandler) preflightHandler(allowedHeaders []string) RouteHandler {
+	return func(writer http.ResponseWriter, request *http.Request, _ PathParams) {
+		writer.Header().Set("Access-Control-Allow-Methods", strings.Join(h.routes.allowed(request.URL.Path), ", "))
+		if len(allowedHeaders) > 0 {
+			writer.Header().Set("Access-Control-Allow-Headers", strings.Join(allowedHeaders, ", "))
+		}
+		if h.options.MaxAge > 0 {
+			writer.Header().Set("Access-Control-Max-Age", strconv.Itoa(h.options.MaxAge))
+		}
+		writer.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,127 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// corsTestSpec returns a spec with operations that have security requirements, header params and a request body.
+func corsTestSpec() OpenAPISpec {
+	getTasks := testSpecOperation("getTasks")
+	getTasks.Security = openapi3.NewSecurityRequirements().With(openapi3.NewSecurityRequirement().Authenticate("apiKey"))
+	createTask := testSpecOperation("createTask")
+	createTask.RequestBody = &openapi3.RequestBodyRef{
+		Value: openapi3.NewRequestBody().WithJSONSchema(openapi3.NewObjectSchema()),
+	}
+	spec := newTestSpec("test",
+		openapi3.WithPath("/tasks", &openapi3.PathItem{
+			Parameters: openapi3.Parameters{
+				{Value: openapi3.NewHeaderParameter("x-request-id").WithSchema(openapi3.NewStringSchema())},
+			},
+			Get:  getTasks,
+			Post: createTask,
+		}),
+		openapi3.WithPath("/status", &openapi3.PathItem{Get: testSpecOperation("getStatus")}),
+	)
+	spec.Components = &openapi3.Components{SecuritySchemes: openapi3.SecuritySchemes{
+		"apiKey": {Value: &openapi3.SecurityScheme{Type: "apiKey", In: "header", Name: "x-api-key"}},
+	}}
+	return spec
+}
+
+func corsTestHandler(t *testing.T, cors CORSOptions) http.Handler {
+	handler, err := corsTestRouter(cors).AsHandler()
+	require.NoError(t, err)
+	return handler
+}
+
+func corsTestRouter(cors CORSOptions) OpenAPIRouter {
+	options := DefaultTestOptions()
+	options.CORS = &cors
+	return okOperationsRouter(corsTestSpec(), options, "getTasks", "getStatus").
+		WithAuthenticator("apiKey", func(*Context, SecurityScheme) error { return nil }).
+		WithOperation("createTask", HandlerFunc[map[string]any, Nil, Nil, OKResponse[Nil]](func(_ *Context, _ Request[map[string]any, Nil, Nil]) (Response[OKResponse[Nil]], error) {
+			return SendOK(OKResponse[Nil]{}), nil
+		}))
+}
+
+func preflightRequest(path string, origin string, method string) *http.Request {
+	request := httptest.NewRequest(http.MethodOptions, path, nil)
+	request.Header.Set("Origin", origin)
+	request.Header.Set("Access-Control-Request-Method", method)
+	return request
+}
+
+func TestCORSPreflight(t *testing.T) {
+	handler := corsTestHandler(t, CORSOptions{AllowedOrigins: []string{"https://app.example.com"}, MaxAge: 600})
+
+	writer := httptest.NewRecorder()
+	handler.ServeHTTP(writer, preflightRequest("/tasks", "https://app.example.com", http.MethodPost))
+	assert.Equal(t, 204, writer.Code)
+	assert.Equal(t, "https://app.example.com", writer.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, OPTIONS, POST", writer.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type, X-Api-Key, X-Request-Id", writer.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", writer.Header().Get("Access-Control-Max-Age"))
+	assert.Equal(t, "Origin", writer.Header().Get("Vary"))
+	assert.Empty(t, writer.Header().Get("Access-Control-Allow-Credentials"))
+
+	writer = httptest.NewRecorder()
+	handler.ServeHTTP(writer, preflightRequest("/status", "https://app.example.com", http.MethodGet))
+	assert.Equal(t, 204, writer.Code)
+	assert.Equal(t, "GET, OPTIONS", writer.Header().Get("Access-Control-Allow-Methods"))
+	assert.Empty(t, writer.Header().Get("Access-Control-Allow-Headers"))
+
+	// preflight of a method that is not defined for the path
+	writer = httptest.NewRecorder()
+	handler.ServeHTTP(writer, preflightRequest("/status", "https://app.example.com", http.MethodDelete))
+	assert.Empty(t, writer.Header().Get("Access-Control-Allow-Origin"))
+
+	// preflight of an origin that is not allowed
+	writer = httptest.NewRecorder()
+	handler.ServeHTTP(writer, preflightRequest("/tasks", "https://other.example.com", http.MethodPost))
+	assert.Empty(t, writer.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, writer.Header().Get("Access-Control-Allow-Methods"))
+}
+
+func TestCORSActualRequest(t *testing.T) {
+	handler := corsTestHandler(t, CORSOptions{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowCredentials: true,
+		ExposedHeaders:   []string{"X-Total-Count"},
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/status", nil)
+	request.Header.Set("Origin", "https://app.example.com")
+	writer := httptest.NewRecorder()
+	handler.ServeHTTP(writer, request)
+	assert.Equal(t, 200, writer.Code)
+	assert.Equal(t, "https://app.example.com", writer.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", writer.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "X-Total-Count", writer.Header().Get("Access-Control-Expose-Headers"))
+
+	// same origin requests are not decorated
+	writer = httptest.NewRecorder()
+	handler.ServeHTTP(writer, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.Equal(t, 200, writer.Code)
+	assert.Empty(t, writer.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSWildcardOrigin(t *testing.T) {
+	handler := corsTestHandler(t, CORSOptions{AllowedOrigins: []string{"*"}})
+
+	request := httptest.NewRequest(http.MethodGet, "/status", nil)
+	request.Header.Set("Origin", "https://app.example.com")
+	writer := httptest.NewRecorder()
+	handler.ServeHTTP(writer, request)
+	assert.Equal(t, "*", writer.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSWildcardOriginWithCredentials(t *testing.T) {
+	_, err := corsTestRouter(CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true}).AsHandler()
+	require.EqualError(t, err, wildcardOriginWithCredentials())
+}
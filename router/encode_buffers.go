@@ -0,0 +1,32 @@
+package router
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBufferSize is the maximal capacity of buffers returned to the pool to avoid holding the memory of rare
+// large responses.
+const maxPooledBufferSize = 64 << 10
+
+// encodeBuffers is a pool of buffers used for encoding responses.
+var encodeBuffers = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// encodeToPooledBuffer encodes the value to a pooled buffer and returns a copy of the encoded bytes.
+// The buffer is returned to the pool before returning, so the returned bytes are never reused by other responses.
+func encodeToPooledBuffer(encoder BufferEncoderContentType, value any) ([]byte, error) {
+	buffer := encodeBuffers.Get().(*bytes.Buffer)
+	defer releaseEncodeBuffer(buffer)
+	buffer.Reset()
+	if err := encoder.EncodeToBuffer(buffer, value); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(buffer.Bytes()), nil
+}
+
+// releaseEncodeBuffer returns the buffer to the pool unless it grew too large.
+func releaseEncodeBuffer(buffer *bytes.Buffer) {
+	if buffer.Cap() <= maxPooledBufferSize {
+		encodeBuffers.Put(buffer)
+	}
+}
@@ -14,6 +14,31 @@ func multipleHandlersFoundForOperationId(id string) string {
 func missingHandlerForOperationId(id string) string {
 	return fmt.Sprintf("missing handler for operation %q", id)
 }
+func missingAuthenticatorForSecurityScheme(name string, operationId string) string {
+	return fmt.Sprintf("security scheme %q required by operation %q has no authenticator", name, operationId)
+}
+func undefinedSecuritySchemeRequiredByOperation(name string, operationId string) string {
+	return fmt.Sprintf("security scheme %q required by operation %q is not defined in the spec components", name, operationId)
+}
+func unresolvedSecurityScheme() string {
+	return "no authenticator can authenticate the request with the security scheme"
+}
+func authenticatorForNonExistingSecurityScheme(name string) string {
+	return fmt.Sprintf("authenticator received for non existing security scheme %q in spec", name)
+}
+func invalidServersForOperation(operationId string, err error) string {
+	return fmt.Sprintf("failed resolving the servers base paths of operation %q: %s", operationId, err)
+}
+func conflictingRoutes(method string, path string, operationId string, conflictingPath string, conflictingOperationId string) string {
+	return fmt.Sprintf("route %s %s of operation %q conflicts with route %s %s of operation %q",
+		method, path, operationId, method, conflictingPath, conflictingOperationId)
+}
+func wildcardOriginWithCredentials() string {
+	return `CORS credentials can't be allowed for the "*" origin, list the allowed origins explicitly`
+}
+func invalidRoute(method string, path string, operationId string, err error) string {
+	return fmt.Sprintf("failed registering route %s %s of operation %q: %s", method, path, operationId, err)
+}
 func notImplementedSpecOperations(count int) string {
 	return fmt.Sprintf("%d of the spec operations are missing handlers", count)
 }
@@ -59,3 +84,6 @@ func paramMissingImplementationInChain(in string, name string, operationId strin
 func anExcludedOperationIsImplemented(operationId string) string {
 	return fmt.Sprintf("the excluded operation %q is implemented by a handler", operationId)
 }
+func invalidRuntimeValidateResponsesSamplePercentage(percentage float64, operationId string) string {
+	return fmt.Sprintf("runtime responses validation sample percentage %v of operation %q is not between 0 and 100", percentage, operationId)
+}
@@ -0,0 +1,559 @@
+package router
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// bindGenericValue binds a value in the generic form used for validating values with the spec to the target the same
+// way encoding/json decodes the JSON encoding of the value to the target.
+// It lets the binders reuse the request body decoded for validating it instead of decoding the body again.
+// Values of types that implement json.Unmarshaler or encoding.TextUnmarshaler are decoded with encoding/json.
+// Object properties are bound in the order of their sorted keys, the order encoding/json encodes maps in, so properties
+// that match the same struct field (e.g. "Name" and "name") are bound deterministically like decoding the encoding of
+// the value.
+func bindGenericValue(value any, target any) error {
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Pointer || targetValue.IsNil() {
+		return &json.InvalidUnmarshalError{Type: reflect.TypeOf(target)}
+	}
+	return bindValue(value, targetValue.Elem(), bindingPath{})
+}
+
+// bindingPath is the location of the bound value reported with type errors.
+type bindingPath struct {
+	structName string
+	field      string
+}
+
+func (p bindingPath) withField(structType reflect.Type, name string) bindingPath {
+	if p.field != "" {
+		name = p.field + "." + name
+	}
+	return bindingPath{structName: structType.Name(), field: name}
+}
+
+func (p bindingPath) typeError(value any, targetType reflect.Type) error {
+	return &json.UnmarshalTypeError{Value: genericValueKind(value), Type: targetType, Struct: p.structName, Field: p.field}
+}
+
+// bindValue binds the value to the addressable target.
+func bindValue(value any, target reflect.Value, path bindingPath) error {
+	targetType := target.Type()
+	if !isGenericValue(value) || implementsUnmarshaler(targetType) {
+		return bindWithEncodingJSON(value, target)
+	}
+	switch targetType.Kind() {
+	case reflect.Pointer:
+		if value == nil {
+			target.Set(reflect.Zero(targetType))
+			return nil
+		}
+		if target.IsNil() {
+			target.Set(reflect.New(targetType.Elem()))
+		}
+		return bindValue(value, target.Elem(), path)
+	case reflect.Interface:
+		if targetType.NumMethod() > 0 || !target.IsNil() {
+			return bindWithEncodingJSON(value, target)
+		}
+		if value == nil {
+			return nil
+		}
+		normalized, err := normalizeGenericValue(value)
+		if err != nil {
+			return err
+		}
+		target.Set(reflect.ValueOf(normalized))
+		return nil
+	}
+	// like encoding/json, null leaves values that are not pointers, interfaces, maps or slices unchanged
+	if value == nil {
+		switch targetType.Kind() {
+		case reflect.Map, reflect.Slice:
+			target.Set(reflect.Zero(targetType))
+		}
+		return nil
+	}
+	switch targetType.Kind() {
+	case reflect.Struct:
+		return bindStruct(value, target, path)
+	case reflect.Map:
+		return bindMap(value, target, path)
+	case reflect.Slice:
+		return bindSlice(value, target, path)
+	case reflect.Array:
+		array, ok := value.([]any)
+		if !ok {
+			return path.typeError(value, targetType)
+		}
+		for i := 0; i < target.Len(); i++ {
+			if i >= len(array) {
+				target.Index(i).Set(reflect.Zero(targetType.Elem()))
+				continue
+			}
+			if err := bindValue(array[i], target.Index(i), path); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return path.typeError(value, targetType)
+		}
+		target.SetString(s)
+		return nil
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return path.typeError(value, targetType)
+		}
+		target.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, ok := genericNumber(value)
+		if !ok {
+			return path.typeError(value, targetType)
+		}
+		n, err := strconv.ParseInt(number, 10, 64)
+		if err != nil || target.OverflowInt(n) {
+			return path.typeError(value, targetType)
+		}
+		target.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		number, ok := genericNumber(value)
+		if !ok {
+			return path.typeError(value, targetType)
+		}
+		n, err := strconv.ParseUint(number, 10, 64)
+		if err != nil || target.OverflowUint(n) {
+			return path.typeError(value, targetType)
+		}
+		target.SetUint(n)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		number, ok := genericNumber(value)
+		if !ok {
+			return path.typeError(value, targetType)
+		}
+		n, err := strconv.ParseFloat(number, targetType.Bits())
+		if err != nil || target.OverflowFloat(n) {
+			return path.typeError(value, targetType)
+		}
+		target.SetFloat(n)
+		return nil
+	}
+	return bindWithEncodingJSON(value, target)
+}
+
+func bindStruct(value any, target reflect.Value, path bindingPath) error {
+	object, ok := value.(map[string]any)
+	if !ok {
+		return path.typeError(value, target.Type())
+	}
+	fields := cachedJSONFields(target.Type())
+	for _, key := range sortedKeys(object) {
+		propertyValue := object[key]
+		field, found := fields.lookup(key)
+		if !found {
+			continue
+		}
+		fieldTarget, err := structFieldByIndex(target, field.index)
+		if err != nil {
+			return err
+		}
+		if field.quoted {
+			err = bindQuotedValue(propertyValue, fieldTarget)
+		} else {
+			err = bindValue(propertyValue, fieldTarget, path.withField(target.Type(), field.name))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// structFieldByIndex returns the struct field with the index and allocates the embedded pointers on its way like
+// encoding/json does.
+func structFieldByIndex(target reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && target.Kind() == reflect.Pointer {
+			if target.IsNil() {
+				if !target.CanSet() {
+					return reflect.Value{}, fmt.Errorf("json: cannot set embedded pointer to unexported struct: %v", target.Type().Elem())
+				}
+				target.Set(reflect.New(target.Type().Elem()))
+			}
+			target = target.Elem()
+		}
+		target = target.Field(x)
+	}
+	return target, nil
+}
+
+// bindQuotedValue binds the value of a field with the ",string" json tag option that is encoded inside a string.
+func bindQuotedValue(value any, target reflect.Value) error {
+	if value == nil {
+		return nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("json: invalid use of ,string struct tag, trying to unmarshal unquoted value into %v", target.Type())
+	}
+	if err := json.Unmarshal([]byte(s), target.Addr().Interface()); err != nil {
+		return fmt.Errorf("json: invalid use of ,string struct tag, trying to unmarshal %q into %v", s, target.Type())
+	}
+	return nil
+}
+
+func bindMap(value any, target reflect.Value, path bindingPath) error {
+	targetType := target.Type()
+	object, ok := value.(map[string]any)
+	if !ok {
+		return path.typeError(value, targetType)
+	}
+	keyType := targetType.Key()
+	switch keyType.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+	default:
+		if !reflect.PointerTo(keyType).Implements(textUnmarshalerType) {
+			return path.typeError(value, targetType)
+		}
+	}
+	if target.IsNil() {
+		target.Set(reflect.MakeMapWithSize(targetType, len(object)))
+	}
+	for _, key := range sortedKeys(object) {
+		elem := reflect.New(targetType.Elem()).Elem()
+		if err := bindValue(object[key], elem, path); err != nil {
+			return err
+		}
+		keyValue, err := mapKey(key, keyType)
+		if err != nil {
+			return err
+		}
+		target.SetMapIndex(keyValue, elem)
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of the object in the order encoding/json encodes them.
+func sortedKeys(object map[string]any) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mapKey converts the object key to the map key type like encoding/json does.
+func mapKey(key string, keyType reflect.Type) (reflect.Value, error) {
+	if reflect.PointerTo(keyType).Implements(textUnmarshalerType) {
+		keyValue := reflect.New(keyType)
+		if err := keyValue.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(key)); err != nil {
+			return reflect.Value{}, err
+		}
+		return keyValue.Elem(), nil
+	}
+	keyValue := reflect.New(keyType).Elem()
+	switch keyType.Kind() {
+	case reflect.String:
+		keyValue.SetString(key)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(key, 10, 64)
+		if err != nil || keyValue.OverflowInt(n) {
+			return reflect.Value{}, &json.UnmarshalTypeError{Value: "number " + key, Type: keyType}
+		}
+		keyValue.SetInt(n)
+	default:
+		n, err := strconv.ParseUint(key, 10, 64)
+		if err != nil || keyValue.OverflowUint(n) {
+			return reflect.Value{}, &json.UnmarshalTypeError{Value: "number " + key, Type: keyType}
+		}
+		keyValue.SetUint(n)
+	}
+	return keyValue, nil
+}
+
+func bindSlice(value any, target reflect.Value, path bindingPath) error {
+	targetType := target.Type()
+	// like encoding/json, byte slices are decoded from base64 strings
+	if s, ok := value.(string); ok && targetType.Elem().Kind() == reflect.Uint8 {
+		data, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return err
+		}
+		target.SetBytes(data)
+		return nil
+	}
+	array, ok := value.([]any)
+	if !ok {
+		return path.typeError(value, targetType)
+	}
+	slice := reflect.MakeSlice(targetType, len(array), len(array))
+	for i, item := range array {
+		if err := bindValue(item, slice.Index(i), path); err != nil {
+			return err
+		}
+	}
+	target.Set(slice)
+	return nil
+}
+
+// bindWithEncodingJSON binds the value by decoding its JSON encoding to the addressable target with encoding/json.
+func bindWithEncodingJSON(value any, target reflect.Value) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target.Addr().Interface())
+}
+
+// implementsUnmarshaler checks if values of the type decode themselves with json.Unmarshaler or
+// encoding.TextUnmarshaler.
+func implementsUnmarshaler(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer || t.Kind() == reflect.Interface {
+		return false
+	}
+	pointerType := reflect.PointerTo(t)
+	return pointerType.Implements(jsonUnmarshalerType) || pointerType.Implements(textUnmarshalerType)
+}
+
+// isGenericValue checks if the value is one of the values produced by decoding JSON to any.
+func isGenericValue(value any) bool {
+	switch value.(type) {
+	case nil, bool, string, float64, json.Number, map[string]any, []any:
+		return true
+	}
+	return false
+}
+
+// normalizeGenericValue returns a copy of the value as produced by encoding/json when decoding to any.
+// Numbers decoded as json.Number are converted to float64.
+func normalizeGenericValue(value any) (any, error) {
+	switch value := value.(type) {
+	case json.Number:
+		n, err := strconv.ParseFloat(string(value), 64)
+		if err != nil {
+			return nil, &json.UnmarshalTypeError{Value: "number " + string(value), Type: reflect.TypeOf(n)}
+		}
+		return n, nil
+	case map[string]any:
+		object := make(map[string]any, len(value))
+		for key, propertyValue := range value {
+			normalized, err := normalizeGenericValue(propertyValue)
+			if err != nil {
+				return nil, err
+			}
+			object[key] = normalized
+		}
+		return object, nil
+	case []any:
+		array := make([]any, len(value))
+		for i, item := range value {
+			normalized, err := normalizeGenericValue(item)
+			if err != nil {
+				return nil, err
+			}
+			array[i] = normalized
+		}
+		return array, nil
+	}
+	return value, nil
+}
+
+// genericNumber returns the decimal representation of a number value.
+func genericNumber(value any) (string, bool) {
+	switch value := value.(type) {
+	case json.Number:
+		return string(value), true
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// genericValueKind describes the value in type errors like encoding/json does.
+func genericValueKind(value any) string {
+	switch value := value.(type) {
+	case bool:
+		return "bool"
+	case string:
+		return "string"
+	case json.Number:
+		return "number " + string(value)
+	case float64:
+		return "number " + strconv.FormatFloat(value, 'g', -1, 64)
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// jsonField is a struct field that encoding/json decodes from the object property with its name.
+type jsonField struct {
+	name   string
+	index  []int
+	tagged bool
+	quoted bool
+}
+
+// jsonFields are the fields of a struct type decoded by encoding/json.
+type jsonFields struct {
+	list   []jsonField
+	byName map[string]jsonField
+}
+
+// lookup returns the field of the property like encoding/json does, preferring an exact match over a case-insensitive
+// match.
+func (f jsonFields) lookup(name string) (jsonField, bool) {
+	if field, found := f.byName[name]; found {
+		return field, true
+	}
+	for _, field := range f.list {
+		if strings.EqualFold(field.name, name) {
+			return field, true
+		}
+	}
+	return jsonField{}, false
+}
+
+var jsonFieldsCache sync.Map
+
+func cachedJSONFields(t reflect.Type) jsonFields {
+	if fields, found := jsonFieldsCache.Load(t); found {
+		return fields.(jsonFields)
+	}
+	fields, _ := jsonFieldsCache.LoadOrStore(t, structJSONFields(t))
+	return fields.(jsonFields)
+}
+
+// structJSONFields finds the fields of the struct type following the encoding/json rules for embedded structs and
+// fields with conflicting names.
+func structJSONFields(t reflect.Type) jsonFields {
+	type embedded struct {
+		t     reflect.Type
+		index []int
+	}
+	var candidates []jsonField
+	current := []embedded{{t: t}}
+	visited := map[reflect.Type]bool{}
+	for len(current) > 0 {
+		var next []embedded
+		for _, e := range current {
+			if visited[e.t] {
+				continue
+			}
+			visited[e.t] = true
+			for i := 0; i < e.t.NumField(); i++ {
+				field := e.t.Field(i)
+				fieldType := field.Type
+				if fieldType.Kind() == reflect.Pointer {
+					fieldType = fieldType.Elem()
+				}
+				if field.Anonymous {
+					if !field.IsExported() && fieldType.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !field.IsExported() {
+					continue
+				}
+				tag := field.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, options, _ := strings.Cut(tag, ",")
+				index := append(append([]int{}, e.index...), i)
+				if name == "" && field.Anonymous && fieldType.Kind() == reflect.Struct {
+					next = append(next, embedded{t: fieldType, index: index})
+					continue
+				}
+				quoted := false
+				if strings.Contains(","+options+",", ",string,") {
+					switch fieldType.Kind() {
+					case reflect.Bool, reflect.String,
+						reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+						reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+						reflect.Float32, reflect.Float64:
+						quoted = true
+					}
+				}
+				tagged := name != ""
+				if !tagged {
+					name = field.Name
+				}
+				candidates = append(candidates, jsonField{
+					name:   name,
+					index:  index,
+					tagged: tagged,
+					quoted: quoted,
+				})
+			}
+		}
+		current = next
+	}
+
+	// fields with the same name are dropped unless one of them is shallower or the only tagged one in its depth
+	byName := make(map[string][]jsonField, len(candidates))
+	for _, field := range candidates {
+		byName[field.name] = append(byName[field.name], field)
+	}
+	fields := jsonFields{byName: make(map[string]jsonField, len(byName))}
+	for name, named := range byName {
+		depth := len(named[0].index)
+		dominant := make([]jsonField, 0, len(named))
+		for _, field := range named {
+			if len(field.index) < depth {
+				depth = len(field.index)
+				dominant = dominant[:0]
+			}
+			if len(field.index) == depth {
+				dominant = append(dominant, field)
+			}
+		}
+		if len(dominant) > 1 {
+			tagged := make([]jsonField, 0, len(dominant))
+			for _, field := range dominant {
+				if field.tagged {
+					tagged = append(tagged, field)
+				}
+			}
+			if len(tagged) != 1 {
+				continue
+			}
+			dominant = tagged
+		}
+		fields.byName[name] = dominant[0]
+		fields.list = append(fields.list, dominant[0])
+	}
+	sort.Slice(fields.list, func(i, j int) bool {
+		a, b := fields.list[i].index, fields.list[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return fields
+}
@@ -0,0 +1,204 @@
+package router
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/piiano/cellotape/router/utils"
+)
+
+type genericValueTestEmbedded struct {
+	Embedded string `json:"embedded"`
+	Shadowed string `json:"name"`
+}
+
+type genericValueTestKey string
+
+func (k *genericValueTestKey) UnmarshalText(text []byte) error {
+	*k = genericValueTestKey("key-" + string(text))
+	return nil
+}
+
+type genericValueTestStruct struct {
+	genericValueTestEmbedded
+	*GenericValueTestPointer
+	Name       string                         `json:"name"`
+	Untagged   int                            `json:",omitempty"`
+	Ignored    string                         `json:"-"`
+	Quoted     int64                          `json:"quoted,string"`
+	Float      float32                        `json:"float"`
+	Unsigned   uint8                          `json:"unsigned"`
+	Bool       bool                           `json:"bool"`
+	Pointer    *string                        `json:"pointer"`
+	Any        any                            `json:"any"`
+	Slice      []int                          `json:"slice"`
+	Array      [2]string                      `json:"array"`
+	Bytes      []byte                         `json:"bytes"`
+	Map        map[string]float64             `json:"map"`
+	IntKeys    map[int]bool                   `json:"intKeys"`
+	TextKeys   map[genericValueTestKey]string `json:"textKeys"`
+	Time       time.Time                      `json:"time"`
+	TimePtr    *time.Time                     `json:"timePtr"`
+	RawMessage json.RawMessage                `json:"raw"`
+	Nested     *genericValueTestStruct        `json:"nested"`
+	unexported string
+}
+
+type GenericValueTestPointer struct {
+	Promoted string `json:"promoted"`
+}
+
+func TestBindGenericValueMatchesEncodingJSON(t *testing.T) {
+	testCases := []struct {
+		name   string
+		goType reflect.Type
+		json   string
+	}{
+		{name: "struct", goType: utils.GetType[genericValueTestStruct](), json: `{
+			"name": "foo", "embedded": "bar", "promoted": "baz", "Untagged": 1, "Ignored": "x", "quoted": "42",
+			"float": 1.5, "unsigned": 255, "bool": true, "pointer": "ptr", "any": {"a": [1, "b", null, {"c": 2.5}]},
+			"slice": [1, 2, 3], "array": ["a", "b", "c"], "bytes": "aGVsbG8=", "map": {"x": 1e3}, "intKeys": {"1": true, "-2": false},
+			"textKeys": {"a": "b"}, "time": "2020-01-02T03:04:05Z", "timePtr": "2021-01-02T03:04:05Z", "raw": {"x":[1]},
+			"nested": {"name": "nested", "NESTED": null}, "unexported": "x", "unknown": 1}`},
+		{name: "case insensitive names", goType: utils.GetType[genericValueTestStruct](), json: `{"NAME": "foo", "untagged": 2, "Bool": true}`},
+		{name: "nulls", goType: utils.GetType[genericValueTestStruct](), json: `{"name": null, "pointer": null, "slice": null, "map": null, "any": null, "time": null, "timePtr": null}`},
+		{name: "empty collections", goType: utils.GetType[genericValueTestStruct](), json: `{"slice": [], "map": {}, "array": []}`},
+		{name: "any", goType: utils.GetType[any](), json: `[1, 2.5, "a", true, null, {"b": [{}]}]`},
+		{name: "map of any", goType: utils.GetType[map[string]any](), json: `{"a": 1, "b": {"c": [1]}}`},
+		{name: "int", goType: utils.GetType[int](), json: `42`},
+		{name: "big int", goType: utils.GetType[int64](), json: `9007199254740993`},
+		{name: "pointer to pointer", goType: utils.GetType[**string](), json: `"foo"`},
+		{name: "multi type", goType: utils.GetType[utils.MultiType[struct {
+			String *string
+			Int    *int
+		}]](), json: `"foo"`},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			expected := reflect.New(test.goType)
+			require.NoError(t, json.Unmarshal([]byte(test.json), expected.Interface()))
+
+			value, err := decodeJSONValue([]byte(test.json))
+			require.NoError(t, err)
+			actual := reflect.New(test.goType)
+			require.NoError(t, bindGenericValue(value, actual.Interface()))
+			assert.Equal(t, expected.Elem().Interface(), actual.Elem().Interface())
+
+			var floatValue any
+			require.NoError(t, json.Unmarshal([]byte(test.json), &floatValue))
+			if test.name == "big int" {
+				// float64 values can't hold the number
+				return
+			}
+			actual = reflect.New(test.goType)
+			require.NoError(t, bindGenericValue(floatValue, actual.Interface()))
+			assert.Equal(t, expected.Elem().Interface(), actual.Elem().Interface())
+		})
+	}
+}
+
+func TestBindGenericValueErrors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		target any
+		json   string
+	}{
+		{name: "string to int", target: new(int), json: `"foo"`},
+		{name: "float to int", target: new(int), json: `1.5`},
+		{name: "overflow", target: new(int8), json: `128`},
+		{name: "negative to unsigned", target: new(uint), json: `-1`},
+		{name: "object to slice", target: new([]int), json: `{}`},
+		{name: "array to struct", target: new(genericValueTestStruct), json: `[]`},
+		{name: "nested field", target: new(genericValueTestStruct), json: `{"nested": {"slice": ["a"]}}`},
+		{name: "unquoted value with string option", target: new(genericValueTestStruct), json: `{"quoted": 42}`},
+		{name: "invalid time", target: new(genericValueTestStruct), json: `{"time": "foo"}`},
+		{name: "invalid map key", target: new(map[int]string), json: `{"a": "b"}`},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			require.Error(t, json.Unmarshal([]byte(test.json), reflect.New(reflect.TypeOf(test.target).Elem()).Interface()))
+			value, err := decodeJSONValue([]byte(test.json))
+			require.NoError(t, err)
+			require.Error(t, bindGenericValue(value, test.target))
+		})
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	value, err := decodeJSONValue([]byte(`{"nested": {"slice": ["a"]}}`))
+	require.NoError(t, err)
+	require.ErrorAs(t, bindGenericValue(value, new(genericValueTestStruct)), &typeErr)
+	assert.Equal(t, "nested.slice", typeErr.Field)
+
+	require.Error(t, bindGenericValue("foo", genericValueTestStruct{}))
+}
+
+func TestBindGenericValueWithFoldedKeys(t *testing.T) {
+	value, err := decodeJSONValue([]byte(`{"name": "a", "NAME": "b", "Name": "c", "quoted": "1", "Quoted": 2}`))
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		assertBindGenericValueMatchesEncodingJSON(t, value, utils.GetType[genericValueTestStruct]())
+	}
+}
+
+// assertBindGenericValueMatchesEncodingJSON checks that binding the value to the type has the same result as decoding
+// the JSON encoding of the value with encoding/json.
+func assertBindGenericValueMatchesEncodingJSON(t *testing.T, value any, goType reflect.Type) {
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+	expected := reflect.New(goType)
+	expectedErr := json.Unmarshal(data, expected.Interface())
+	actual := reflect.New(goType)
+	actualErr := bindGenericValue(value, actual.Interface())
+	if expectedErr != nil {
+		require.Errorf(t, actualErr, "%s to %s", data, goType)
+		return
+	}
+	require.NoErrorf(t, actualErr, "%s to %s", data, goType)
+	assert.Equalf(t, expected.Elem().Interface(), actual.Elem().Interface(), "%s to %s", data, goType)
+}
+
+func FuzzBindGenericValue(f *testing.F) {
+	for _, seed := range []string{
+		`{"name": "foo", "embedded": "bar", "promoted": "baz", "Untagged": 1, "quoted": "42", "float": 1.5}`,
+		`{"Name": 1, "name": "x"}`,
+		`{"nested": {"slice": [1, 2], "map": {"a": 1e3}, "intKeys": {"1": true}}, "NESTED": null}`,
+		`{"bytes": "aGVsbG8=", "array": ["a"], "time": "2020-01-02T03:04:05Z", "raw": [1, {}]}`,
+		`{"unsigned": 256, "quoted": 42, "textKeys": {"a": "b"}, "pointer": null, "any": [null, true]}`,
+		`[1, "a", {"b": null}]`,
+	} {
+		f.Add([]byte(seed))
+	}
+	goTypes := []reflect.Type{
+		utils.GetType[genericValueTestStruct](),
+		utils.GetType[map[string]any](),
+		utils.GetType[map[int]int8](),
+		utils.GetType[[]genericValueTestStruct](),
+		utils.GetType[any](),
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		value, err := decodeJSONValue(data)
+		if err != nil {
+			return
+		}
+		for _, goType := range goTypes {
+			assertBindGenericValueMatchesEncodingJSON(t, value, goType)
+		}
+	})
+}
+
+func TestBindGenericValueCopiesValues(t *testing.T) {
+	value := map[string]any{"a": []any{json.Number("1")}}
+	var first, second map[string]any
+	require.NoError(t, bindGenericValue(value, &first))
+	require.NoError(t, bindGenericValue(value, &second))
+	first["a"].([]any)[0] = "changed"
+	assert.Equal(t, map[string]any{"a": []any{float64(1)}}, second)
+	assert.Equal(t, map[string]any{"a": []any{json.Number("1")}}, value)
+}
@@ -3,8 +3,6 @@ package router
 import (
 	"net/http"
 
-	"github.com/julienschmidt/httprouter"
-
 	"github.com/piiano/cellotape/router/utils"
 )
 
@@ -17,7 +15,7 @@ type Handler interface {
 	requestTypes() requestTypes
 	responseTypes() handlerResponses
 	sourcePosition() sourcePosition
-	handlerFactory(oa openapi, next BoundHandlerFunc) BoundHandlerFunc
+	handlerFactory(oa openapi, operationID string, next BoundHandlerFunc) BoundHandlerFunc
 }
 
 // HandlerFunc is the typed handler that declare explicitly all types of request and responses.
@@ -34,10 +32,19 @@ type Context struct {
 	Operation   SpecOperation
 	Writer      http.ResponseWriter
 	Request     *http.Request
-	Params      *httprouter.Params
+	Params      PathParams
 	RawResponse *RawResponse
 	NextFunc    BoundHandlerFunc
 	Durations   HTTPDurations
+	// principal is the authenticated principal of the request set with SetPrincipal.
+	principal any
+	// requestBodyValidator is the request body validator compiled for the operation when creating the router.
+	// It is nil if the request body is validated only with kin-openapi.
+	requestBodyValidator *requestBodyValidator
+	// requestBodyValue is the request body decoded and validated with the spec, with its default values populated.
+	// It is set only when requestBodyDecoded is true, to bind the body without decoding it again.
+	requestBodyValue   any
+	requestBodyDecoded bool
 }
 
 func (c *Context) Next() (RawResponse, error) {
@@ -87,7 +94,7 @@ type RawResponse struct {
 	Status int
 	// ContentType is the content type used to write the response
 	ContentType string
-	// buffered Body bytes written by calls to Write
+	// buffered Body bytes written by calls to Write.
 	Body []byte
 	// response Headers
 	Headers http.Header
@@ -116,9 +123,9 @@ func (h HandlerFunc[B, P, Q, R]) sourcePosition() sourcePosition {
 	return functionSourcePosition(h)
 }
 
-func (h HandlerFunc[B, P, Q, R]) handlerFactory(oa openapi, next BoundHandlerFunc) BoundHandlerFunc {
-	bindRequest := requestBinderFactory[B, P, Q](oa, h.requestTypes())
-	bindResponse := responseBinderFactory[R](h.responseTypes(), oa.contentTypes, oa.options.DefaultOperationValidation.RuntimeValidateResponses)
+func (h HandlerFunc[B, P, Q, R]) handlerFactory(oa openapi, operationID string, next BoundHandlerFunc) BoundHandlerFunc {
+	bindRequest := requestBinderFactory[B, P, Q](oa, operationID, h.requestTypes())
+	bindResponse := responseBinderFactory[R](h.responseTypes(), oa.contentTypes, oa.options, operationID)
 	return func(context *Context) (RawResponse, error) {
 		// when handler will be called, set the next to next
 		context.NextFunc = next
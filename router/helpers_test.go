@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"testing"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -14,6 +15,37 @@ type OKResponse[R any] struct {
 	OK R `status:"200"`
 }
 
+// newTestSpec returns a valid spec with the title and the paths.
+func newTestSpec(title string, paths ...openapi3.NewPathsOption) OpenAPISpec {
+	return OpenAPISpec(openapi3.T{
+		OpenAPI: "3.0.3",
+		Info:    &openapi3.Info{Title: title, Version: "1.0.0"},
+		Paths:   openapi3.NewPaths(paths...),
+	})
+}
+
+// okOperationsRouter returns a router of the spec that implements each of the operations with a handler that responds
+// with status 200 and no content.
+func okOperationsRouter(spec OpenAPISpec, options Options, ids ...string) OpenAPIRouter {
+	router := NewOpenAPIRouterWithOptions(spec, options)
+	for _, id := range ids {
+		router.WithOperation(id, HandlerFunc[Nil, Nil, Nil, OKResponse[Nil]](func(_ *Context, _ Request[Nil, Nil, Nil]) (Response[OKResponse[Nil]], error) {
+			return SendOK(OKResponse[Nil]{}), nil
+		}))
+	}
+	return router
+}
+
+// testSpecOperation returns an operation with the id and a 200 response with no content.
+func testSpecOperation(id string) *openapi3.Operation {
+	operation := openapi3.NewOperation()
+	operation.OperationID = id
+	operation.Responses = openapi3.NewResponses(openapi3.WithStatus(200, &openapi3.ResponseRef{
+		Value: openapi3.NewResponse().WithDescription("ok"),
+	}))
+	return operation
+}
+
 func TestSimpleSend(t *testing.T) {
 	response := Send(OKResponse[string]{OK: "ok"})
 	assert.Equal(t, "ok", response.response.OK)
@@ -124,7 +156,7 @@ func TestRawHandler(t *testing.T) {
 		Body:        []byte("test"),
 		Headers:     nil,
 	}
-	handlerFunc := rawHandler.handlerFactory(openapi{}, func(c *Context) (RawResponse, error) {
+	handlerFunc := rawHandler.handlerFactory(openapi{}, "", func(c *Context) (RawResponse, error) {
 		return rawResponse, nil
 	})
 	resp, err := handlerFunc(testContext())
@@ -12,8 +12,15 @@ type openapi struct {
 	options Options
 	// contentTypes added with WithContentType
 	contentTypes ContentTypes
+	// authenticators of security schemes added with WithAuthenticator
+	authenticators map[string]Authenticator
 	// group hold internal resources added by OpenAPIRouter.Use, OpenAPIRouter.WithGroup and OpenAPIRouter.WithOperation
 	group
+	// typeSchemaErrors collects the structured errors of handler types that are incompatible with their schemas when
+	// validating the router with the spec
+	typeSchemaErrors *TypeSchemaErrors
+	// report collects the errors and warnings found when validating the router with the spec
+	report *ValidationReport
 }
 
 // group describes the internal state of the Group builder
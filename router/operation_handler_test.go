@@ -6,11 +6,14 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/piiano/cellotape/router/schema_validator"
 	"github.com/piiano/cellotape/router/utils"
 )
 
@@ -80,3 +83,214 @@ func toString(reader io.Reader) (string, error) {
 	}
 	return buf.String(), nil
 }
+
+func TestRouterAsHandlerWithPatternProperties(t *testing.T) {
+	type labels struct {
+		Name   string            `json:"name"`
+		Labels map[string]string `json:",inline"`
+	}
+	type responses struct {
+		OK labels `status:"200"`
+	}
+	fn := HandlerFunc[utils.Nil, utils.Nil, utils.Nil, responses](func(*Context, Request[utils.Nil, utils.Nil, utils.Nil]) (Response[responses], error) {
+		return SendOKJSON(responses{OK: labels{Name: "foo"}}), nil
+	})
+	spec, err := NewSpecFromData([]byte(`
+  { "openapi": "3.0.3", "info": { "title": "test", "version": "1.0.0" }, "paths": { "/labels": { "get": {
+    "operationId": "id",
+    "responses":{ "200": { "description": "ok", "content": { "application/json": { "schema": {
+      "type": "object",
+      "properties": { "name": { "type": "string" } },
+      "patternProperties": { "^label-": { "type": "string" } },
+      "additionalProperties": false
+    } } } } }
+  } } } }`))
+	require.NoError(t, err)
+	options := DefaultOptions()
+	options.LogOutput = bytes.NewBuffer([]byte{})
+	// encoding/json serializes the inline field as an ordinary "Labels" property that is not allowed by the schema
+	_, err = NewOpenAPIRouterWithOptions(spec, options).WithOperation("id", fn).AsHandler()
+	require.Error(t, err)
+	_, err = NewOpenAPIRouterWithOptions(spec, options).WithContentType(catchAllJSONContentType{}).WithOperation("id", fn).AsHandler()
+	require.Error(t, err)
+
+	spec.Paths.Value("/labels").Get.Responses.Status(200).Value.Content.Get("application/json").Schema.Value.AdditionalProperties.Has = nil
+	_, err = NewOpenAPIRouterWithOptions(spec, options).WithContentType(catchAllJSONContentType{}).WithOperation("id", fn).AsHandler()
+	require.NoError(t, err)
+}
+
+func TestRouterAsHandlerWithAdditionalPropertiesRequestBody(t *testing.T) {
+	type task struct {
+		Name string `json:"name"`
+	}
+	fn := HandlerFunc[task, utils.Nil, utils.Nil, OKResponse[utils.Nil]](func(*Context, Request[task, utils.Nil, utils.Nil]) (Response[OKResponse[utils.Nil]], error) {
+		return SendOK(OKResponse[utils.Nil]{}), nil
+	})
+	spec, err := NewSpecFromData([]byte(`
+  { "openapi": "3.0.3", "info": { "title": "test", "version": "1.0.0" }, "paths": { "/tasks": { "post": {
+    "operationId": "id",
+    "requestBody": { "content": { "application/json": { "schema": {
+      "type": "object",
+      "properties": { "name": { "type": "string" } },
+      "patternProperties": { "^label-": { "type": "string" } },
+      "additionalProperties": { "type": "string" }
+    } } } },
+    "responses":{ "200": { "description": "ok" } }
+  } } } }`))
+	require.NoError(t, err)
+	options := DefaultOptions()
+	options.LogOutput = bytes.NewBuffer([]byte{})
+	// encoding/json has no catch-all fields, so the additional properties of the request are dropped by design
+	_, err = NewOpenAPIRouterWithOptions(spec, options).WithOperation("id", fn).AsHandler()
+	require.NoError(t, err)
+	_, err = NewOpenAPIRouterWithOptions(spec, options).WithContentType(catchAllJSONContentType{}).WithOperation("id", fn).AsHandler()
+	require.Error(t, err)
+}
+
+// catchAllJSONContentType is a JSON content type that validates types with catch-all fields like encoding/json/v2.
+type catchAllJSONContentType struct {
+	JSONContentType
+}
+
+func (catchAllJSONContentType) TypeSchemaValidator(goType reflect.Type, schema openapi3.Schema) schema_validator.TypeSchemaValidator {
+	return schema_validator.NewTypeSchemaValidator(goType, schema).WithCatchAllFields(true)
+}
+
+func TestRouterAsHandlerWithRecursiveSchema(t *testing.T) {
+	type comment struct {
+		Text    string    `json:"text"`
+		Replies []comment `json:"replies,omitempty"`
+	}
+	type responses struct {
+		OK comment `status:"200"`
+	}
+	fn := HandlerFunc[comment, utils.Nil, utils.Nil, responses](func(_ *Context, request Request[comment, utils.Nil, utils.Nil]) (Response[responses], error) {
+		return SendOKJSON(responses{OK: request.Body}), nil
+	})
+	spec, err := NewSpecFromData([]byte(`
+  { "openapi": "3.0.3", "info": { "title": "test", "version": "1.0.0" }, "paths": { "/comments": { "post": {
+    "operationId": "id",
+    "requestBody": { "content": { "application/json": { "schema": { "$ref": "#/components/schemas/Comment" } } } },
+    "responses":{ "200": { "description": "ok", "content": { "application/json": { "schema": { "$ref": "#/components/schemas/Comment" } } } } }
+  } } },
+  "components": { "schemas": { "Comment": {
+    "type": "object",
+    "properties": {
+      "text": { "type": "string" },
+      "replies": { "type": "array", "items": { "$ref": "#/components/schemas/Comment" } }
+    }
+  } } } }`))
+	require.NoError(t, err)
+	options := DefaultOptions()
+	options.LogOutput = bytes.NewBuffer([]byte{})
+	h, err := NewOpenAPIRouterWithOptions(spec, options).WithOperation("id", fn).AsHandler()
+	require.NoError(t, err)
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+
+	body := `{"text":"a","replies":[{"text":"b","replies":[{"text":"c"}]}]}`
+	resp, err := http.Post(fmt.Sprintf("%s/comments", ts.URL), "application/json", bytes.NewBufferString(body))
+	require.NoError(t, err)
+	res, err := toString(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.JSONEq(t, body, res)
+
+	resp, err = http.Post(fmt.Sprintf("%s/comments", ts.URL), "application/json", bytes.NewBufferString(`{"text":"a","replies":[{"text":1}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+}
+
+func TestRouterRuntimeValidateRequestsBehaviours(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+	}
+	type pathParams struct {
+		ID int `uri:"id"`
+	}
+	type queryParams struct {
+		Limit int `form:"limit"`
+	}
+	type responses struct {
+		OK item `status:"200"`
+	}
+	spec, err := NewSpecFromData([]byte(`
+  { "openapi": "3.0.3", "info": { "title": "test", "version": "1.0.0" }, "paths": { "/items/{id}": { "post": {
+    "operationId": "createItem",
+    "parameters": [
+      { "name": "id", "in": "path", "required": true, "schema": { "type": "integer", "minimum": 1 } },
+      { "name": "limit", "in": "query", "schema": { "type": "integer", "maximum": 10 } }
+    ],
+    "requestBody": { "content": { "application/json": { "schema": {
+      "type": "object", "required": ["name"], "properties": { "name": { "type": "string", "minLength": 2 } }
+    } } } },
+    "responses":{ "200": { "description": "ok", "content": { "application/json": { "schema": {
+      "type": "object", "properties": { "name": { "type": "string" } }
+    } } } } }
+  } } } }`))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		path     string
+		body     string
+		violates bool
+		in       In
+	}{
+		{name: "valid request", path: "/items/1?limit=5", body: `{"name":"foo"}`},
+		{name: "invalid body", path: "/items/1?limit=5", body: `{"name":"f"}`, violates: true, in: InBody},
+		{name: "invalid path param", path: "/items/0?limit=5", body: `{"name":"foo"}`, violates: true, in: InPathParams},
+		{name: "invalid query param", path: "/items/1?limit=20", body: `{"name":"foo"}`, violates: true, in: InQueryParams},
+	}
+
+	for _, behaviour := range []Behaviour{PropagateError, PrintWarning, Ignore} {
+		behaviourName, err := behaviour.MarshalText()
+		require.NoError(t, err)
+		var violations []RuntimeValidationViolation
+		options := DefaultOptions()
+		options.LogOutput = bytes.NewBuffer([]byte{})
+		operationValidation := options.DefaultOperationValidation
+		operationValidation.RuntimeValidateRequests = utils.Ptr(behaviour)
+		options.OperationValidations = map[string]OperationValidationOptions{"createItem": operationValidation}
+		options.RuntimeValidationViolationHandler = func(violation RuntimeValidationViolation) {
+			violations = append(violations, violation)
+		}
+
+		var called bool
+		fn := HandlerFunc[item, pathParams, queryParams, responses](func(_ *Context, request Request[item, pathParams, queryParams]) (Response[responses], error) {
+			called = true
+			return SendOKJSON(responses{OK: request.Body}), nil
+		})
+		h, err := NewOpenAPIRouterWithOptions(spec, options).WithOperation("createItem", fn).AsHandler()
+		require.NoError(t, err)
+
+		for _, test := range testCases {
+			t.Run(fmt.Sprintf("%s with %s behaviour", test.name, behaviourName), func(t *testing.T) {
+				called, violations = false, nil
+				recorder := httptest.NewRecorder()
+				request := httptest.NewRequest(http.MethodPost, test.path, bytes.NewBufferString(test.body))
+				request.Header.Set("Content-Type", "application/json")
+				h.ServeHTTP(recorder, request)
+
+				reported := test.violates && behaviour != Ignore
+				if reported {
+					require.Len(t, violations, 1)
+					assert.Equal(t, test.in, violations[0].In)
+					assert.False(t, violations[0].Response)
+					assert.Equal(t, "createItem", violations[0].Context.Operation.OperationID)
+				} else {
+					assert.Empty(t, violations)
+				}
+
+				if test.violates && behaviour == PropagateError {
+					assert.Equal(t, http.StatusBadRequest, recorder.Code)
+					assert.False(t, called)
+					return
+				}
+				assert.Equal(t, http.StatusOK, recorder.Code)
+				assert.True(t, called)
+				assert.JSONEq(t, test.body, recorder.Body.String())
+			})
+		}
+	}
+}
@@ -3,9 +3,12 @@ package router
 import (
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"os"
 
+	"github.com/invopop/jsonschema"
+
 	"github.com/piiano/cellotape/router/utils"
 )
 
@@ -68,6 +71,11 @@ func (b *Behaviour) UnmarshalText(data []byte) error {
 	return nil
 }
 
+// JSONSchema describes behaviours with their text values in the options schema.
+func (Behaviour) JSONSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{Type: "string", Enum: []any{"propagate-error", "print-warning", "ignore"}}
+}
+
 // Options defines the behaviour of the OpenAPI router
 type Options struct {
 
@@ -83,22 +91,31 @@ type Options struct {
 
 	// LogLevel defines what log levels should be printed to LogOutput.
 	// By default, LogLevel is set to utils.Info to print all info to the log.
-	// The router prints to the log only during initialization to show validation errors, warnings and useful info.
-	// No printing is done after initialization.
+	// The router prints to the log during initialization to show validation errors, warnings and useful info.
+	// After initialization, runtime diagnostics are printed to the log unless RuntimeLogger is set.
 	LogLevel LogLevel `json:"logLevel,omitempty"`
 
 	// LogOutput defines where to write the outputs too.
 	// By default, it is set to write to os.Stderr.
 	// You can provide your own writer to be able to read the validation errors programmatically or to write them to
 	// different destination.
-	// The router prints to the log only during initialization to show validation errors, warnings and useful info.
-	// No printing is done after initialization.
+	// The router prints to the log during initialization to show validation errors, warnings and useful info.
+	// After initialization, runtime diagnostics are printed to the log unless RuntimeLogger is set.
 	LogOutput io.Writer `json:"-"`
 
+	// RuntimeLogger logs diagnostics while handling requests such as runtime validation warnings and recovered panics
+	// with structured fields (operation id, method, path, status and error).
+	// A *slog.Logger can be used as a RuntimeLogger.
+	// By default, runtime diagnostics are printed to LogOutput according to LogLevel.
+	RuntimeLogger RuntimeLogger `json:"-"`
+
 	// OperationValidations allow defining validation for specific operations using a map of operation id to an
 	// operationValidationOptions structure.
 	// This option is used only to override the default operation validations options defined ny the
 	// DefaultOperationValidation option.
+	// The runtime validation options that are not set (RuntimeValidateRequests, RuntimeValidateResponses,
+	// RuntimeValidateResponsesSamplePercentage and ContentTypesToSkipRuntimeValidation) are taken from
+	// DefaultOperationValidation.
 	OperationValidations map[string]OperationValidationOptions `json:"operationValidations,omitempty"`
 
 	// DefaultOperationValidation defines the default validations run for every operation.
@@ -123,16 +140,104 @@ type Options struct {
 	// OpenAPIRouter.WithContentType to define serialization and deserialization behaviour.
 	HandleAllContentTypes Behaviour `json:"handleAllContentTypes,omitempty"`
 
+	// The router authenticates requests with the security schemes required by their operations in the spec using the
+	// authenticators added with OpenAPIRouter.WithAuthenticator.
+	// HandleAllSecuritySchemes defines the behaviour when a security scheme required by an implemented operation has no
+	// authenticator.
+	// By default, it is set to PropagateError to propagate the error.
+	// With PrintWarning the router still fails closed. Security requirements with a security scheme that has no
+	// authenticator can't be satisfied, so requests that don't satisfy another security requirement of the operation
+	// are rejected with status 401.
+	// With Ignore the router doesn't authenticate requests with security schemes that have no authenticator, so the
+	// application can authenticate them itself (e.g. in a middleware of the operations).
+	HandleAllSecuritySchemes Behaviour `json:"handleAllSecuritySchemes,omitempty"`
+
+	// UseServersBasePaths registers the operations under the base path of every server URL defined in the spec
+	// servers (e.g. "/v2" for "https://api.example.com/v2") instead of at the root.
+	// Servers defined on an operation or on its path item override the spec servers for the operation.
+	// Server variables are expanded to every value of their enum, or to their default value if they have no enum.
+	// By default, it is set to false and the spec servers are ignored.
+	UseServersBasePaths bool `json:"useServersBasePaths,omitempty"`
+
+	// NewRouteMatcher creates the RouteMatcher that matches requests to the routes of the operations.
+	// By default, it is nil and NewHTTPRouterMatcher is used.
+	NewRouteMatcher func() RouteMatcher `json:"-"`
+
 	// ExcludeOperations defined an array of operations that are defined in the spec but are excluded from the implementation.
 	// One use for this option is when your spec defines the entire API of your app but the implementation is spread to multiple microservices.
 	// With this option you can define list of operations that are to be implemented by other microservices.
-	ExcludeOperations []string
+	ExcludeOperations []string `json:"excludeOperations,omitempty"`
 
 	// OptionsHandler defines a handler that is called for every OPTIONS request of any path that is defined in the spec.
 	// This handler allows you to define a response for OPTIONS preflight requests and control the allowed methods or set CORS headers.
 	// The handler will receive the allowed methods in the `Allow` header based on the spec.
 	// Set to nil to disable the automatic handling of OPTIONS requests.
 	OptionsHandler http.Handler `json:"-"`
+
+	// CORS enables handling of cross-origin requests for the allowed origins.
+	// The methods and headers allowed for each path are derived from the operations of the path in the spec, including
+	// header parameters, headers of security schemes and Content-Type for operations with a request body.
+	// Preflight requests are answered by the router and responses to actual requests are decorated with the CORS headers.
+	// By default, it is nil and CORS is left to the application.
+	CORS *CORSOptions `json:"cors,omitempty"`
+
+	// ServeSpec exposes the spec of the router as JSON and YAML documents and an interactive docs page.
+	// By default, it is nil and the spec is not served.
+	ServeSpec *ServeSpecOptions `json:"serveSpec,omitempty"`
+
+	// NotFoundHandler defines a handler that is called for requests that don't match any path defined in the spec.
+	// Use NewErrorResponseHandler to respond with an error body defined in the spec.
+	// Set to nil to respond with status 404 and an empty body.
+	NotFoundHandler http.Handler `json:"-"`
+
+	// MethodNotAllowedHandler defines a handler that is called for requests to a path defined in the spec with a method
+	// that is not defined for the path.
+	// The handler will receive the allowed methods in the `Allow` header based on the spec.
+	// Use NewErrorResponseHandler to respond with an error body defined in the spec.
+	// Set to nil to respond to these requests with the NotFoundHandler.
+	MethodNotAllowedHandler http.Handler `json:"-"`
+
+	// RuntimeValidationViolationHandler is called for every request or response that violates the spec at runtime
+	// unless its validation behaviour is Ignore.
+	// Use it to report violations to metrics or to your own logger.
+	// When it is set, violations with a PrintWarning behaviour are not logged with the RuntimeLogger.
+	RuntimeValidationViolationHandler func(RuntimeValidationViolation) `json:"-"`
+}
+
+// CORSOptions defines the options of the cross-origin requests handling.
+type CORSOptions struct {
+	// AllowedOrigins are the origins that are allowed to make cross-origin requests. Use "*" to allow any origin.
+	AllowedOrigins []string `json:"allowedOrigins,omitempty"`
+
+	// AllowCredentials allows cross-origin requests to include credentials such as cookies and authorization headers.
+	// Credentials can't be allowed together with the "*" origin, so the allowed origins must be listed explicitly.
+	AllowCredentials bool `json:"allowCredentials,omitempty"`
+
+	// MaxAge is the number of seconds the results of a preflight request can be cached. Zero omits the header.
+	MaxAge int `json:"maxAge,omitempty" jsonschema:"minimum=0"`
+
+	// ExposedHeaders are the response headers that the browser exposes to the cross-origin requests.
+	ExposedHeaders []string `json:"exposedHeaders,omitempty"`
+}
+
+// ServeSpecOptions defines the options of serving the spec of the router.
+type ServeSpecOptions struct {
+	// JSONPath is the path the spec is served on as a JSON document. Defaults to "/openapi.json".
+	JSONPath string `json:"jsonPath,omitempty"`
+
+	// YAMLPath is the path the spec is served on as a YAML document. Defaults to "/openapi.yaml".
+	YAMLPath string `json:"yamlPath,omitempty"`
+
+	// DocsPath is the path of the interactive docs page rendered from the JSON document. Defaults to "/docs".
+	// The page is embedded in the router and doesn't load any external resources.
+	DocsPath string `json:"docsPath,omitempty"`
+
+	// DisableDocs disables serving the docs page.
+	DisableDocs bool `json:"disableDocs,omitempty"`
+
+	// StripInternalOperations removes from the served spec the operations marked with "x-internal: true" (on the
+	// operation or on its path item) and the operations excluded with Options.ExcludeOperations.
+	StripInternalOperations bool `json:"stripInternalOperations,omitempty"`
 }
 
 // OperationValidationOptions defines options to control operation validations
@@ -158,13 +263,40 @@ type OperationValidationOptions struct {
 	// HandleAllOperationResponses describes the behaviour when not every response defined in the spec is handled at least once in the handlers chain
 	HandleAllOperationResponses Behaviour `json:"handleAllOperationResponses,omitempty"`
 
+	// ForbidWriteOnlyPropertiesInResponses determines whether a response type with a field mapped to a writeOnly schema
+	// property is considered incompatible with the spec. Disabled by default.
+	// Regardless of this option, response types don't have to map writeOnly properties, and request body types don't
+	// have to map readOnly properties.
+	ForbidWriteOnlyPropertiesInResponses bool `json:"forbidWriteOnlyPropertiesInResponses,omitempty"`
+
+	// RuntimeValidateRequests defines the behaviour when a request violates the spec at runtime.
+	//   - PropagateError - the request is rejected with a BadRequestErr. This is the default behaviour.
+	//   - PrintWarning - the violation is reported and the request is passed as received to the handlers. This is useful
+	//     during migrations to find invalid requests without rejecting them.
+	//   - Ignore - requests are not validated at runtime.
+	//
+	// Violations are reported to Options.RuntimeValidationViolationHandler when it is set, or printed as warnings to the
+	// log otherwise.
+	// When it is nil, operations use the behaviour of Options.DefaultOperationValidation and PropagateError is used if
+	// it is nil there as well.
+	RuntimeValidateRequests *Behaviour `json:"runtimeValidateRequests,omitempty"`
+
 	// ContentTypesToSkipRuntimeValidation defines a list of content types that are skipped when validating operation request body at runtime.
 	ContentTypesToSkipRuntimeValidation []string `json:"contentTypesToSkipRuntimeValidation,omitempty"`
 
 	// RuntimeValidateResponses defines the behaviour when validating operation response body at runtime. Printing a warning to
 	// the log by default. It is recommended to turn this option to Ignore in production as it can impact performance for large
 	// responses, and to be used in development and testing environments.
-	RuntimeValidateResponses Behaviour `json:"runtimeValidateResponses,omitempty"`
+	// When it is nil, operations use the behaviour of Options.DefaultOperationValidation and PropagateError is used if
+	// it is nil there as well.
+	RuntimeValidateResponses *Behaviour `json:"runtimeValidateResponses,omitempty"`
+
+	// RuntimeValidateResponsesSamplePercentage defines the percentage (0-100) of responses validated at runtime when
+	// RuntimeValidateResponses is not Ignore. Validating only a sample of the responses allows leaving the validation on in
+	// production for critical operations without paying its cost on every request.
+	// The zero value validates every response, the same as 100. To not validate responses at all set
+	// RuntimeValidateResponses to Ignore.
+	RuntimeValidateResponsesSamplePercentage float64 `json:"runtimeValidateResponsesSamplePercentage,omitempty" jsonschema:"minimum=0,maximum=100"`
 }
 
 // SchemaValidationOptions defines options to control schema validations
@@ -191,19 +323,65 @@ func DefaultOptions() Options {
 			ValidateQueryParams:                 PropagateError,
 			ValidateResponses:                   PropagateError,
 			HandleAllOperationResponses:         PropagateError,
+			RuntimeValidateRequests:             utils.Ptr(PropagateError),
 			ContentTypesToSkipRuntimeValidation: []string{PlainTextContentType{}.Mime(), OctetStreamContentType{}.Mime()},
-			RuntimeValidateResponses:            PrintWarning,
+			RuntimeValidateResponses:            utils.Ptr(PrintWarning),
 		},
-		MustHandleAllOperations: PropagateError,
-		HandleAllContentTypes:   PropagateError,
-		OptionsHandler:          http.HandlerFunc(DefaultOptionsHandler),
+		MustHandleAllOperations:  PropagateError,
+		HandleAllContentTypes:    PropagateError,
+		HandleAllSecuritySchemes: PropagateError,
+		OptionsHandler:           http.HandlerFunc(DefaultOptionsHandler),
+		NotFoundHandler:          http.HandlerFunc(DefaultNotFoundHandler),
+		MethodNotAllowedHandler:  http.HandlerFunc(DefaultMethodNotAllowedHandler),
+	}
+}
+
+// sampleRuntimeResponseValidation decides whether a response should be validated at runtime according to
+// RuntimeValidateResponses and RuntimeValidateResponsesSamplePercentage.
+func (o OperationValidationOptions) sampleRuntimeResponseValidation() bool {
+	if o.runtimeValidateResponses() == Ignore {
+		return false
 	}
+	percentage := o.RuntimeValidateResponsesSamplePercentage
+	return percentage <= 0 || percentage >= 100 || rand.Float64()*100 < percentage
 }
 
+// runtimeValidateRequests returns the runtime request validation behaviour or PropagateError if it is not set.
+func (o OperationValidationOptions) runtimeValidateRequests() Behaviour {
+	if o.RuntimeValidateRequests == nil {
+		return PropagateError
+	}
+	return *o.RuntimeValidateRequests
+}
+
+// runtimeValidateResponses returns the runtime response validation behaviour or PropagateError if it is not set.
+func (o OperationValidationOptions) runtimeValidateResponses() Behaviour {
+	if o.RuntimeValidateResponses == nil {
+		return PropagateError
+	}
+	return *o.RuntimeValidateResponses
+}
+
+// operationValidationOptions returns the validation options of the operation.
+// The runtime validation options an operation doesn't set are merged from DefaultOperationValidation, so overriding an
+// operation validation doesn't reset its runtime validation behaviours to PropagateError.
 func (o Options) operationValidationOptions(id string) OperationValidationOptions {
 	options, ok := o.OperationValidations[id]
-	if ok {
-		return options
+	if !ok {
+		return o.DefaultOperationValidation
+	}
+	defaults := o.DefaultOperationValidation
+	if options.RuntimeValidateRequests == nil {
+		options.RuntimeValidateRequests = defaults.RuntimeValidateRequests
+	}
+	if options.RuntimeValidateResponses == nil {
+		options.RuntimeValidateResponses = defaults.RuntimeValidateResponses
+	}
+	if options.RuntimeValidateResponsesSamplePercentage == 0 {
+		options.RuntimeValidateResponsesSamplePercentage = defaults.RuntimeValidateResponsesSamplePercentage
+	}
+	if options.ContentTypesToSkipRuntimeValidation == nil {
+		options.ContentTypesToSkipRuntimeValidation = defaults.ContentTypesToSkipRuntimeValidation
 	}
-	return o.DefaultOperationValidation
+	return options
 }
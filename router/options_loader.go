@@ -0,0 +1,329 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/invopop/jsonschema"
+	"github.com/invopop/yaml"
+
+	"github.com/piiano/cellotape/router/utils"
+)
+
+// OptionsEnvPrefix is the prefix of the environment variables that override Options.
+const OptionsEnvPrefix = "CELLOTAPE_"
+
+// ErrInvalidOptions is returned when loading Options that don't match the options schema.
+var ErrInvalidOptions = errors.New("invalid options")
+
+// LoadOptionsFromFile loads Options from a JSON or YAML file (by the .yaml or .yml extension) and applies the
+// overrides of the CELLOTAPE_* environment variables described by LoadOptionsFromEnv.
+//
+// Loading starts from DefaultOptions, so the file only needs to define the options that are different from the
+// defaults. The "$schema" key is allowed to reference the options schema.
+// The merged options are validated with the options schema (options-schema.json) and an error wrapping
+// ErrInvalidOptions is returned with all the violations, including every unknown key.
+// The deprecated "ExcludeOperations" key of older options files is accepted as "excludeOperations" with a warning
+// logged to the LogOutput of the loaded options.
+func LoadOptionsFromFile(path string) (Options, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Options{}, err
+	}
+	if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+		if data, err = yaml.YAMLToJSON(data); err != nil {
+			return Options{}, fmt.Errorf("%w: %s: %w", ErrInvalidOptions, path, err)
+		}
+	}
+	var document map[string]any
+	if err = json.Unmarshal(data, &document); err != nil {
+		return Options{}, fmt.Errorf("%w: %s: %w", ErrInvalidOptions, path, err)
+	}
+	if document == nil {
+		document = map[string]any{}
+	}
+	delete(document, "$schema")
+	deprecatedKeys, err := renameDeprecatedOptionsKeys(document)
+	if err != nil {
+		return Options{}, fmt.Errorf("%w: %s: %w", ErrInvalidOptions, path, err)
+	}
+	options, err := loadOptions(document, os.Environ())
+	if err != nil {
+		return Options{}, fmt.Errorf("%s: %w", path, err)
+	}
+	logger := utils.NewLoggerWithLevel(options.LogOutput, options.LogLevel)
+	for _, key := range deprecatedKeys {
+		logger.Warnf("%s: options key %q is deprecated, use %q instead", path, key, deprecatedOptionsKeys[key])
+	}
+	return options, nil
+}
+
+// deprecatedOptionsKeys maps keys of older options files to their current keys.
+var deprecatedOptionsKeys = map[string]string{
+	"ExcludeOperations": "excludeOperations",
+}
+
+// renameDeprecatedOptionsKeys replaces the deprecated keys of the options document with their current keys and
+// returns the sorted deprecated keys that were found.
+// Returns an error if both a deprecated key and its current key are defined.
+func renameDeprecatedOptionsKeys(document map[string]any) ([]string, error) {
+	keys := make([]string, 0)
+	for deprecatedKey, key := range deprecatedOptionsKeys {
+		value, ok := document[deprecatedKey]
+		if !ok {
+			continue
+		}
+		if _, ok = document[key]; ok {
+			return nil, fmt.Errorf("%s: deprecated key conflicts with %q", optionsKeyPath(deprecatedKey), key)
+		}
+		delete(document, deprecatedKey)
+		document[key] = value
+		keys = append(keys, deprecatedKey)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// LoadOptionsFromEnv loads Options from the CELLOTAPE_* environment variables.
+//
+// Loading starts from DefaultOptions and every option with a scalar or a list value can be overridden with an
+// environment variable named after its key in upper snake case, with nested keys joined by an underscore.
+// For example CELLOTAPE_LOG_LEVEL=warn sets LogLevel and CELLOTAPE_DEFAULT_OPERATION_VALIDATION_VALIDATE_RESPONSES=ignore
+// sets DefaultOperationValidation.ValidateResponses. List values are comma separated.
+//
+// The options are validated with the options schema and an error wrapping ErrInvalidOptions is returned with all the
+// violations, including every unknown CELLOTAPE_* variable.
+func LoadOptionsFromEnv() (Options, error) {
+	return loadOptions(map[string]any{}, os.Environ())
+}
+
+// loadOptions applies the environment overrides on the options document, validates it with the options schema and
+// decodes it on top of DefaultOptions.
+func loadOptions(document map[string]any, environ []string) (Options, error) {
+	schema := jsonschema.Reflect(&Options{})
+	errs := applyOptionsEnv(schema, document, environ)
+	errs = append(errs, validateOptionsValue(schema, schema.Definitions, "", document)...)
+	if len(errs) > 0 {
+		return Options{}, fmt.Errorf("%w: %w", ErrInvalidOptions, errors.Join(errs...))
+	}
+	data, err := json.Marshal(document)
+	if err != nil {
+		return Options{}, err
+	}
+	options := DefaultOptions()
+	if err = json.Unmarshal(data, &options); err != nil {
+		return Options{}, fmt.Errorf("%w: %w", ErrInvalidOptions, err)
+	}
+	return options, nil
+}
+
+// applyOptionsEnv sets the values of the CELLOTAPE_* environment variables in the options document.
+func applyOptionsEnv(schema *jsonschema.Schema, document map[string]any, environ []string) []error {
+	envKeys := make(map[string][]string)
+	collectOptionsEnvKeys(schema, schema.Definitions, strings.TrimSuffix(OptionsEnvPrefix, "_"), nil, envKeys)
+	var errs []error
+	sort.Strings(environ)
+	for _, env := range environ {
+		name, value, _ := strings.Cut(env, "=")
+		if !strings.HasPrefix(name, OptionsEnvPrefix) {
+			continue
+		}
+		keys, ok := envKeys[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown environment variable %s", name))
+			continue
+		}
+		parent := document
+		for _, key := range keys[:len(keys)-1] {
+			child, isObject := parent[key].(map[string]any)
+			if !isObject {
+				child = map[string]any{}
+				parent[key] = child
+			}
+			parent = child
+		}
+		propertySchema := resolveOptionsSchema(schemaProperty(schema, schema.Definitions, keys), schema.Definitions)
+		parsedValue, err := parseOptionsEnvValue(propertySchema, value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("environment variable %s: %w", name, err))
+			continue
+		}
+		parent[keys[len(keys)-1]] = parsedValue
+	}
+	return errs
+}
+
+// collectOptionsEnvKeys maps the environment variable names to the keys of the options they override.
+// Properties of nested objects are mapped recursively while maps with arbitrary keys are not mapped.
+func collectOptionsEnvKeys(schema *jsonschema.Schema, definitions jsonschema.Definitions, prefix string, keys []string, envKeys map[string][]string) {
+	schema = resolveOptionsSchema(schema, definitions)
+	if schema.Type == "object" {
+		if schema.Properties == nil {
+			return
+		}
+		for pair := schema.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			propertyKeys := append(append([]string{}, keys...), pair.Key)
+			collectOptionsEnvKeys(pair.Value, definitions, prefix+"_"+upperSnakeCase(pair.Key), propertyKeys, envKeys)
+		}
+		return
+	}
+	envKeys[prefix] = keys
+}
+
+// schemaProperty returns the schema of the property at the keys path.
+func schemaProperty(schema *jsonschema.Schema, definitions jsonschema.Definitions, keys []string) *jsonschema.Schema {
+	for _, key := range keys {
+		schema, _ = resolveOptionsSchema(schema, definitions).Properties.Get(key)
+	}
+	return schema
+}
+
+// parseOptionsEnvValue parses the environment variable value to a JSON value of the schema type.
+func parseOptionsEnvValue(schema *jsonschema.Schema, value string) (any, error) {
+	switch schema.Type {
+	case "boolean":
+		return strconv.ParseBool(value)
+	case "integer", "number":
+		return strconv.ParseFloat(value, 64)
+	case "array":
+		items := make([]any, 0)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	}
+	return value, nil
+}
+
+// upperSnakeCase converts a camel case key to upper snake case (e.g. "logLevel" to "LOG_LEVEL").
+func upperSnakeCase(key string) string {
+	var name strings.Builder
+	for i, r := range key {
+		if i > 0 && unicode.IsUpper(r) {
+			name.WriteRune('_')
+		}
+		name.WriteRune(unicode.ToUpper(r))
+	}
+	return name.String()
+}
+
+// resolveOptionsSchema resolves a reference to a schema definition.
+func resolveOptionsSchema(schema *jsonschema.Schema, definitions jsonschema.Definitions) *jsonschema.Schema {
+	for schema.Ref != "" {
+		schema = definitions[strings.TrimPrefix(schema.Ref, "#/$defs/")]
+	}
+	return schema
+}
+
+// validateOptionsValue validates a JSON value with the options schema.
+// It supports the subset of JSON schema reflected from Options and returns all the violations found.
+func validateOptionsValue(schema *jsonschema.Schema, definitions jsonschema.Definitions, path string, value any) []error {
+	schema = resolveOptionsSchema(schema, definitions)
+	if len(schema.Enum) > 0 {
+		for _, enumValue := range schema.Enum {
+			if enumValue == value {
+				return nil
+			}
+		}
+		return []error{fmt.Errorf("%s: %v is not one of %v", optionsKeyPath(path), value, schema.Enum)}
+	}
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return []error{optionsTypeError(path, schema.Type, value)}
+		}
+		return validateOptionsObject(schema, definitions, path, object)
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			return []error{optionsTypeError(path, schema.Type, value)}
+		}
+		var errs []error
+		for i, item := range array {
+			errs = append(errs, validateOptionsValue(schema.Items, definitions, fmt.Sprintf("%s[%d]", path, i), item)...)
+		}
+		return errs
+	case "string":
+		if _, ok := value.(string); !ok {
+			return []error{optionsTypeError(path, schema.Type, value)}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []error{optionsTypeError(path, schema.Type, value)}
+		}
+	case "integer", "number":
+		number, ok := value.(float64)
+		if !ok || (schema.Type == "integer" && number != math.Trunc(number)) {
+			return []error{optionsTypeError(path, schema.Type, value)}
+		}
+		if minimum, err := schema.Minimum.Float64(); err == nil && number < minimum {
+			return []error{fmt.Errorf("%s: %v is less than the minimum %v", optionsKeyPath(path), number, minimum)}
+		}
+		if maximum, err := schema.Maximum.Float64(); err == nil && number > maximum {
+			return []error{fmt.Errorf("%s: %v is greater than the maximum %v", optionsKeyPath(path), number, maximum)}
+		}
+	}
+	return nil
+}
+
+func validateOptionsObject(schema *jsonschema.Schema, definitions jsonschema.Definitions, path string, object map[string]any) []error {
+	var errs []error
+	for _, key := range schema.Required {
+		if _, ok := object[key]; !ok {
+			errs = append(errs, fmt.Errorf("%s: missing required key", optionsKeyPath(joinOptionsKeyPath(path, key))))
+		}
+	}
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		keyPath := joinOptionsKeyPath(path, key)
+		var propertySchema *jsonschema.Schema
+		if schema.Properties != nil {
+			propertySchema, _ = schema.Properties.Get(key)
+		}
+		if propertySchema == nil {
+			propertySchema = schema.AdditionalProperties
+		}
+		if propertySchema == nil || reflect.DeepEqual(propertySchema, jsonschema.FalseSchema) {
+			errs = append(errs, fmt.Errorf("%s: unknown key", optionsKeyPath(keyPath)))
+			continue
+		}
+		errs = append(errs, validateOptionsValue(propertySchema, definitions, keyPath, object[key])...)
+	}
+	return errs
+}
+
+func joinOptionsKeyPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func optionsKeyPath(path string) string {
+	if path == "" {
+		return "options"
+	}
+	return strconv.Quote(path)
+}
+
+func optionsTypeError(path string, expectedType string, value any) error {
+	actual, _ := json.Marshal(value)
+	return fmt.Errorf("%s: expected %s but got %s", optionsKeyPath(path), expectedType, bytes.TrimSpace(actual))
+}
@@ -0,0 +1,167 @@
+package router
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/piiano/cellotape/router/utils"
+)
+
+func writeOptionsFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadOptionsFromRepoOptionsFile(t *testing.T) {
+	options, err := LoadOptionsFromFile("../options.json")
+	require.NoError(t, err)
+
+	defaultOptions := DefaultOptions()
+	assert.Equal(t, defaultOptions.DefaultOperationValidation, options.DefaultOperationValidation)
+	assert.Equal(t, defaultOptions.LogLevel, options.LogLevel)
+	assert.Equal(t, defaultOptions.MustHandleAllOperations, options.MustHandleAllOperations)
+	assert.True(t, options.RecoverOnPanic)
+	assert.NotNil(t, options.OptionsHandler)
+}
+
+func TestLoadOptionsFromJSONFile(t *testing.T) {
+	path := writeOptionsFile(t, "options.json", `{
+  "$schema": "./options-schema.json",
+  "recoverOnPanic": false,
+  "logLevel": "warn",
+  "mustHandleAllOperations": "print-warning",
+  "excludeOperations": ["foo"],
+  "defaultOperationValidation": { "validateResponses": "ignore" },
+  "operationValidations": { "bar": { "runtimeValidateResponsesSamplePercentage": 10 } }
+}`)
+	options, err := LoadOptionsFromFile(path)
+	require.NoError(t, err)
+
+	assert.False(t, options.RecoverOnPanic)
+	assert.Equal(t, LogLevelWarn, options.LogLevel)
+	assert.Equal(t, PrintWarning, options.MustHandleAllOperations)
+	assert.Equal(t, PropagateError, options.HandleAllContentTypes)
+	assert.Equal(t, []string{"foo"}, options.ExcludeOperations)
+	assert.Equal(t, Ignore, options.DefaultOperationValidation.ValidateResponses)
+	// values missing from the file keep their defaults
+	assert.Equal(t, utils.Ptr(PrintWarning), options.DefaultOperationValidation.RuntimeValidateResponses)
+	assert.Equal(t, DefaultOptions().DefaultOperationValidation.ContentTypesToSkipRuntimeValidation,
+		options.DefaultOperationValidation.ContentTypesToSkipRuntimeValidation)
+	assert.Equal(t, 10.0, options.OperationValidations["bar"].RuntimeValidateResponsesSamplePercentage)
+	assert.Equal(t, os.Stderr, options.LogOutput)
+}
+
+func TestLoadOptionsFromYAMLFile(t *testing.T) {
+	path := writeOptionsFile(t, "options.yaml", `
+logLevel: error
+defaultOperationValidation:
+  handleAllPathParams: ignore
+`)
+	options, err := LoadOptionsFromFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, LogLevelError, options.LogLevel)
+	assert.Equal(t, Ignore, options.DefaultOperationValidation.HandleAllPathParams)
+}
+
+func TestLoadOptionsFromFileWithInvalidOptions(t *testing.T) {
+	path := writeOptionsFile(t, "options.json", `{
+  "foo": 1,
+  "logLevel": "verbose",
+  "recoverOnPanic": "yes",
+  "defaultOperationValidation": { "bar": true, "runtimeValidateResponsesSamplePercentage": 120 },
+  "operationValidations": { "baz": { "validateResponses": 1 } }
+}`)
+	_, err := LoadOptionsFromFile(path)
+	require.ErrorIs(t, err, ErrInvalidOptions)
+
+	assert.ErrorContains(t, err, `"foo": unknown key`)
+	assert.ErrorContains(t, err, `"defaultOperationValidation.bar": unknown key`)
+	assert.ErrorContains(t, err, `"logLevel": verbose is not one of [error warn info off]`)
+	assert.ErrorContains(t, err, `"recoverOnPanic": expected boolean but got "yes"`)
+	assert.ErrorContains(t, err, `"defaultOperationValidation.runtimeValidateResponsesSamplePercentage": 120 is greater than the maximum 100`)
+	assert.ErrorContains(t, err, `"operationValidations.baz.validateResponses": 1 is not one of [propagate-error print-warning ignore]`)
+}
+
+func TestLoadOptionsFromFileWithDeprecatedKey(t *testing.T) {
+	stderr := os.Stderr
+	t.Cleanup(func() { os.Stderr = stderr })
+	logOutput, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+	require.NoError(t, err)
+	os.Stderr = logOutput
+
+	path := writeOptionsFile(t, "options.json", `{ "ExcludeOperations": ["foo"] }`)
+	options, err := LoadOptionsFromFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo"}, options.ExcludeOperations)
+
+	logs, err := os.ReadFile(logOutput.Name())
+	require.NoError(t, err)
+	assert.Contains(t, string(logs), `options key "ExcludeOperations" is deprecated, use "excludeOperations" instead`)
+
+	path = writeOptionsFile(t, "options.json", `{ "ExcludeOperations": ["foo"], "excludeOperations": ["bar"] }`)
+	_, err = LoadOptionsFromFile(path)
+	require.ErrorIs(t, err, ErrInvalidOptions)
+	assert.ErrorContains(t, err, `"ExcludeOperations": deprecated key conflicts with "excludeOperations"`)
+}
+
+func TestLoadOptionsFromMissingFile(t *testing.T) {
+	_, err := LoadOptionsFromFile(filepath.Join(t.TempDir(), "missing.json"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestLoadOptionsFromEnv(t *testing.T) {
+	t.Setenv("CELLOTAPE_LOG_LEVEL", "off")
+	t.Setenv("CELLOTAPE_RECOVER_ON_PANIC", "false")
+	t.Setenv("CELLOTAPE_EXCLUDE_OPERATIONS", "foo, bar")
+	t.Setenv("CELLOTAPE_DEFAULT_OPERATION_VALIDATION_VALIDATE_RESPONSES", "print-warning")
+	t.Setenv("CELLOTAPE_DEFAULT_OPERATION_VALIDATION_RUNTIME_VALIDATE_RESPONSES_SAMPLE_PERCENTAGE", "25")
+
+	options, err := LoadOptionsFromEnv()
+	require.NoError(t, err)
+
+	assert.Equal(t, LogLevelOff, options.LogLevel)
+	assert.False(t, options.RecoverOnPanic)
+	assert.Equal(t, []string{"foo", "bar"}, options.ExcludeOperations)
+	assert.Equal(t, PrintWarning, options.DefaultOperationValidation.ValidateResponses)
+	assert.Equal(t, 25.0, options.DefaultOperationValidation.RuntimeValidateResponsesSamplePercentage)
+	assert.Equal(t, PropagateError, options.DefaultOperationValidation.ValidateRequestBody)
+}
+
+func TestLoadOptionsFromFileWithEnvOverrides(t *testing.T) {
+	t.Setenv("CELLOTAPE_DEFAULT_OPERATION_VALIDATION_VALIDATE_RESPONSES", "print-warning")
+	path := writeOptionsFile(t, "options.json", `{
+  "logLevel": "warn",
+  "defaultOperationValidation": { "validateResponses": "ignore", "validatePathParams": "ignore" }
+}`)
+	options, err := LoadOptionsFromFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, LogLevelWarn, options.LogLevel)
+	assert.Equal(t, PrintWarning, options.DefaultOperationValidation.ValidateResponses)
+	assert.Equal(t, Ignore, options.DefaultOperationValidation.ValidatePathParams)
+}
+
+func TestLoadOptionsFromEnvWithInvalidOptions(t *testing.T) {
+	t.Setenv("CELLOTAPE_FOO", "bar")
+	t.Setenv("CELLOTAPE_RECOVER_ON_PANIC", "maybe")
+	t.Setenv("CELLOTAPE_MUST_HANDLE_ALL_OPERATIONS", "fail")
+
+	_, err := LoadOptionsFromEnv()
+	require.ErrorIs(t, err, ErrInvalidOptions)
+
+	assert.ErrorContains(t, err, "unknown environment variable CELLOTAPE_FOO")
+	assert.ErrorContains(t, err, "environment variable CELLOTAPE_RECOVER_ON_PANIC: ")
+	assert.ErrorContains(t, err, `"mustHandleAllOperations": fail is not one of [propagate-error print-warning ignore]`)
+}
+
+func TestUpperSnakeCase(t *testing.T) {
+	assert.Equal(t, "LOG_LEVEL", upperSnakeCase("logLevel"))
+	assert.Equal(t, "RECOVER_ON_PANIC", upperSnakeCase("recoverOnPanic"))
+	assert.Equal(t, "EXCLUDE_OPERATIONS", upperSnakeCase("excludeOperations"))
+}
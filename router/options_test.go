@@ -66,5 +66,23 @@ func TestOperationValidationOptions(t *testing.T) {
 		"foo": customOperationOptions,
 	}
 	operationOptions = options.operationValidationOptions("foo")
-	assert.Equal(t, customOperationOptions, operationOptions)
+	assert.Equal(t, Ignore, operationOptions.ValidateRequestBody)
+	assert.Equal(t, PropagateError, operationOptions.ValidateResponses)
+
+	// runtime validation options that are not set are merged from the defaults
+	assert.Equal(t, utils.Ptr(PrintWarning), operationOptions.RuntimeValidateResponses)
+	assert.Equal(t, PropagateError, operationOptions.runtimeValidateRequests())
+	assert.Equal(t, options.DefaultOperationValidation.ContentTypesToSkipRuntimeValidation, operationOptions.ContentTypesToSkipRuntimeValidation)
+
+	options.DefaultOperationValidation.RuntimeValidateRequests = utils.Ptr(PrintWarning)
+	options.DefaultOperationValidation.RuntimeValidateResponsesSamplePercentage = 10
+	options.OperationValidations["foo"] = OperationValidationOptions{
+		RuntimeValidateResponses:            utils.Ptr(PropagateError),
+		ContentTypesToSkipRuntimeValidation: []string{},
+	}
+	operationOptions = options.operationValidationOptions("foo")
+	assert.Equal(t, PropagateError, operationOptions.runtimeValidateResponses())
+	assert.Equal(t, PrintWarning, operationOptions.runtimeValidateRequests())
+	assert.Equal(t, 10.0, operationOptions.RuntimeValidateResponsesSamplePercentage)
+	assert.Empty(t, operationOptions.ContentTypesToSkipRuntimeValidation)
 }
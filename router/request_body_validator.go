@@ -0,0 +1,336 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"regexp"
+	"unicode/utf16"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+)
+
+// jsonMimeType is the only mime type validated natively as its request bodies are decoded by kin-openapi with the
+// standard JSON decoder.
+const jsonMimeType = "application/json"
+
+// kinCodepointsPattern matches the \uXXXX escapes that kin-openapi rewrites before compiling schema patterns.
+var kinCodepointsPattern = regexp.MustCompile(`(?P<replaced_with_slash_x>\\u)(?P<code>[0-9A-F]{4})`)
+
+// requestBodyValidator validates the request bodies of an operation with schemas compiled when creating the router
+// instead of rebuilding the kin-openapi validation input for every request.
+//
+// The native validation accepts a body only if kin-openapi accepts it as well and populates the same default values.
+// Values that fail the native validation are visited again with kin-openapi to report the same errors, and schemas
+// with keywords that are not supported natively are always validated with kin-openapi.
+type requestBodyValidator struct {
+	schemas map[*openapi3.MediaType]*compiledSchema
+}
+
+// newRequestBodyValidator compiles the request body schemas of the operation.
+// Returns nil if no request body schema can be validated natively.
+func newRequestBodyValidator(specOp SpecOperation) *requestBodyValidator {
+	if specOp.Operation == nil || specOp.RequestBody == nil || specOp.RequestBody.Value == nil {
+		return nil
+	}
+	schemas := make(map[*openapi3.MediaType]*compiledSchema)
+	for mimeType, mediaType := range specOp.RequestBody.Value.Content {
+		if mimeType != jsonMimeType || mediaType == nil || mediaType.Schema == nil {
+			continue
+		}
+		compiler := schemaCompiler{compiled: make(map[*openapi3.Schema]*compiledSchema)}
+		if schema, ok := compiler.compile(mediaType.Schema); ok {
+			schemas[mediaType] = schema
+		}
+	}
+	if len(schemas) == 0 {
+		return nil
+	}
+	return &requestBodyValidator{schemas: schemas}
+}
+
+// schema returns the compiled schema of the media type or nil if it is validated only with kin-openapi.
+func (v *requestBodyValidator) schema(mediaType *openapi3.MediaType) *compiledSchema {
+	if v == nil {
+		return nil
+	}
+	return v.schemas[mediaType]
+}
+
+// decodeJSONBody decodes a JSON request body the same way kin-openapi decodes it.
+// Unlike kin-openapi, data after the JSON value is rejected like it is rejected when decoding the body with
+// json.Unmarshal.
+func decodeJSONBody(data []byte) (any, error) {
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err := decoder.Decode(&value)
+	if err == nil && len(bytes.TrimLeft(data[decoder.InputOffset():], " \t\r\n")) > 0 {
+		err = json.Unmarshal(data, &value)
+	}
+	if err != nil {
+		return nil, &openapi3filter.ParseError{Kind: openapi3filter.KindInvalidFormat, Cause: err}
+	}
+	return value, nil
+}
+
+// decodeJSONValue decodes the first JSON value in the data the same way kin-openapi decodes JSON request bodies.
+func decodeJSONValue(data []byte) (any, error) {
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// schemaCompiler compiles schemas to compiledSchema.
+// Each schema is compiled once to support recursive schemas.
+type schemaCompiler struct {
+	compiled map[*openapi3.Schema]*compiledSchema
+}
+
+// compiledSchema is a schema prepared for validating request values natively.
+// It follows the kin-openapi request validation semantics for the keywords it supports.
+type compiledSchema struct {
+	schema *openapi3.Schema
+	// empty is true for schemas that kin-openapi considers empty which accept any non-null value without visiting it
+	empty                bool
+	nullable             bool
+	pattern              *regexp.Regexp
+	properties           map[string]*compiledSchema
+	additionalProperties *compiledSchema
+	items                *compiledSchema
+}
+
+// compile returns the compiled schema or false if the schema has keywords that are not supported natively.
+func (c schemaCompiler) compile(schemaRef *openapi3.SchemaRef) (*compiledSchema, bool) {
+	if schemaRef == nil || schemaRef.Value == nil {
+		return nil, false
+	}
+	schema := schemaRef.Value
+	if compiled, found := c.compiled[schema]; found {
+		return compiled, true
+	}
+	if schema.Not != nil || len(schema.AllOf) > 0 || len(schema.AnyOf) > 0 || len(schema.OneOf) > 0 ||
+		schema.UniqueItems || (schema.ExclusiveMin && schema.Min == nil) || (schema.ExclusiveMax && schema.Max == nil) {
+		return nil, false
+	}
+	compiled := &compiledSchema{
+		schema:   schema,
+		empty:    schema.IsEmpty(),
+		nullable: schema.PermitsNull(),
+	}
+	c.compiled[schema] = compiled
+	if schema.Pattern != "" {
+		pattern, err := regexp.Compile(kinCodepointsPattern.ReplaceAllString(schema.Pattern, `\x{${code}}`))
+		if err != nil {
+			return nil, false
+		}
+		compiled.pattern = pattern
+	}
+	if len(schema.Properties) > 0 {
+		compiled.properties = make(map[string]*compiledSchema, len(schema.Properties))
+		for name, property := range schema.Properties {
+			compiledProperty, ok := c.compile(property)
+			if !ok {
+				return nil, false
+			}
+			compiled.properties[name] = compiledProperty
+		}
+	}
+	if schema.AdditionalProperties.Schema != nil {
+		additionalProperties, ok := c.compile(schema.AdditionalProperties.Schema)
+		if !ok {
+			return nil, false
+		}
+		compiled.additionalProperties = additionalProperties
+	}
+	if schema.Items != nil {
+		items, ok := c.compile(schema.Items)
+		if !ok {
+			return nil, false
+		}
+		compiled.items = items
+	}
+	return compiled, true
+}
+
+// isValid checks if the value decoded from JSON is valid with the schema and populates missing properties with their
+// default values. defaultsSet is set to true if any default value is populated.
+func (s *compiledSchema) isValid(value any, defaultsSet *bool) bool {
+	if value == nil {
+		return s.nullable
+	}
+	if s.empty {
+		return true
+	}
+	if len(s.schema.Enum) > 0 && !s.isEnumValue(value) {
+		return false
+	}
+	switch value := value.(type) {
+	case bool:
+		return s.schema.Type.Permits(openapi3.TypeBoolean)
+	case json.Number:
+		number, err := value.Float64()
+		return err == nil && s.isValidNumber(number)
+	case string:
+		return s.isValidString(value)
+	case []any:
+		return s.isValidArray(value, defaultsSet)
+	case map[string]any:
+		return s.isValidObject(value, defaultsSet)
+	}
+	return false
+}
+
+// isEnumValue compares the value with the enum values the same way kin-openapi does.
+func (s *compiledSchema) isEnumValue(value any) bool {
+	for _, enumValue := range s.schema.Enum {
+		if number, ok := value.(json.Number); ok {
+			if float, err := number.Float64(); err == nil && enumValue == any(float) {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(enumValue, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *compiledSchema) isValidNumber(value float64) bool {
+	schema := s.schema
+	requireInteger := schema.Type.Permits(openapi3.TypeInteger) && !schema.Type.Permits(openapi3.TypeNumber)
+	if requireInteger {
+		if !big.NewFloat(value).IsInt() {
+			return false
+		}
+	} else if !schema.Type.Permits(openapi3.TypeInteger) && !schema.Type.Permits(openapi3.TypeNumber) {
+		return false
+	}
+	if schema.Format != "" {
+		if requireInteger {
+			if format, ok := openapi3.SchemaIntegerFormats[schema.Format]; ok && format.Validate(int64(value)) != nil {
+				return false
+			}
+		} else if format, ok := openapi3.SchemaNumberFormats[schema.Format]; ok && format.Validate(value) != nil {
+			return false
+		}
+	}
+	if schema.ExclusiveMin && !(*schema.Min < value) {
+		return false
+	}
+	if schema.ExclusiveMax && !(*schema.Max > value) {
+		return false
+	}
+	if schema.Min != nil && !(*schema.Min <= value) {
+		return false
+	}
+	if schema.Max != nil && !(*schema.Max >= value) {
+		return false
+	}
+	if schema.MultipleOf != nil && !big.NewFloat(value/(*schema.MultipleOf)).IsInt() {
+		return false
+	}
+	return true
+}
+
+func (s *compiledSchema) isValidString(value string) bool {
+	schema := s.schema
+	if !schema.Type.Permits(openapi3.TypeString) {
+		return false
+	}
+	if schema.MinLength != 0 || schema.MaxLength != nil {
+		// count the length the same way kin-openapi does
+		length := uint64(0)
+		for _, r := range value {
+			if utf16.IsSurrogate(r) {
+				length += 2
+			} else {
+				length++
+			}
+		}
+		if length < schema.MinLength || (schema.MaxLength != nil && length > *schema.MaxLength) {
+			return false
+		}
+	}
+	if s.pattern != nil && !s.pattern.MatchString(value) {
+		return false
+	}
+	if schema.Format != "" {
+		if format, ok := openapi3.SchemaStringFormats[schema.Format]; ok && format.Validate(value) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *compiledSchema) isValidArray(value []any, defaultsSet *bool) bool {
+	schema := s.schema
+	if !schema.Type.Permits(openapi3.TypeArray) {
+		return false
+	}
+	length := uint64(len(value))
+	if length < schema.MinItems || (schema.MaxItems != nil && length > *schema.MaxItems) {
+		return false
+	}
+	if s.items != nil {
+		for _, item := range value {
+			if !s.items.isValid(item, defaultsSet) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s *compiledSchema) isValidObject(value map[string]any, defaultsSet *bool) bool {
+	schema := s.schema
+	if !schema.Type.Permits(openapi3.TypeObject) {
+		return false
+	}
+	// readOnly properties are forbidden in requests and missing properties are populated with their default values
+	for name, property := range s.properties {
+		readOnly := property.schema.ReadOnly
+		if value[name] == nil && property.schema.Default != nil && !readOnly {
+			value[name] = property.schema.Default
+			*defaultsSet = true
+		}
+		if value[name] != nil && readOnly {
+			return false
+		}
+	}
+	length := uint64(len(value))
+	if length < schema.MinProps || (schema.MaxProps != nil && length > *schema.MaxProps) {
+		return false
+	}
+	additionalPropertiesAllowed := schema.AdditionalProperties.Has == nil || *schema.AdditionalProperties.Has
+	for name, propertyValue := range value {
+		if property, found := s.properties[name]; found {
+			if !property.isValid(propertyValue, defaultsSet) {
+				return false
+			}
+			continue
+		}
+		if !additionalPropertiesAllowed {
+			return false
+		}
+		if s.additionalProperties != nil && !s.additionalProperties.isValid(propertyValue, defaultsSet) {
+			return false
+		}
+	}
+	for _, name := range schema.Required {
+		if _, found := value[name]; !found {
+			if property, isProperty := s.properties[name]; isProperty && property.schema.ReadOnly {
+				continue
+			}
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,275 @@
+package router
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const requestBodyValidatorTestSchema = `{
+  "type": "object",
+  "required": ["name", "tags"],
+  "additionalProperties": false,
+  "properties": {
+    "name": { "type": "string", "minLength": 2, "maxLength": 10, "pattern": "^[a-z]+$" },
+    "age": { "type": "integer", "minimum": 0, "maximum": 150, "format": "int32" },
+    "score": { "type": "number", "exclusiveMinimum": true, "minimum": 0, "multipleOf": 0.5 },
+    "status": { "type": "string", "enum": ["active", "inactive"], "default": "active" },
+    "level": { "type": "number", "enum": [1, 2, 3] },
+    "birthday": { "type": "string", "format": "date" },
+    "id": { "type": "string", "readOnly": true },
+    "note": { "type": "string", "nullable": true },
+    "any": {},
+    "tags": { "type": "array", "minItems": 1, "maxItems": 3, "items": { "type": "string" } },
+    "labels": { "type": "object", "additionalProperties": { "type": "boolean" } },
+    "child": { "type": "object", "properties": { "count": { "type": "integer", "default": 1 } } }
+  }
+}`
+
+func requestBodyValidatorTestOperation(t testing.TB, schemaJSON string) *openapi3.Operation {
+	schema := openapi3.NewSchema()
+	require.NoError(t, schema.UnmarshalJSON([]byte(schemaJSON)))
+	operation := openapi3.NewOperation()
+	operation.OperationID = "test"
+	operation.RequestBody = &openapi3.RequestBodyRef{
+		Value: openapi3.NewRequestBody().WithRequired(true).WithJSONSchema(schema),
+	}
+	return operation
+}
+
+func TestRequestBodyValidatorMatchesKinOpenAPI(t *testing.T) {
+	operation := requestBodyValidatorTestOperation(t, requestBodyValidatorTestSchema)
+	validator := newRequestBodyValidator(SpecOperation{Operation: operation})
+	require.NotNil(t, validator)
+
+	testCases := []struct {
+		name  string
+		body  string
+		valid bool
+	}{
+		{name: "minimal", body: `{"name":"foo","tags":["a"]}`, valid: true},
+		{name: "all properties", body: `{"name":"foo","age":30,"score":1.5,"status":"inactive","level":2,"birthday":"2000-01-02","note":null,"any":[1],"tags":["a","b"],"labels":{"x":true},"child":{"count":3}}`, valid: true},
+		{name: "nested default", body: `{"name":"foo","tags":["a"],"child":{}}`, valid: true},
+		{name: "null property replaced with default", body: `{"name":"foo","tags":["a"],"status":null}`, valid: true},
+		{name: "empty body", body: ``},
+		{name: "invalid json", body: `{"name":`},
+		{name: "null body", body: `null`},
+		{name: "wrong type", body: `[]`},
+		{name: "missing required", body: `{"name":"foo"}`},
+		{name: "short string", body: `{"name":"f","tags":["a"]}`},
+		{name: "long string", body: `{"name":"fooooooooooo","tags":["a"]}`},
+		{name: "pattern mismatch", body: `{"name":"Foo","tags":["a"]}`},
+		{name: "not an integer", body: `{"name":"foo","tags":["a"],"age":1.5}`},
+		{name: "integer out of format", body: `{"name":"foo","tags":["a"],"age":3000000000}`},
+		{name: "below minimum", body: `{"name":"foo","tags":["a"],"age":-1}`},
+		{name: "above maximum", body: `{"name":"foo","tags":["a"],"age":151}`},
+		{name: "exclusive minimum", body: `{"name":"foo","tags":["a"],"score":0}`},
+		{name: "not multiple of", body: `{"name":"foo","tags":["a"],"score":0.3}`},
+		{name: "not in enum", body: `{"name":"foo","tags":["a"],"status":"unknown"}`},
+		{name: "number not in enum", body: `{"name":"foo","tags":["a"],"level":4}`},
+		{name: "invalid format", body: `{"name":"foo","tags":["a"],"birthday":"foo"}`},
+		{name: "read only", body: `{"name":"foo","tags":["a"],"id":"1"}`},
+		{name: "not nullable", body: `{"name":null,"tags":["a"]}`},
+		{name: "empty schema with null", body: `{"name":"foo","tags":["a"],"any":null}`},
+		{name: "too few items", body: `{"name":"foo","tags":[]}`},
+		{name: "too many items", body: `{"name":"foo","tags":["a","b","c","d"]}`},
+		{name: "wrong item", body: `{"name":"foo","tags":[1]}`},
+		{name: "wrong additional property value", body: `{"name":"foo","tags":["a"],"labels":{"x":1}}`},
+		{name: "unsupported property", body: `{"name":"foo","tags":["a"],"other":1}`},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			kinBytes, kinErr := validateBodyAndPopulateDefaults(testContext(
+				withBody(test.body),
+				withHeader(contentTypeHeader, "application/json"),
+				withOperation(operation)))
+
+			ctx := testContext(
+				withBody(test.body),
+				withHeader(contentTypeHeader, "application/json"),
+				withOperation(operation),
+				withRequestBodyValidator(validator))
+			_, err := validateDecodedBodyAndPopulateDefaults(ctx, decodeJSONBody)
+
+			if !test.valid {
+				require.Error(t, kinErr)
+				require.Error(t, err)
+				assert.Equal(t, kinErr.Error(), err.Error())
+				assert.False(t, ctx.requestBodyDecoded)
+				return
+			}
+			require.NoError(t, kinErr)
+			require.NoError(t, err)
+			require.True(t, ctx.requestBodyDecoded)
+			bodyBytes, err := json.Marshal(ctx.requestBodyValue)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(kinBytes), string(bodyBytes))
+		})
+	}
+}
+
+func TestRequestBodyValidatorWithRecursiveSchema(t *testing.T) {
+	schema := openapi3.NewObjectSchema()
+	schema.WithProperty("name", openapi3.NewStringSchema())
+	schema.Properties["children"] = openapi3.NewSchemaRef("", openapi3.NewArraySchema().WithItems(schema))
+	operation := openapi3.NewOperation()
+	operation.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().WithJSONSchema(schema)}
+	validator := newRequestBodyValidator(SpecOperation{Operation: operation})
+	require.NotNil(t, validator)
+
+	_, err := validateDecodedBodyAndPopulateDefaults(testContext(
+		withBody(`{"name":"a","children":[{"name":"b","children":[{"name":"c"}]}]}`),
+		withHeader(contentTypeHeader, "application/json"),
+		withOperation(operation),
+		withRequestBodyValidator(validator)), decodeJSONBody)
+	require.NoError(t, err)
+
+	_, err = validateDecodedBodyAndPopulateDefaults(testContext(
+		withBody(`{"name":"a","children":[{"name":"b","children":[{"name":1}]}]}`),
+		withHeader(contentTypeHeader, "application/json"),
+		withOperation(operation),
+		withRequestBodyValidator(validator)), decodeJSONBody)
+	require.Error(t, err)
+}
+
+func TestRequestBodyValidatorWithUnsupportedSchema(t *testing.T) {
+	operation := requestBodyValidatorTestOperation(t, `{ "oneOf": [{ "type": "string" }, { "type": "integer" }] }`)
+	assert.Nil(t, newRequestBodyValidator(SpecOperation{Operation: operation}))
+
+	operation = requestBodyValidatorTestOperation(t, `{ "type": "array", "uniqueItems": true }`)
+	assert.Nil(t, newRequestBodyValidator(SpecOperation{Operation: operation}))
+
+	assert.Nil(t, newRequestBodyValidator(SpecOperation{Operation: openapi3.NewOperation()}))
+}
+
+func TestRequestBodyValidatorWithoutCompiledSchema(t *testing.T) {
+	operation := requestBodyValidatorTestOperation(t, `{ "oneOf": [{ "type": "string" }, { "type": "integer" }] }`)
+	for _, body := range []string{`"foo"`, `42`, `true`, `{"a":`} {
+		kinBytes, kinErr := validateBodyAndPopulateDefaults(testContext(
+			withBody(body),
+			withHeader(contentTypeHeader, "application/json"),
+			withOperation(operation)))
+
+		ctx := testContext(
+			withBody(body),
+			withHeader(contentTypeHeader, "application/json"),
+			withOperation(operation))
+		_, err := validateDecodedBodyAndPopulateDefaults(ctx, decodeJSONBody)
+		if kinErr != nil {
+			require.Error(t, err)
+			assert.Equal(t, kinErr.Error(), err.Error())
+			continue
+		}
+		require.NoError(t, err)
+		bodyBytes, err := json.Marshal(ctx.requestBodyValue)
+		require.NoError(t, err)
+		assert.JSONEq(t, string(kinBytes), string(bodyBytes))
+	}
+}
+
+func TestDecodeJSONBodyRejectsTrailingData(t *testing.T) {
+	value, err := decodeJSONBody([]byte(" {\"a\": 1} \n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": json.Number("1")}, value)
+
+	_, err = decodeJSONBody([]byte(`{"a": 1} {}`))
+	var parseErr *openapi3filter.ParseError
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, openapi3filter.KindInvalidFormat, parseErr.Kind)
+}
+
+// requestBodyValidatorDifferentialSchemas are schemas with the keywords validated natively by compiledSchema.
+var requestBodyValidatorDifferentialSchemas = []string{
+	requestBodyValidatorTestSchema,
+	`{ "type": "integer", "format": "int64", "minimum": -5, "maximum": 5, "exclusiveMaximum": true }`,
+	`{ "type": "integer", "format": "int32", "multipleOf": 3 }`,
+	`{ "type": "number", "format": "float", "multipleOf": 0.1, "exclusiveMinimum": true, "minimum": -1 }`,
+	`{ "type": "number", "format": "double", "maximum": 1e300 }`,
+	`{ "type": "string", "minLength": 1, "maxLength": 3, "pattern": "^[\\u00E9a-z]*$" }`,
+	`{ "type": "string", "format": "date-time" }`,
+	`{ "type": "string", "format": "date", "enum": ["2000-01-02", "2000-02-30"] }`,
+	`{ "enum": ["a", 1, 1.5, true, null, [1], { "a": 1 }] }`,
+	`{ "type": "boolean", "enum": [true], "nullable": true }`,
+	`{ "type": "array", "minItems": 1, "maxItems": 2, "items": { "type": "object", "properties": { "x": { "type": "integer", "default": 3 } } } }`,
+	`{ "type": "object", "minProperties": 1, "maxProperties": 2, "additionalProperties": { "type": "string", "nullable": true } }`,
+	`{ "type": "object", "required": ["id", "name"], "additionalProperties": false, "properties": { "id": { "type": "string", "readOnly": true, "default": "x" }, "name": { "type": "string", "default": "n" }, "secret": { "type": "string", "writeOnly": true } } }`,
+	`{ "type": "object", "nullable": true, "properties": { "nested": { "type": "object", "properties": { "list": { "type": "array", "items": { "type": "number", "default": 1 } } } } } }`,
+	`{ "properties": { "a": { "type": "string" } }, "required": ["a"] }`,
+	`{}`,
+}
+
+// FuzzRequestBodyValidatorMatchesKinOpenAPI checks that compiledSchema accepts only values that kin-openapi accepts and
+// populates the same default values.
+func FuzzRequestBodyValidatorMatchesKinOpenAPI(f *testing.F) {
+	for _, seed := range []string{
+		`{"name":"foo","age":30,"score":1.5,"status":"inactive","level":2,"birthday":"2000-01-02","note":null,"any":[1],"tags":["a","b"],"labels":{"x":true},"child":{"count":3}}`,
+		`{"name":"foo","tags":["a"],"child":{},"status":null}`,
+		`{"id":"1","name":null}`, `{"name":"a","secret":"s"}`, `{"a":null}`, `{"a":"b","c":null,"d":1}`,
+		`[{"x":null},{}]`, `[{"x":1.5}]`, `{"nested":{"list":[null,2]}}`, `{"nested":null}`,
+		`4`, `-5`, `5`, `4.999`, `0.30000000000000004`, `0.3`, `1e300`, `2147483648`, `-9223372036854775809`, `1.5`,
+		`"é"`, `"aé"`, `"abcd"`, `"\ud83d\ude00"`, `""`, `"2000-01-02"`, `"2000-02-30"`, `"2000-01-02T03:04:05Z"`,
+		`true`, `false`, `null`, `[]`, `[1]`, `{}`, `{"a":1}`,
+	} {
+		f.Add([]byte(seed))
+	}
+	schemas := make([]*openapi3.Schema, len(requestBodyValidatorDifferentialSchemas))
+	for i, schemaJSON := range requestBodyValidatorDifferentialSchemas {
+		schemas[i] = openapi3.NewSchema()
+		require.NoError(f, schemas[i].UnmarshalJSON([]byte(schemaJSON)))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if _, err := decodeJSONValue(data); err != nil {
+			return
+		}
+		for i, schema := range schemas {
+			compiled, ok := schemaCompiler{compiled: make(map[*openapi3.Schema]*compiledSchema)}.compile(openapi3.NewSchemaRef("", schema))
+			require.Truef(t, ok, "schema %d is not compiled", i)
+			nativeValue, _ := decodeJSONValue(data)
+			if !compiled.isValid(nativeValue, new(bool)) {
+				continue
+			}
+			kinValue, _ := decodeJSONValue(data)
+			err := schema.VisitJSON(kinValue, openapi3.VisitAsRequest(), openapi3.DefaultsSet(func() {}))
+			require.NoErrorf(t, err, "schema %d accepted %s", i, data)
+			nativeJSON, err := json.Marshal(nativeValue)
+			require.NoError(t, err)
+			kinJSON, err := json.Marshal(kinValue)
+			require.NoError(t, err)
+			assert.Equalf(t, string(kinJSON), string(nativeJSON), "schema %d with %s", i, data)
+		}
+	})
+}
+
+func benchmarkRequestBodyValidation(b *testing.B, operation *openapi3.Operation, validate func(ctx *Context) ([]byte, error)) {
+	body := `{"name":"foo","age":30,"score":1.5,"level":2,"birthday":"2000-01-02","tags":["a","b"],"labels":{"x":true},"child":{"count":3}}`
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx := testContext(
+			withBody(body),
+			withHeader(contentTypeHeader, "application/json"),
+			withOperation(operation))
+		if _, err := validate(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRequestBodyValidationWithKinOpenAPI(b *testing.B) {
+	operation := requestBodyValidatorTestOperation(b, requestBodyValidatorTestSchema)
+	benchmarkRequestBodyValidation(b, operation, validateBodyAndPopulateDefaults)
+}
+
+func BenchmarkRequestBodyValidationWithCompiledSchema(b *testing.B) {
+	operation := requestBodyValidatorTestOperation(b, requestBodyValidatorTestSchema)
+	validator := newRequestBodyValidator(SpecOperation{Operation: operation})
+	benchmarkRequestBodyValidation(b, operation, func(ctx *Context) ([]byte, error) {
+		ctx.requestBodyValidator = validator
+		return validateDecodedBodyAndPopulateDefaults(ctx, decodeJSONBody)
+	})
+}
@@ -0,0 +1,109 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// PathParam is a path param of a request with the name of the param in the spec path template.
+type PathParam struct {
+	Key   string
+	Value string
+}
+
+// PathParams are the path params of a request in the order they appear in the path.
+type PathParams []PathParam
+
+// ByName returns the value of the path param with the name or an empty string if there is no such param.
+func (ps PathParams) ByName(name string) string {
+	for _, p := range ps {
+		if p.Key == name {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// RouteHandler handles a request matched by a RouteMatcher with its path params.
+type RouteHandler func(writer http.ResponseWriter, request *http.Request, params PathParams)
+
+// RouteMatcher is the routing engine that matches requests to the routes of the operations.
+//
+// The router registers the route of every implemented operation with Handle and dispatches requests to the handler
+// returned by Match. Handling of requests that don't match any route (e.g. OPTIONS and 404 responses) and trailing
+// slash or path case redirects are done by the router, so they behave the same for all the RouteMatcher implementations.
+//
+// NewHTTPRouterMatcher is used by default. Set Options.NewRouteMatcher to use a different routing engine.
+type RouteMatcher interface {
+	// Handle registers the handler of the route with the method and path.
+	// The path is an OpenAPI path template with the server base path (e.g. "/v1/tasks/{id}").
+	// Returns an error if the route can't be registered together with the routes that are already registered.
+	Handle(method string, path string, handler RouteHandler) error
+	// Match returns the handler and the path params of the route that matches the method and path.
+	// Returns false if no route matches.
+	Match(method string, path string) (RouteHandler, PathParams, bool)
+}
+
+// NewHTTPRouterMatcher returns a RouteMatcher implemented with github.com/julienschmidt/httprouter.
+//
+// httprouter can't register a static path segment and a path param at the same position of routes with the same
+// method (e.g. "/tasks/{id}" and "/tasks/archived"), so Handle returns an error for such routes.
+func NewHTTPRouterMatcher() RouteMatcher {
+	return httpRouterMatcher{router: httprouter.New()}
+}
+
+type httpRouterMatcher struct {
+	router *httprouter.Router
+}
+
+// Handle registers the route on httprouter.
+// httprouter panics when a route conflicts with a route that is already registered, so the panic is recovered and
+// returned as an error.
+func (m httpRouterMatcher) Handle(method string, path string, handler RouteHandler) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("%v", recovered)
+		}
+	}()
+	m.router.Handle(method, pathParamsMatcher.ReplaceAllString(path, ":$1"), func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		handler(writer, request, fromHTTPRouterParams(params))
+	})
+	return nil
+}
+
+func (m httpRouterMatcher) Match(method string, path string) (RouteHandler, PathParams, bool) {
+	handle, params, _ := m.router.Lookup(method, path)
+	if handle == nil {
+		return nil, nil, false
+	}
+	// the route handler is called with the params passed to the returned handler rather than the params matched here
+	return func(writer http.ResponseWriter, request *http.Request, params PathParams) {
+		handle(writer, request, toHTTPRouterParams(params))
+	}, fromHTTPRouterParams(params), true
+}
+
+func fromHTTPRouterParams(params httprouter.Params) PathParams {
+	pathParams := make(PathParams, len(params))
+	for i, param := range params {
+		pathParams[i] = PathParam{Key: param.Key, Value: param.Value}
+	}
+	return pathParams
+}
+
+func toHTTPRouterParams(params PathParams) httprouter.Params {
+	httpRouterParams := make(httprouter.Params, len(params))
+	for i, param := range params {
+		httpRouterParams[i] = httprouter.Param{Key: param.Key, Value: param.Value}
+	}
+	return httpRouterParams
+}
+
+// newRouteMatcher creates the RouteMatcher of the options or the default httprouter RouteMatcher.
+func (o Options) newRouteMatcher() RouteMatcher {
+	if o.NewRouteMatcher != nil {
+		return o.NewRouteMatcher()
+	}
+	return NewHTTPRouterMatcher()
+}
@@ -0,0 +1,238 @@
+package router
+
+import (
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/piiano/cellotape/router/utils"
+)
+
+var pathParamsMatcher = regexp.MustCompile(`\{([^/}]*)}`)
+
+// route is a method and path an operation is registered on, with the base path of its server.
+type route struct {
+	method      string
+	path        string
+	operationID string
+}
+
+// pattern returns the path of the route with the params names removed, so routes that match the same requests have
+// the same pattern.
+func (r route) pattern() string {
+	return r.method + " " + pathParamsMatcher.ReplaceAllString(r.path, "{}")
+}
+
+// findConflictingRoute returns the registered route that conflicts with the route when registered together on a new
+// RouteMatcher.
+func findConflictingRoute(newRouteMatcher func() RouteMatcher, registered []route, r route) (route, bool) {
+	for _, registeredRoute := range registered {
+		matcher := newRouteMatcher()
+		_ = matcher.Handle(registeredRoute.method, registeredRoute.path, noopRouteHandler)
+		if err := matcher.Handle(r.method, r.path, noopRouteHandler); err != nil {
+			return registeredRoute, true
+		}
+	}
+	return route{}, false
+}
+
+func noopRouteHandler(http.ResponseWriter, *http.Request, PathParams) {}
+
+// validateRoutes checks that the routes of the implemented operations resolved with their servers don't conflict.
+// Base paths that can't be resolved are reported by validateServers.
+func validateRoutes(oa openapi, flatOperations []operation) error {
+	log := oa.checkLogger(CheckRoutes, "", sourcePosition{})
+	specOperations := oa.spec.Operations()
+	registered := make(map[string]route)
+	registeredRoutes := make([]route, 0, len(flatOperations))
+	matcher := oa.options.newRouteMatcher()
+	checkedOperations := utils.NewSet[string]()
+	for _, flatOp := range flatOperations {
+		specOp, found := specOperations[flatOp.id]
+		if !found || !checkedOperations.Add(flatOp.id) {
+			continue
+		}
+		basePaths, err := oa.operationBasePaths(specOp)
+		if err != nil {
+			continue
+		}
+		operationLog := oa.checkLogger(CheckRoutes, flatOp.id, flatOp.sourcePosition)
+		for _, basePath := range basePaths {
+			r := route{method: specOp.Method, path: basePath + specOp.Path, operationID: flatOp.id}
+			if conflict, found := registered[r.pattern()]; found {
+				operationLog.Errorf(conflictingRoutes(r.method, r.path, r.operationID, conflict.path, conflict.operationID))
+				continue
+			}
+			// routes with a different pattern can still conflict in the route matcher
+			if err := matcher.Handle(r.method, r.path, noopRouteHandler); err != nil {
+				if conflict, found := findConflictingRoute(oa.options.newRouteMatcher, registeredRoutes, r); found {
+					operationLog.Errorf(conflictingRoutes(r.method, r.path, r.operationID, conflict.path, conflict.operationID))
+				} else {
+					operationLog.Errorf(invalidRoute(r.method, r.path, r.operationID, err))
+				}
+				continue
+			}
+			registered[r.pattern()] = r
+			registeredRoutes = append(registeredRoutes, r)
+		}
+		log.AppendCounters(operationLog.Counters())
+	}
+	return log.MustHaveNoErrors()
+}
+
+// routesHandler dispatches requests to the routes registered on a RouteMatcher and handles the requests that don't
+// match any route.
+type routesHandler struct {
+	matcher RouteMatcher
+	// methods are the methods of the registered routes.
+	methods utils.Set[string]
+	// routes are the registered routes in the order they were registered.
+	routes []route
+	// optionsHandler handles OPTIONS requests to paths without an OPTIONS route. Nil to respond with not found.
+	optionsHandler http.Handler
+	// methodNotAllowedHandler handles requests to paths with routes of other methods. Nil to respond with not found.
+	methodNotAllowedHandler http.Handler
+	// notFoundHandler handles requests that don't match any route.
+	notFoundHandler http.Handler
+}
+
+func newRoutesHandler(matcher RouteMatcher) *routesHandler {
+	return &routesHandler{
+		matcher:         matcher,
+		methods:         utils.NewSet[string](),
+		notFoundHandler: http.HandlerFunc(DefaultNotFoundHandler),
+	}
+}
+
+// handle registers the route on the route matcher.
+func (h *routesHandler) handle(method string, path string, handler RouteHandler) error {
+	if err := h.matcher.Handle(method, path, handler); err != nil {
+		return err
+	}
+	h.methods.Add(method)
+	h.routes = append(h.routes, route{method: method, path: path})
+	return nil
+}
+
+// allowed returns the sorted methods of the routes that match the path.
+// OPTIONS is allowed for every path with a route if OPTIONS requests are handled by the options handler.
+func (h *routesHandler) allowed(path string) []string {
+	allowed := make([]string, 0, len(h.methods)+1)
+	for method := range h.methods {
+		if method == http.MethodOptions && h.optionsHandler != nil {
+			continue
+		}
+		if _, _, found := h.matcher.Match(method, path); found {
+			allowed = append(allowed, method)
+		}
+	}
+	if len(allowed) > 0 && h.optionsHandler != nil {
+		allowed = append(allowed, http.MethodOptions)
+	}
+	sort.Strings(allowed)
+	return allowed
+}
+
+func (h *routesHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	path := request.URL.Path
+	if handler, params, found := h.matcher.Match(request.Method, path); found {
+		handler(writer, request, params)
+		return
+	}
+	if h.redirect(writer, request) {
+		return
+	}
+	if request.Method == http.MethodOptions && h.optionsHandler != nil {
+		if allowed := h.allowed(path); len(allowed) > 0 {
+			writer.Header().Set("Allow", strings.Join(allowed, ", "))
+			h.optionsHandler.ServeHTTP(writer, request)
+			return
+		}
+	}
+	// OPTIONS requests are not allowed only when they are not handled by the options handler
+	if request.Method != http.MethodOptions && h.methodNotAllowedHandler != nil {
+		if allowed := h.allowed(path); len(allowed) > 0 {
+			writer.Header().Set("Allow", strings.Join(allowed, ", "))
+			h.methodNotAllowedHandler.ServeHTTP(writer, request)
+			return
+		}
+	}
+	h.notFoundHandler.ServeHTTP(writer, request)
+}
+
+// redirect redirects the request to the same path with or without a trailing slash, or to the cleaned path, if a route
+// matches it. Otherwise, like the RedirectFixedPath option of httprouter, the request is redirected to the cleaned path
+// with or without a trailing slash if it matches a route case-insensitively.
+// Returns false if the request is not redirected.
+func (h *routesHandler) redirect(writer http.ResponseWriter, request *http.Request) bool {
+	requestPath := request.URL.Path
+	if request.Method == http.MethodConnect || requestPath == "/" {
+		return false
+	}
+	// permanent redirect for GET requests and temporary redirect with the same method for other requests
+	code := http.StatusMovedPermanently
+	if request.Method != http.MethodGet {
+		code = http.StatusTemporaryRedirect
+	}
+	toggledSlashPath := requestPath + "/"
+	if strings.HasSuffix(requestPath, "/") {
+		toggledSlashPath = strings.TrimSuffix(requestPath, "/")
+	}
+	cleanedPath := path.Clean(requestPath)
+	candidates := []string{toggledSlashPath, cleanedPath, cleanedPath + "/"}
+	for _, candidate := range candidates {
+		if candidate == requestPath || candidate == "" {
+			continue
+		}
+		if _, _, found := h.matcher.Match(request.Method, candidate); found {
+			redirectTo(writer, request, candidate, code)
+			return true
+		}
+	}
+	for _, candidate := range []string{cleanedPath, cleanedPath + "/"} {
+		if fixedPath, found := h.fixPathCase(request.Method, candidate); found && fixedPath != requestPath {
+			redirectTo(writer, request, fixedPath, code)
+			return true
+		}
+	}
+	return false
+}
+
+// redirectTo redirects the request to the path, keeping the query of the request.
+func redirectTo(writer http.ResponseWriter, request *http.Request, path string, code int) {
+	redirectURL := *request.URL
+	redirectURL.Path = path
+	http.Redirect(writer, request, redirectURL.String(), code)
+}
+
+// fixPathCase returns the path with the case of the static segments of the first route of the method that matches
+// the path case-insensitively. Segments with path params keep the case of the path.
+// Returns false if no route matches.
+func (h *routesHandler) fixPathCase(method string, requestPath string) (string, bool) {
+	segments := strings.Split(requestPath, "/")
+routes:
+	for _, r := range h.routes {
+		routeSegments := strings.Split(r.path, "/")
+		if r.method != method || len(routeSegments) != len(segments) {
+			continue
+		}
+		fixedSegments := make([]string, len(segments))
+		for i, routeSegment := range routeSegments {
+			switch {
+			case pathParamsMatcher.MatchString(routeSegment):
+				fixedSegments[i] = segments[i]
+			case strings.EqualFold(routeSegment, segments[i]):
+				fixedSegments[i] = routeSegment
+			default:
+				continue routes
+			}
+		}
+		fixedPath := strings.Join(fixedSegments, "/")
+		if _, _, found := h.matcher.Match(method, fixedPath); found {
+			return fixedPath, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,194 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const conflictingRoutesTestSpec = `openapi: 3.0.3
+info:
+  title: test
+  version: 1.0.0
+paths:
+  /tasks/{id}:
+    get:
+      operationId: getTask
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: string
+      responses:
+        "200":
+          description: ok
+  /tasks/archived:
+    get:
+      operationId: getArchivedTasks
+      responses:
+        "200":
+          description: ok
+    post:
+      operationId: archiveTasks
+      responses:
+        "200":
+          description: ok
+`
+
+func TestConflictingRoutes(t *testing.T) {
+	spec, err := NewSpecFromData([]byte(conflictingRoutesTestSpec))
+	require.NoError(t, err)
+	router := NewOpenAPIRouterWithOptions(spec, DefaultTestOptions())
+	router.WithOperation("getTask", HandlerFunc[Nil, struct {
+		ID string `uri:"id"`
+	}, Nil, OKResponse[Nil]](func(_ *Context, _ Request[Nil, struct {
+		ID string `uri:"id"`
+	}, Nil]) (Response[OKResponse[Nil]], error) {
+		return SendOK(OKResponse[Nil]{}), nil
+	}))
+	for _, id := range []string{"getArchivedTasks", "archiveTasks"} {
+		router.WithOperation(id, HandlerFunc[Nil, Nil, Nil, OKResponse[Nil]](func(_ *Context, _ Request[Nil, Nil, Nil]) (Response[OKResponse[Nil]], error) {
+			return SendOK(OKResponse[Nil]{}), nil
+		}))
+	}
+
+	report, err := router.Validate()
+	require.Error(t, err)
+	require.Len(t, report.Results, 1)
+	assert.Equal(t, CheckRoutes, report.Results[0].Check)
+	assert.Equal(t, ErrorSeverity, report.Results[0].Severity)
+	assert.Equal(t, "getArchivedTasks", report.Results[0].OperationID)
+	assert.Equal(t, conflictingRoutes("GET", "/tasks/archived", "getArchivedTasks", "/tasks/{id}", "getTask"), report.Results[0].Message)
+
+	assert.NotPanics(t, func() {
+		_, err = router.AsHandler()
+	})
+	require.Error(t, err)
+}
+
+func TestHTTPRouterMatcher(t *testing.T) {
+	matcher := NewHTTPRouterMatcher()
+
+	require.NoError(t, matcher.Handle("GET", "/tasks/{id}", noopRouteHandler))
+	require.NoError(t, matcher.Handle("POST", "/tasks/archived", noopRouteHandler))
+	require.Error(t, matcher.Handle("GET", "/tasks/archived", noopRouteHandler))
+	require.Error(t, matcher.Handle("GET", "/tasks/{taskId}/status", noopRouteHandler))
+
+	_, params, found := matcher.Match("GET", "/tasks/123")
+	require.True(t, found)
+	assert.Equal(t, PathParams{{Key: "id", Value: "123"}}, params)
+	assert.Equal(t, "123", params.ByName("id"))
+	assert.Equal(t, "", params.ByName("taskId"))
+
+	_, _, found = matcher.Match("POST", "/tasks/123")
+	assert.False(t, found)
+}
+
+func TestHTTPRouterMatcherReturnsRouteHandlers(t *testing.T) {
+	matcher := NewHTTPRouterMatcher()
+	var called []string
+	routeHandler := func(name string) RouteHandler {
+		return func(_ http.ResponseWriter, _ *http.Request, params PathParams) {
+			called = append(called, name+":"+params.ByName("id"))
+		}
+	}
+	require.NoError(t, matcher.Handle("GET", "/tasks/{id}", routeHandler("getTask")))
+	require.NoError(t, matcher.Handle("DELETE", "/tasks/{id}", routeHandler("deleteTask")))
+	require.NoError(t, matcher.Handle("GET", "/users/{id}", routeHandler("getUser")))
+
+	for _, request := range [][2]string{{"GET", "/tasks/1"}, {"DELETE", "/tasks/2"}, {"GET", "/users/3"}} {
+		handler, params, found := matcher.Match(request[0], request[1])
+		require.True(t, found)
+		handler(nil, nil, params)
+	}
+	assert.Equal(t, []string{"getTask:1", "deleteTask:2", "getUser:3"}, called)
+
+	// the handler is called with the params it is given
+	handler, _, found := matcher.Match("GET", "/tasks/1")
+	require.True(t, found)
+	handler(nil, nil, PathParams{{Key: "id", Value: "4"}})
+	assert.Equal(t, "getTask:4", called[len(called)-1])
+}
+
+func TestFindConflictingRoute(t *testing.T) {
+	registered := []route{
+		{method: "GET", path: "/tasks", operationID: "getTasks"},
+		{method: "POST", path: "/tasks/{id}", operationID: "updateTask"},
+		{method: "GET", path: "/tasks/{id}", operationID: "getTask"},
+	}
+	conflict, found := findConflictingRoute(NewHTTPRouterMatcher, registered, route{method: "GET", path: "/tasks/archived"})
+	require.True(t, found)
+	assert.Equal(t, "getTask", conflict.operationID)
+
+	_, found = findConflictingRoute(NewHTTPRouterMatcher, registered, route{method: "GET", path: "/users/{id}"})
+	assert.False(t, found)
+}
+
+func TestRoutesHandlerRedirect(t *testing.T) {
+	router := newRoutesHandler(NewHTTPRouterMatcher())
+	require.NoError(t, router.handle("GET", "/tasks", noopRouteHandler))
+	require.NoError(t, router.handle("POST", "/tasks/{id}/", noopRouteHandler))
+
+	testCases := []struct {
+		method   string
+		path     string
+		status   int
+		location string
+	}{
+		{method: "GET", path: "/tasks/", status: 301, location: "/tasks"},
+		{method: "GET", path: "/tasks/../tasks", status: 301, location: "/tasks"},
+		{method: "GET", path: "//tasks?page=2", status: 301, location: "/tasks?page=2"},
+		{method: "POST", path: "/tasks/1", status: 307, location: "/tasks/1/"},
+		{method: "POST", path: "/tasks", status: 404},
+		{method: "GET", path: "/", status: 404},
+		{method: "GET", path: "/TASKS?page=2", status: 301, location: "/tasks?page=2"},
+		{method: "GET", path: "/Tasks/", status: 301, location: "/tasks"},
+		{method: "GET", path: "//TASKS", status: 301, location: "/tasks"},
+		{method: "POST", path: "/Tasks/AbC", status: 307, location: "/tasks/AbC/"},
+		{method: "GET", path: "/TASK", status: 404},
+	}
+	for _, testCase := range testCases {
+		writer := httptest.NewRecorder()
+		router.ServeHTTP(writer, httptest.NewRequest(testCase.method, testCase.path, nil))
+		assert.Equalf(t, testCase.status, writer.Code, "%s %s", testCase.method, testCase.path)
+		assert.Equal(t, testCase.location, writer.Header().Get("Location"))
+	}
+}
+
+type testRouteMatcher struct {
+	RouteMatcher
+	handled []string
+}
+
+func (m *testRouteMatcher) Handle(method string, path string, handler RouteHandler) error {
+	m.handled = append(m.handled, method+" "+path)
+	return m.RouteMatcher.Handle(method, path, handler)
+}
+
+func TestCustomRouteMatcher(t *testing.T) {
+	spec, err := NewSpecFromData([]byte(serversTestSpec))
+	require.NoError(t, err)
+	var matcher *testRouteMatcher
+	options := DefaultTestOptions()
+	options.UseServersBasePaths = true
+	options.NewRouteMatcher = func() RouteMatcher {
+		// the last matcher created is the matcher of the handler
+		matcher = &testRouteMatcher{RouteMatcher: NewHTTPRouterMatcher()}
+		return matcher
+	}
+	handler, err := NewOpenAPIRouterWithOptions(spec, options).
+		WithOperation("greet", HandlerFunc[Nil, Nil, Nil, OKResponse[Nil]](func(_ *Context, _ Request[Nil, Nil, Nil]) (Response[OKResponse[Nil]], error) {
+			return SendOK(OKResponse[Nil]{}), nil
+		})).
+		AsHandler()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GET /v1/greet", "GET /v2/greet"}, matcher.handled)
+
+	writer := httptest.NewRecorder()
+	handler.ServeHTTP(writer, httptest.NewRequest(http.MethodGet, "/v1/greet", nil))
+	assert.Equal(t, 200, writer.Code)
+}
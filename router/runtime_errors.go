@@ -36,6 +36,50 @@ func (in In) String() string {
 	return inString
 }
 
+// RuntimeValidationViolation describes a request or a response that violates the spec found by the runtime validations.
+type RuntimeValidationViolation struct {
+	// Context is the context of the request.
+	Context *Context
+	// Response is true when the response violates the spec and false when the request violates the spec.
+	Response bool
+	// In is the location of the violation in the request. It is relevant only for request violations.
+	In In
+	// Err is the validation error.
+	Err error
+}
+
+func (v RuntimeValidationViolation) Error() string {
+	if v.Response {
+		return fmt.Sprintf("%s. response violates the spec", v.Err)
+	}
+	return fmt.Sprintf("%s. request %s violates the spec", v.Err, v.In)
+}
+
+func (v RuntimeValidationViolation) Unwrap() error {
+	return v.Err
+}
+
+// handleRuntimeValidationViolation reports the violation to Options.RuntimeValidationViolationHandler, or logs it as a
+// warning with the runtime logger when the behaviour is PrintWarning and no handler is set.
+// Returns the violation error only if the behaviour is PropagateError.
+func (o Options) handleRuntimeValidationViolation(behaviour Behaviour, violation RuntimeValidationViolation) error {
+	if o.RuntimeValidationViolationHandler != nil {
+		o.RuntimeValidationViolationHandler(violation)
+	} else if behaviour == PrintWarning {
+		if violation.Response {
+			o.runtimeLogger().Warn("response violates the spec", runtimeLogFields(violation.Context,
+				"status", violation.Context.RawResponse.Status, "error", violation.Err)...)
+		} else {
+			o.runtimeLogger().Warn("request violates the spec", runtimeLogFields(violation.Context,
+				"in", violation.In.String(), "error", violation.Err)...)
+		}
+	}
+	if behaviour == PropagateError {
+		return violation.Err
+	}
+	return nil
+}
+
 // BadRequestErr is the error returned when there is an error binding the request.
 // You can handle this request using an ErrorHandler middleware to return a custom HTTP response.
 type BadRequestErr struct {
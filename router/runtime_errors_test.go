@@ -11,7 +11,6 @@ import (
 	"testing"
 
 	"github.com/getkin/kin-openapi/openapi3"
-	"github.com/julienschmidt/httprouter"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -31,7 +30,7 @@ func testContext(modifiers ...contextModifier) *Context {
 			Header: http.Header{},
 		},
 		Writer: &httptest.ResponseRecorder{},
-		Params: &httprouter.Params{},
+		Params: PathParams{},
 	}
 	for _, modifier := range modifiers {
 		modifier(ctx)
@@ -59,7 +58,7 @@ func withBodyReader(bodyReader io.ReadCloser) contextModifier {
 	}
 }
 
-func withParams(params *httprouter.Params) contextModifier {
+func withParams(params PathParams) contextModifier {
 	return func(ctx *Context) {
 		ctx.Params = params
 	}
@@ -79,6 +78,12 @@ func withOperation(operation *openapi3.Operation) contextModifier {
 	}
 }
 
+func withRequestBodyValidator(validator *requestBodyValidator) contextModifier {
+	return func(ctx *Context) {
+		ctx.requestBodyValidator = validator
+	}
+}
+
 func withResponseWriter(writer http.ResponseWriter) contextModifier {
 	return func(ctx *Context) {
 		ctx.Writer = writer
@@ -149,7 +154,7 @@ func TestErrorHandler(t *testing.T) {
 	}
 	handlerFunc := errorHandler.handlerFactory(openapi{contentTypes: ContentTypes{
 		"text/plain": PlainTextContentType{},
-	}}, func(c *Context) (RawResponse, error) {
+	}}, "", func(c *Context) (RawResponse, error) {
 		if c.Params.ByName("foo") == "bar" {
 			return RawResponse{}, errors.New("foo can not be bar")
 		}
@@ -161,7 +166,7 @@ func TestErrorHandler(t *testing.T) {
 	assert.Equal(t, successResponse, resp)
 
 	testContextWithParam := testContext()
-	testContextWithParam.Params = &httprouter.Params{{
+	testContextWithParam.Params = PathParams{{
 		Key: "foo", Value: "bar",
 	}}
 	resp, err = handlerFunc(testContextWithParam)
@@ -170,3 +175,22 @@ func TestErrorHandler(t *testing.T) {
 	assert.Equal(t, "foo can not be bar", string(resp.Body))
 	assert.Equal(t, "text/plain", resp.ContentType)
 }
+
+func TestRuntimeValidationViolation(t *testing.T) {
+	err := errors.New("invalid value")
+	requestViolation := RuntimeValidationViolation{In: InQueryParams, Err: err}
+	assert.Equal(t, "invalid value. request query param violates the spec", requestViolation.Error())
+	assert.ErrorIs(t, requestViolation, err)
+
+	responseViolation := RuntimeValidationViolation{Response: true, Err: err}
+	assert.Equal(t, "invalid value. response violates the spec", responseViolation.Error())
+
+	var reported []RuntimeValidationViolation
+	options := DefaultOptions()
+	options.RuntimeValidationViolationHandler = func(violation RuntimeValidationViolation) {
+		reported = append(reported, violation)
+	}
+	assert.ErrorIs(t, options.handleRuntimeValidationViolation(PropagateError, requestViolation), err)
+	assert.NoError(t, options.handleRuntimeValidationViolation(PrintWarning, requestViolation))
+	assert.Len(t, reported, 2)
+}
@@ -0,0 +1,80 @@
+package router
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/piiano/cellotape/router/utils"
+)
+
+// RuntimeLogger logs diagnostics while handling requests at runtime.
+// Structured fields are passed as alternating keys and values after the message.
+// A *slog.Logger implements RuntimeLogger and can be set as Options.RuntimeLogger.
+type RuntimeLogger interface {
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// runtimeLogger returns the RuntimeLogger defined by the options.
+// When Options.RuntimeLogger is not set, the runtime diagnostics are printed to Options.LogOutput according to
+// Options.LogLevel.
+func (o Options) runtimeLogger() RuntimeLogger {
+	if o.RuntimeLogger != nil {
+		return o.RuntimeLogger
+	}
+	return defaultRuntimeLogger{output: o.LogOutput, level: o.LogLevel}
+}
+
+// runtimeLogFields returns the structured fields describing the request of the context followed by the given fields.
+func runtimeLogFields(ctx *Context, fields ...any) []any {
+	logFields := make([]any, 0, 6+len(fields))
+	if ctx != nil {
+		if ctx.Operation.Operation != nil {
+			logFields = append(logFields, "operationId", ctx.Operation.OperationID)
+		}
+		if ctx.Request != nil {
+			logFields = append(logFields, "method", ctx.Request.Method)
+			if ctx.Request.URL != nil {
+				logFields = append(logFields, "path", ctx.Request.URL.Path)
+			}
+		}
+	}
+	return append(logFields, fields...)
+}
+
+// defaultRuntimeLogger prints runtime diagnostics in the same format as the logs printed when creating the router
+// followed by the structured fields as key=value pairs.
+type defaultRuntimeLogger struct {
+	output io.Writer
+	level  LogLevel
+}
+
+func (l defaultRuntimeLogger) Warn(msg string, args ...any) {
+	l.log(utils.Warn, "[Warning]", msg, args)
+}
+func (l defaultRuntimeLogger) Error(msg string, args ...any) {
+	l.log(utils.Error, "[Error]", msg, args)
+}
+
+func (l defaultRuntimeLogger) log(level LogLevel, prefix string, msg string, args []any) {
+	if l.output == nil || l.level == utils.Off || l.level < level {
+		return
+	}
+	var line strings.Builder
+	line.WriteString(prefix)
+	line.WriteString(" ")
+	line.WriteString(msg)
+	for i := 0; i < len(args); i += 2 {
+		key, value := fmt.Sprint(args[i]), "!MISSING"
+		if i+1 < len(args) {
+			value = fmt.Sprint(args[i+1])
+		}
+		if value == "" || strings.ContainsAny(value, " =\"\n\t") {
+			value = fmt.Sprintf("%q", value)
+		}
+		line.WriteString(fmt.Sprintf(" %s=%s", key, value))
+	}
+	line.WriteString("\n")
+	_, _ = io.WriteString(l.output, line.String())
+}
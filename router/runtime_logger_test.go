@@ -0,0 +1,85 @@
+package router
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/piiano/cellotape/router/utils"
+)
+
+type runtimeLogEntry struct {
+	level string
+	msg   string
+	args  []any
+}
+
+type testRuntimeLogger struct {
+	entries []runtimeLogEntry
+}
+
+func (l *testRuntimeLogger) Warn(msg string, args ...any) {
+	l.entries = append(l.entries, runtimeLogEntry{level: "warn", msg: msg, args: args})
+}
+
+func (l *testRuntimeLogger) Error(msg string, args ...any) {
+	l.entries = append(l.entries, runtimeLogEntry{level: "error", msg: msg, args: args})
+}
+
+func TestDefaultRuntimeLogger(t *testing.T) {
+	testCases := []struct {
+		level    LogLevel
+		expected string
+	}{
+		{level: utils.Info, expected: "[Warning] warn message operationId=test method=GET path=/foo error=\"bad value\" status=400\n" +
+			"[Error] error message key=!MISSING\n"},
+		{level: utils.Warn, expected: "[Warning] warn message operationId=test method=GET path=/foo error=\"bad value\" status=400\n" +
+			"[Error] error message key=!MISSING\n"},
+		{level: utils.Error, expected: "[Error] error message key=!MISSING\n"},
+		{level: utils.Off, expected: ""},
+	}
+	for _, test := range testCases {
+		level, err := test.level.MarshalText()
+		require.NoError(t, err)
+		t.Run(string(level), func(t *testing.T) {
+			var output strings.Builder
+			options := DefaultOptions()
+			options.LogOutput = &output
+			options.LogLevel = test.level
+			logger := options.runtimeLogger()
+			ctx := testContext(withOperation(&openapi3.Operation{OperationID: "test"}), func(ctx *Context) {
+				ctx.Request.Method = "GET"
+				ctx.Request.URL.Path = "/foo"
+			})
+
+			logger.Warn("warn message", runtimeLogFields(ctx, "error", errors.New("bad value"), "status", 400)...)
+			logger.Error("error message", "key")
+
+			assert.Equal(t, test.expected, output.String())
+		})
+	}
+}
+
+func TestCustomRuntimeLogger(t *testing.T) {
+	logger := &testRuntimeLogger{}
+	options := DefaultOptions()
+	options.RuntimeLogger = logger
+	violation := RuntimeValidationViolation{
+		Context: testContext(withOperation(&openapi3.Operation{OperationID: "test"})),
+		In:      InQueryParams,
+		Err:     errors.New("bad query"),
+	}
+
+	err := options.handleRuntimeValidationViolation(PrintWarning, violation)
+
+	require.NoError(t, err)
+	require.Len(t, logger.entries, 1)
+	assert.Equal(t, "warn", logger.entries[0].level)
+	assert.Equal(t, "request violates the spec", logger.entries[0].msg)
+	assert.Equal(t, []any{"operationId", "test", "method", "", "path", "", "in", "query param", "error", violation.Err},
+		logger.entries[0].args)
+}
@@ -2,6 +2,11 @@ package router
 
 import (
 	"errors"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
 	"sync"
 
 	"github.com/getkin/kin-openapi/openapi3"
@@ -14,24 +19,160 @@ var (
 
 	// ErrInvalidUUID is returned when the uuid format validation fails.
 	ErrInvalidUUID = errors.New("invalid uuid")
+	// ErrInvalidEmail is returned when the email format validation fails.
+	ErrInvalidEmail = errors.New("invalid email")
+	// ErrInvalidIPv4 is returned when the ipv4 format validation fails.
+	ErrInvalidIPv4 = errors.New("invalid ipv4")
+	// ErrInvalidIPv6 is returned when the ipv6 format validation fails.
+	ErrInvalidIPv6 = errors.New("invalid ipv6")
+	// ErrInvalidHostname is returned when the hostname format validation fails.
+	ErrInvalidHostname = errors.New("invalid hostname")
+	// ErrInvalidURI is returned when the uri or uri-reference format validation fails.
+	ErrInvalidURI = errors.New("invalid uri")
+	// ErrInvalidDuration is returned when the duration format validation fails.
+	ErrInvalidDuration = errors.New("invalid duration")
+	// ErrInvalidRegex is returned when the regex format validation fails.
+	ErrInvalidRegex = errors.New("invalid regex")
+	// ErrInvalidJSONPointer is returned when the json-pointer or relative-json-pointer format validation fails.
+	ErrInvalidJSONPointer = errors.New("invalid json pointer")
+
+	// durationPattern matches an ISO 8601 duration as described by RFC 3339 appendix A.
+	// Empty durations ("P" or "PT") are rejected separately as they are not expressible without lookahead.
+	durationPattern = regexp.MustCompile(`^P(\d+Y)?(\d+M)?(\d+W)?(\d+D)?(T(\d+H)?(\d+M)?(\d+(\.\d+)?S)?)?$`)
+	// hostnameLabelPattern matches a single hostname label as defined by RFC 1123.
+	hostnameLabelPattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+	// relativeJSONPointerPrefixPattern matches the non-negative integer prefix of a relative JSON pointer.
+	relativeJSONPointerPrefixPattern = regexp.MustCompile(`^(0|[1-9][0-9]*)`)
 )
 
 // registerAdditionalOpenAPIFormatValidations registers additional validations that are not supported by the kin-openapi.
 // kin-openapi supports out of the box the following formats: `date-time`, `date` and `byte`.
+//
+// The `time` format is intentionally not validated at runtime as the type validations accept time.Time for it which is
+// serialized as a `date-time`.
 func registerAdditionalOpenAPIFormatValidations() {
 
 	// Make sure we register the validations only once (even if we have multiple openapi instances)
 	// This is because the kin-openapi library uses a global map to store the validations and we don't want to have a
 	// race condition when initializing multiple routers.
 	registerOpenAPIFormatValidationsOnce.Do(func() {
+		openapi3.DefineStringFormatCallback("uuid", validateUUIDFormat)
+		openapi3.DefineStringFormatCallback("email", validateEmailFormat)
+		openapi3.DefineStringFormatCallback("ipv4", validateIPv4Format)
+		openapi3.DefineStringFormatCallback("ipv6", validateIPv6Format)
+		openapi3.DefineStringFormatCallback("hostname", validateHostnameFormat)
+		openapi3.DefineStringFormatCallback("uri", validateURIFormat)
+		openapi3.DefineStringFormatCallback("uri-reference", validateURIReferenceFormat)
+		openapi3.DefineStringFormatCallback("duration", validateDurationFormat)
+		openapi3.DefineStringFormatCallback("regex", validateRegexFormat)
+		openapi3.DefineStringFormatCallback("json-pointer", validateJSONPointerFormat)
+		openapi3.DefineStringFormatCallback("relative-json-pointer", validateRelativeJSONPointerFormat)
+	})
+}
 
-		// Register uuid format validation
-		openapi3.DefineStringFormatCallback("uuid", func(value string) error {
-			if _, err := uuid.Parse(value); err != nil {
-				return ErrInvalidUUID
-			}
+func validateUUIDFormat(value string) error {
+	if _, err := uuid.Parse(value); err != nil {
+		return ErrInvalidUUID
+	}
+	return nil
+}
 
-			return nil
-		})
-	})
+// validateEmailFormat accepts a bare address as defined by RFC 5322 without a display name.
+func validateEmailFormat(value string) error {
+	address, err := mail.ParseAddress(value)
+	if err != nil || address.Address != value {
+		return ErrInvalidEmail
+	}
+	return nil
+}
+
+func validateIPv4Format(value string) error {
+	addr, err := netip.ParseAddr(value)
+	if err != nil || !addr.Is4() {
+		return ErrInvalidIPv4
+	}
+	return nil
+}
+
+// validateIPv6Format accepts IPv6 addresses as defined in RFC 4291 which doesn't include scoped addresses with a zone.
+func validateIPv6Format(value string) error {
+	addr, err := netip.ParseAddr(value)
+	if err != nil || !addr.Is6() || addr.Zone() != "" {
+		return ErrInvalidIPv6
+	}
+	return nil
+}
+
+// validateHostnameFormat accepts hostnames as defined by RFC 1123 section 2.1.
+func validateHostnameFormat(value string) error {
+	if len(value) == 0 || len(value) > 253 {
+		return ErrInvalidHostname
+	}
+	for _, label := range strings.Split(value, ".") {
+		if !hostnameLabelPattern.MatchString(label) {
+			return ErrInvalidHostname
+		}
+	}
+	return nil
+}
+
+// validateURIFormat accepts absolute URIs (with a scheme) as defined by RFC 3986.
+func validateURIFormat(value string) error {
+	uri, err := url.Parse(value)
+	if err != nil || !uri.IsAbs() {
+		return ErrInvalidURI
+	}
+	return nil
+}
+
+// validateURIReferenceFormat accepts either a URI or a relative reference as defined by RFC 3986.
+func validateURIReferenceFormat(value string) error {
+	if _, err := url.Parse(value); err != nil {
+		return ErrInvalidURI
+	}
+	return nil
+}
+
+func validateDurationFormat(value string) error {
+	if value == "P" || strings.HasSuffix(value, "T") || !durationPattern.MatchString(value) {
+		return ErrInvalidDuration
+	}
+	return nil
+}
+
+// validateRegexFormat accepts regular expressions that compile with the go regexp package.
+// The go regexp syntax (RE2) is a subset of ECMA-262 so some valid expressions like lookarounds are rejected.
+func validateRegexFormat(value string) error {
+	if _, err := regexp.Compile(value); err != nil {
+		return ErrInvalidRegex
+	}
+	return nil
+}
+
+// validateJSONPointerFormat accepts JSON pointers as defined by RFC 6901.
+func validateJSONPointerFormat(value string) error {
+	if value == "" {
+		return nil
+	}
+	if !strings.HasPrefix(value, "/") {
+		return ErrInvalidJSONPointer
+	}
+	for i := 0; i < len(value); i++ {
+		if value[i] == '~' && (i+1 == len(value) || (value[i+1] != '0' && value[i+1] != '1')) {
+			return ErrInvalidJSONPointer
+		}
+	}
+	return nil
+}
+
+// validateRelativeJSONPointerFormat accepts relative JSON pointers as defined by draft-handrews-relative-json-pointer.
+func validateRelativeJSONPointerFormat(value string) error {
+	prefix := relativeJSONPointerPrefixPattern.FindString(value)
+	if prefix == "" {
+		return ErrInvalidJSONPointer
+	}
+	if rest := value[len(prefix):]; rest != "#" {
+		return validateJSONPointerFormat(rest)
+	}
+	return nil
 }
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/getkin/kin-openapi/openapi3"
@@ -21,3 +22,87 @@ func TestStringFormatValidation(t *testing.T) {
 	err = uuidSchema.VisitJSON("not-a-uuid", openapi3.EnableFormatValidation())
 	require.ErrorIs(t, err, ErrInvalidUUID)
 }
+
+func TestAdditionalStringFormatValidations(t *testing.T) {
+	registerAdditionalOpenAPIFormatValidations()
+
+	testCases := []struct {
+		format  string
+		valid   []string
+		invalid []string
+		err     error
+	}{
+		{
+			format:  "email",
+			valid:   []string{"john@example.com", "john.doe+tag@sub.example.com"},
+			invalid: []string{"john", "John Doe <john@example.com>", "john@"},
+			err:     ErrInvalidEmail,
+		},
+		{
+			format:  "ipv4",
+			valid:   []string{"127.0.0.1", "192.168.1.255"},
+			invalid: []string{"::1", "256.0.0.1", "localhost"},
+			err:     ErrInvalidIPv4,
+		},
+		{
+			format:  "ipv6",
+			valid:   []string{"::1", "2001:db8::ff00:42:8329"},
+			invalid: []string{"127.0.0.1", "fe80::1%eth0", "2001:db8:::1"},
+			err:     ErrInvalidIPv6,
+		},
+		{
+			format:  "hostname",
+			valid:   []string{"localhost", "api.example.com", "xn--bcher-kva.example"},
+			invalid: []string{"", "-example.com", "example..com", strings.Repeat("a", 64) + ".com"},
+			err:     ErrInvalidHostname,
+		},
+		{
+			format:  "uri",
+			valid:   []string{"https://example.com/path?q=1", "urn:isbn:0451450523"},
+			invalid: []string{"/relative/path", "://missing-scheme"},
+			err:     ErrInvalidURI,
+		},
+		{
+			format:  "uri-reference",
+			valid:   []string{"https://example.com", "/relative/path", "#fragment"},
+			invalid: []string{"://missing-scheme"},
+			err:     ErrInvalidURI,
+		},
+		{
+			format:  "duration",
+			valid:   []string{"P1Y2M3DT4H5M6S", "PT0.5S", "P2W", "P1D"},
+			invalid: []string{"P", "PT", "P1DT", "1D", "P1S"},
+			err:     ErrInvalidDuration,
+		},
+		{
+			format:  "regex",
+			valid:   []string{"^[a-z]+$", ".*"},
+			invalid: []string{"[a-z", "(unclosed"},
+			err:     ErrInvalidRegex,
+		},
+		{
+			format:  "json-pointer",
+			valid:   []string{"", "/", "/foo/0", "/a~1b/m~0n"},
+			invalid: []string{"foo", "/foo~2", "/foo~"},
+			err:     ErrInvalidJSONPointer,
+		},
+		{
+			format:  "relative-json-pointer",
+			valid:   []string{"0", "1/foo", "2#", "0/a~1b"},
+			invalid: []string{"", "/foo", "01/foo", "1foo"},
+			err:     ErrInvalidJSONPointer,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.format, func(t *testing.T) {
+			schema := openapi3.NewStringSchema().WithFormat(testCase.format)
+			for _, value := range testCase.valid {
+				require.NoErrorf(t, schema.VisitJSON(value, openapi3.EnableFormatValidation()), "expect %q to be valid", value)
+			}
+			for _, value := range testCase.invalid {
+				require.ErrorIsf(t, schema.VisitJSON(value, openapi3.EnableFormatValidation()), testCase.err, "expect %q to be invalid", value)
+			}
+		})
+	}
+}
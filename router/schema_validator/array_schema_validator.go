@@ -7,17 +7,17 @@ import (
 func (c typeSchemaValidatorContext) validateArraySchema() {
 	isGoTypeArray := isArrayGoType(c.goType)
 	if c.schema.Type != nil && c.schema.Type.Is(openapi3.TypeArray) && !isGoTypeArray {
-		c.err(schemaTypeIsIncompatibleWithType(c.schema, c.goType))
+		c.err(RuleType, schemaTypeIsIncompatibleWithType(c.schema, c.goType))
 	}
 
 	if !isSchemaTypeArrayOrEmpty(c.schema) {
 		if isGoTypeArray && !isSliceOfBytes(c.goType) {
-			c.err(schemaTypeIsIncompatibleWithType(c.schema, c.goType))
+			c.err(RuleType, schemaTypeIsIncompatibleWithType(c.schema, c.goType))
 		}
 		return
 	}
 
 	if isGoTypeArray && c.schema.Items != nil {
-		_ = c.WithSchemaAndType(*c.schema.Items.Value, c.goType.Elem()).Validate()
+		_ = c.withSchemaRef(c.schema.Items, "items").withElem(c.goType.Elem()).validate()
 	}
 }
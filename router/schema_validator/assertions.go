@@ -1,6 +1,10 @@
 package schema_validator
 
 import (
+	"encoding"
+	"encoding/json"
+	"net"
+	"net/netip"
 	"reflect"
 	"time"
 
@@ -14,12 +18,20 @@ var (
 	timeType         = utils.GetType[time.Time]()
 	uuidType         = utils.GetType[uuid.UUID]()
 	sliceOfBytesType = utils.GetType[[]byte]()
+	netipAddrType    = utils.GetType[netip.Addr]()
+	netIPType        = utils.GetType[net.IP]()
+
+	textMarshallerType  = utils.GetType[encoding.TextMarshaler]()
+	textUnmarshalerType = utils.GetType[encoding.TextUnmarshaler]()
+	jsonMarshalerType   = utils.GetType[json.Marshaler]()
 
 	isString               = kindIs(reflect.String)
 	isUUIDCompatible       = anyOf(isString, convertibleTo(uuidType))
 	isSliceOfBytes         = anyOf(isString, typeIs(sliceOfBytesType))
 	isTimeCompatible       = anyOf(isString, convertibleTo(timeType))
-	isSerializedFromString = anyOf(isString, isUUIDCompatible, isTimeCompatible, isSliceOfBytes)
+	isIPCompatible         = anyOf(isString, typeIs(netipAddrType, netIPType))
+	isTextCompatible       = handleMultiType(isTextSerialized)
+	isSerializedFromString = anyOf(isString, isUUIDCompatible, isTimeCompatible, isSliceOfBytes, isTextCompatible)
 
 	isTimeFormat         = schemaFormatIs(dateTimeFormat, timeFormat)
 	isSchemaStringFormat = schemaFormatIs(uuidFormat, byteFormat, dateTimeFormat, timeFormat, dateFormat, durationFormat,
@@ -27,7 +39,7 @@ var (
 		uriReferenceFormat, iriFormat, iriReferenceFormat, uriTemplateFormat, jsonPointerFormat,
 		relativeJsonPointerFormat, regexFormat, passwordFormat)
 
-	isSerializedFromObject = allOf(kindIs(reflect.Struct, reflect.Map), not(isTimeCompatible))
+	isSerializedFromObject = allOf(kindIs(reflect.Struct, reflect.Map), not(isTimeCompatible), not(isTextCompatible))
 
 	isSchemaTypeStringOrEmpty  = schemaTypeIsOrNil(openapi3.TypeString)
 	isSchemaTypeBooleanOrEmpty = schemaTypeIsOrNil(openapi3.TypeBoolean)
@@ -40,13 +52,24 @@ var (
 	isNumericType = kindIs(reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
 		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64)
 
-	isArrayGoType = allOf(kindIs(reflect.Array, reflect.Slice), not(isUUIDCompatible))
+	isArrayGoType = allOf(kindIs(reflect.Array, reflect.Slice), not(isUUIDCompatible), not(isTextCompatible))
 )
 
 func isAny(t reflect.Type) bool {
 	return t.Kind() == reflect.Interface && t.NumMethod() == 0
 }
 
+// isTextSerialized checks if a type is serialized to a JSON string using encoding.TextMarshaler and
+// encoding.TextUnmarshaler (e.g. netip.Addr, net.IP, civil.Date).
+// Types that implement json.Marshaler are excluded as their JSON representation can't be deduced from their type.
+func isTextSerialized(t reflect.Type) bool {
+	ptr := reflect.PointerTo(t)
+	if t.Implements(jsonMarshalerType) || ptr.Implements(jsonMarshalerType) {
+		return false
+	}
+	return (t.Implements(textMarshallerType) || ptr.Implements(textMarshallerType)) && ptr.Implements(textUnmarshalerType)
+}
+
 type assertion[T any] func(t T) bool
 type typeAssertion = assertion[reflect.Type]
 type schemaAssertion = assertion[openapi3.Schema]
@@ -7,6 +7,6 @@ func (c typeSchemaValidatorContext) validateBooleanSchema() {
 	isTypeBool := isBoolType(c.goType)
 	if (isTypeBool && !isSchemaTypeBooleanOrEmpty(c.schema)) ||
 		(c.schema.Type.Is(openapi3.TypeBoolean) && !isTypeBool) {
-		c.err(schemaTypeIsIncompatibleWithType(c.schema, c.goType))
+		c.err(RuleType, schemaTypeIsIncompatibleWithType(c.schema, c.goType))
 	}
 }
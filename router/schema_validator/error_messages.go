@@ -26,3 +26,39 @@ func schemaPropertyIsNotMappedToFieldInType(name string, fieldType reflect.Type)
 func schemaPropertyIsIncompatibleWithFieldType(property string, field string, fieldType reflect.Type) string {
 	return fmt.Sprintf("property %q is incompatible with type %s of field %q", property, fieldType, field)
 }
+
+func writeOnlyPropertyIsMappedToFieldInResponseType(property string, field string, goType reflect.Type) string {
+	return fmt.Sprintf("writeOnly property %q is exposed by field %q of response type %s", property, field, goType)
+}
+
+func fieldIsIncompatibleWithPatternProperty(field string, name string, fieldType reflect.Type, pattern string) string {
+	return fmt.Sprintf("field %q (%q) with type %s is incompatible with pattern property %q", field, name, fieldType, pattern)
+}
+
+func additionalPropertiesAreDroppedByType(goType reflect.Type) string {
+	return fmt.Sprintf("schema allows additional properties that are silently dropped when decoding type %s with no catch-all map field", goType)
+}
+
+func multipleCatchAllFieldsInType(goType reflect.Type) string {
+	return fmt.Sprintf("type %s has multiple catch-all map fields", goType)
+}
+
+func mapCanProducePropertiesForbiddenBySchema(description string) string {
+	return fmt.Sprintf("%s can produce properties that are forbidden by schema with no additional properties", description)
+}
+
+func mapCanProducePropertiesNotMatchingPatternProperties(description string) string {
+	return fmt.Sprintf("%s can produce properties that don't match schema pattern properties and are forbidden by schema with no additional properties", description)
+}
+
+func mapValueIsIncompatibleWithAdditionalProperties(description string, valueType reflect.Type) string {
+	return fmt.Sprintf("%s value type %s is incompatible with schema additional properties", description, valueType)
+}
+
+func mapValueIsIncompatibleWithPatternProperty(description string, valueType reflect.Type, pattern string) string {
+	return fmt.Sprintf("%s value type %s is incompatible with schema pattern property %q", description, valueType, pattern)
+}
+
+func invalidPatternProperties(err error) string {
+	return fmt.Sprintf("invalid schema pattern properties. %s", err)
+}
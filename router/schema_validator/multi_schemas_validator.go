@@ -2,12 +2,16 @@ package schema_validator
 
 import (
 	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
 
 	"github.com/piiano/cellotape/router/utils"
 )
 
+const componentSchemasPrefix = "#/components/schemas/"
+
 func (c typeSchemaValidatorContext) matchAllSchemaValidator(name string, schemas openapi3.SchemaRefs) {
 	if schemas == nil {
 		return
@@ -17,42 +21,144 @@ func (c typeSchemaValidatorContext) matchAllSchemaValidator(name string, schemas
 
 	if utils.IsMultiType(c.goType) {
 		if multiTypeTypes, err := utils.ExtractMultiTypeTypes(c.goType); err != nil {
-			c.err(err.Error())
+			c.err(name, err.Error())
 		} else {
 			types = multiTypeTypes
 		}
 	}
 
 	usedTypes := utils.NewSet[string]()
+	matchedTypes := make(map[int]reflect.Type, len(schemas))
 
 schemas:
 	for index, schema := range schemas {
-		schemaErrors := make([]string, 0)
+		schemaErrors := make([]SchemaError, 0)
 
 		for _, multiTypeType := range types {
-			typeValidator := typeSchemaValidatorContext{
-				errors: new([]string),
-				schema: *schema.Value,
-				goType: multiTypeType,
-			}
+			typeValidator := c.withSchemaRef(schema, name, strconv.Itoa(index))
+			typeValidator.errors = new([]SchemaError)
+			typeValidator.warnings = new([]SchemaError)
+			typeValidator.goType = multiTypeType
 
-			if err := typeValidator.Validate(); err == nil {
+			if err := typeValidator.validate(); err == nil {
+				*c.warnings = append(*c.warnings, *typeValidator.warnings...)
 				usedTypes.Add(multiTypeType.String())
+				matchedTypes[index] = multiTypeType
 				continue schemas
 			}
 
 			schemaErrors = append(schemaErrors, *typeValidator.errors...)
 		}
 
-		c.err("%s schema at index %d didn't match type of %q", name, index, c.goType)
+		c.err(name, "%s schema at index %d didn't match type of %q", name, index, c.goType)
 		*c.errors = append(*c.errors, schemaErrors...)
 	}
 
 	if utils.IsMultiType(c.goType) {
 		for _, multiTypeType := range types {
 			if !usedTypes.Has(multiTypeType.String()) {
-				c.err("non of %s schemas match type %q of %q", name, multiTypeType, c.goType)
+				c.err(name, "non of %s schemas match type %q of %q", name, multiTypeType, c.goType)
+			}
+		}
+		c.validateDiscriminator(name, schemas, matchedTypes)
+	}
+}
+
+// validateDiscriminator checks that every schema selected by the discriminator has a corresponding MultiType field
+// that declares the discriminator property, and that the MultiType fields have discriminator tags that agree with the
+// schema discriminator mapping.
+func (c typeSchemaValidatorContext) validateDiscriminator(name string, schemas openapi3.SchemaRefs, matchedTypes map[int]reflect.Type) {
+	if c.schema.Discriminator == nil {
+		return
+	}
+	propertyName := c.schema.Discriminator.PropertyName
+	mtDiscriminator, err := utils.ExtractMultiTypeDiscriminator(c.goType)
+	if err != nil {
+		c.err(RuleDiscriminator, err.Error())
+		return
+	}
+	if mtDiscriminator == nil {
+		// untagged MultiType values are decoded by trying every field, which may select a type the discriminator
+		// value doesn't map to
+		c.warn(RuleDiscriminator, "%q has no discriminator tags while the schema has a discriminator on property %q. tag its fields with `discriminator:\"%s=<value>\"`", c.goType, propertyName, propertyName)
+	} else if mtDiscriminator.PropertyName != propertyName {
+		c.err(RuleDiscriminator, "discriminator property %q of %q doesn't match the schema discriminator property %q", mtDiscriminator.PropertyName, c.goType, propertyName)
+		return
+	}
+
+	schemaValues := discriminatorValues(*c.schema.Discriminator, schemas)
+	schemaRefs := utils.NewSet(utils.Map(schemas, func(schema *openapi3.SchemaRef) string { return schema.Ref })...)
+	for value, ref := range schemaValues {
+		if !schemaRefs.Has(ref) {
+			c.err(RuleDiscriminator, "discriminator value %q maps to %s which is not one of the %s schemas", value, ref, name)
+		}
+	}
+
+	for index, schema := range schemas {
+		matchedType, matched := matchedTypes[index]
+		if !matched {
+			// already reported as a mismatch
+			continue
+		}
+		if !hasDiscriminatorProperty(matchedType, propertyName, c.catchAllFields) {
+			c.err(RuleDiscriminator, "type %q matching %s schema at index %d has no field for discriminator property %q", matchedType, name, index, propertyName)
+		}
+		if mtDiscriminator == nil {
+			continue
+		}
+		for value, ref := range schemaValues {
+			if ref != schema.Ref {
+				continue
 			}
+			if mappedType, ok := mtDiscriminator.Mapping[value]; !ok {
+				c.err(RuleDiscriminator, "discriminator value %q of %s schema at index %d is not mapped to a field of %q", value, name, index, c.goType)
+			} else if mappedType != matchedType {
+				c.err(RuleDiscriminator, "discriminator value %q is mapped to type %q but %s schema at index %d matches type %q", value, mappedType, name, index, matchedType)
+			}
+		}
+	}
+
+	if mtDiscriminator != nil {
+		for value := range mtDiscriminator.Mapping {
+			if _, found := schemaValues[value]; !found {
+				c.err(RuleDiscriminator, "discriminator value %q of %q is not declared by the schema discriminator", value, c.goType)
+			}
+		}
+	}
+}
+
+// discriminatorValues returns a map of every discriminator value to the schema ref it selects.
+// Schemas that are not explicitly mapped are implicitly selected by their component name.
+func discriminatorValues(discriminator openapi3.Discriminator, schemas openapi3.SchemaRefs) map[string]string {
+	values := make(map[string]string, len(schemas))
+	mappedRefs := utils.NewSet[string]()
+	for value, ref := range discriminator.Mapping {
+		if !strings.Contains(ref, "/") {
+			ref = componentSchemasPrefix + ref
+		}
+		values[value] = ref
+		mappedRefs.Add(ref)
+	}
+	for _, schema := range schemas {
+		if schema.Ref == "" || mappedRefs.Has(schema.Ref) {
+			continue
 		}
+		values[schema.Ref[strings.LastIndex(schema.Ref, "/")+1:]] = schema.Ref
+	}
+	return values
+}
+
+// hasDiscriminatorProperty checks if values of the type have the discriminator property when serialized to JSON.
+func hasDiscriminatorProperty(t reflect.Type, propertyName string, catchAllFields bool) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Map:
+		return true
+	case reflect.Struct:
+		_, found := structJsonFields(t, catchAllFields)[propertyName]
+		return found
 	}
+	return false
 }
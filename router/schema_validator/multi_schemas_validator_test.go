@@ -7,6 +7,7 @@ import (
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/piiano/cellotape/router/utils"
@@ -242,3 +243,169 @@ func TestCorruptedMultiType(t *testing.T) {
 	)).WithType(testType).Validate()
 	require.Error(t, err, "expect schema to be incompatible with invalid MultiType %s", testType)
 }
+
+type discriminatedCat struct {
+	PetType string `json:"petType"`
+	Meow    bool   `json:"meow"`
+}
+
+type discriminatedDog struct {
+	PetType string `json:"petType"`
+	Bark    bool   `json:"bark"`
+}
+
+type undiscriminatedCat struct {
+	Meow bool `json:"meow"`
+}
+
+type undiscriminatedDog struct {
+	Bark bool `json:"bark"`
+}
+
+func TestDiscriminatorMultiSchemaValidator(t *testing.T) {
+	petSchema := func(property string, withPetType bool) *openapi3.Schema {
+		schema := openapi3.NewObjectSchema().WithProperty(property, openapi3.NewBoolSchema())
+		if withPetType {
+			schema.WithProperty("petType", openapi3.NewStringSchema())
+		}
+		return schema
+	}
+	discriminatedSchema := func(discriminator *openapi3.Discriminator, withPetType bool) openapi3.Schema {
+		schema := openapi3.NewOneOfSchema()
+		schema.OneOf = openapi3.SchemaRefs{
+			{Ref: "#/components/schemas/Cat", Value: petSchema("meow", withPetType)},
+			{Ref: "#/components/schemas/Dog", Value: petSchema("bark", withPetType)},
+		}
+		schema.Discriminator = discriminator
+		return *schema
+	}
+
+	testCases := []struct {
+		name         string
+		goType       reflect.Type
+		schema       openapi3.Schema
+		errAssertion func(require.TestingT, error, ...any)
+	}{
+		{
+			name: "untagged multi type",
+			goType: reflect.TypeOf(&utils.MultiType[struct {
+				Cat *discriminatedCat
+				Dog *discriminatedDog
+			}]{}),
+			schema:       discriminatedSchema(&openapi3.Discriminator{PropertyName: "petType"}, true),
+			errAssertion: require.NoError,
+		},
+		{
+			name: "implicit mapping with tagged multi type",
+			goType: reflect.TypeOf(&utils.MultiType[struct {
+				Cat *discriminatedCat `discriminator:"petType=Cat"`
+				Dog *discriminatedDog `discriminator:"petType=Dog"`
+			}]{}),
+			schema:       discriminatedSchema(&openapi3.Discriminator{PropertyName: "petType"}, true),
+			errAssertion: require.NoError,
+		},
+		{
+			name: "explicit mapping with tagged multi type",
+			goType: reflect.TypeOf(&utils.MultiType[struct {
+				Cat *discriminatedCat `discriminator:"petType=cat,kitten"`
+				Dog *discriminatedDog `discriminator:"petType=dog"`
+			}]{}),
+			schema: discriminatedSchema(&openapi3.Discriminator{PropertyName: "petType", Mapping: map[string]string{
+				"cat":    "#/components/schemas/Cat",
+				"kitten": "Cat",
+				"dog":    "#/components/schemas/Dog",
+			}}, true),
+			errAssertion: require.NoError,
+		},
+		{
+			name: "tag value not declared in the schema mapping",
+			goType: reflect.TypeOf(&utils.MultiType[struct {
+				Cat *discriminatedCat `discriminator:"petType=cat,kitten"`
+				Dog *discriminatedDog `discriminator:"petType=dog"`
+			}]{}),
+			schema: discriminatedSchema(&openapi3.Discriminator{PropertyName: "petType", Mapping: map[string]string{
+				"cat": "#/components/schemas/Cat",
+				"dog": "#/components/schemas/Dog",
+			}}, true),
+			errAssertion: require.Error,
+		},
+		{
+			name: "schema mapping value mapped to the wrong field",
+			goType: reflect.TypeOf(&utils.MultiType[struct {
+				Cat *discriminatedCat `discriminator:"petType=dog"`
+				Dog *discriminatedDog `discriminator:"petType=cat"`
+			}]{}),
+			schema: discriminatedSchema(&openapi3.Discriminator{PropertyName: "petType", Mapping: map[string]string{
+				"cat": "#/components/schemas/Cat",
+				"dog": "#/components/schemas/Dog",
+			}}, true),
+			errAssertion: require.Error,
+		},
+		{
+			name: "tags with different property name",
+			goType: reflect.TypeOf(&utils.MultiType[struct {
+				Cat *discriminatedCat `discriminator:"kind=Cat"`
+				Dog *discriminatedDog `discriminator:"kind=Dog"`
+			}]{}),
+			schema:       discriminatedSchema(&openapi3.Discriminator{PropertyName: "petType"}, true),
+			errAssertion: require.Error,
+		},
+		{
+			name: "schema mapping to a schema that is not part of oneOf",
+			goType: reflect.TypeOf(&utils.MultiType[struct {
+				Cat *discriminatedCat `discriminator:"petType=Cat"`
+				Dog *discriminatedDog `discriminator:"petType=Dog"`
+			}]{}),
+			schema: discriminatedSchema(&openapi3.Discriminator{PropertyName: "petType", Mapping: map[string]string{
+				"bird": "#/components/schemas/Bird",
+			}}, true),
+			errAssertion: require.Error,
+		},
+		{
+			name: "multi type fields without the discriminator property",
+			goType: reflect.TypeOf(&utils.MultiType[struct {
+				Cat *undiscriminatedCat `discriminator:"petType=Cat"`
+				Dog *undiscriminatedDog `discriminator:"petType=Dog"`
+			}]{}),
+			schema:       discriminatedSchema(&openapi3.Discriminator{PropertyName: "petType"}, false),
+			errAssertion: require.Error,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := typeSchemaValidator(testCase.goType, testCase.schema).Validate()
+			testCase.errAssertion(t, err)
+		})
+	}
+}
+
+func TestDiscriminatorMultiSchemaValidatorWarnsOnUntaggedMultiType(t *testing.T) {
+	schema := openapi3.NewOneOfSchema()
+	schema.OneOf = openapi3.SchemaRefs{
+		{Ref: "#/components/schemas/Cat", Value: openapi3.NewObjectSchema().
+			WithProperty("petType", openapi3.NewStringSchema()).
+			WithProperty("meow", openapi3.NewBoolSchema())},
+		{Ref: "#/components/schemas/Dog", Value: openapi3.NewObjectSchema().
+			WithProperty("petType", openapi3.NewStringSchema()).
+			WithProperty("bark", openapi3.NewBoolSchema())},
+	}
+	schema.Discriminator = &openapi3.Discriminator{PropertyName: "petType"}
+
+	validator := typeSchemaValidator(reflect.TypeOf(&utils.MultiType[struct {
+		Cat *discriminatedCat
+		Dog *discriminatedDog
+	}]{}), *schema)
+	require.NoError(t, validator.Validate())
+	assert.Empty(t, validator.SchemaErrors())
+	require.Len(t, validator.Warnings(), 1)
+	assert.Equal(t, RuleDiscriminator, validator.Warnings()[0].Rule)
+	assert.Contains(t, validator.Warnings()[0].Message, "has no discriminator tags")
+
+	tagged := typeSchemaValidator(reflect.TypeOf(&utils.MultiType[struct {
+		Cat *discriminatedCat `discriminator:"petType=Cat"`
+		Dog *discriminatedDog `discriminator:"petType=Dog"`
+	}]{}), *schema)
+	require.NoError(t, tagged.Validate())
+	assert.Empty(t, tagged.Warnings())
+}
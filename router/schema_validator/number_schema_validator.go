@@ -13,14 +13,14 @@ func (c typeSchemaValidatorContext) validateNumberSchema() {
 		}
 
 		// schema type is numeric and go type is not numeric
-		c.err(schemaTypeIsIncompatibleWithType(c.schema, c.goType))
+		c.err(RuleType, schemaTypeIsIncompatibleWithType(c.schema, c.goType))
 		return
 	}
 
 	// schema type is numeric and go type is not numeric
 	if (c.schema.Format == floatFormat && !isFloat32(c.goType)) ||
 		(c.schema.Format == doubleFormat && !isFloat64(c.goType)) {
-		c.err(schemaTypeWithFormatIsIncompatibleWithType(c.schema, c.goType))
+		c.err(RuleFormat, schemaTypeWithFormatIsIncompatibleWithType(c.schema, c.goType))
 		return
 	}
 
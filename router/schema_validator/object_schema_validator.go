@@ -1,10 +1,11 @@
 package schema_validator
 
 import (
-	"encoding"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
@@ -12,21 +13,19 @@ import (
 	"github.com/piiano/cellotape/router/utils"
 )
 
-var textMarshallerType = reflect.TypeOf(new(encoding.TextMarshaler)).Elem()
-
 func (c typeSchemaValidatorContext) validateObjectSchema() {
-	// TODO: validate required properties, nullable, additionalProperties, etc.
+	// TODO: validate required properties, nullable, etc.
 	serializedFromObject := isSerializedFromObject(c.goType)
 
 	if !serializedFromObject {
 		if c.schema.Type.Is(openapi3.TypeObject) {
-			c.err(schemaTypeIsIncompatibleWithType(c.schema, c.goType))
+			c.err(RuleType, schemaTypeIsIncompatibleWithType(c.schema, c.goType))
 		}
 		return
 	}
 
 	if !isSchemaTypeObjectOrEmpty(c.schema) {
-		c.err(schemaTypeIsIncompatibleWithType(c.schema, c.goType))
+		c.err(RuleType, schemaTypeIsIncompatibleWithType(c.schema, c.goType))
 	}
 
 	handleMultiType(func(t reflect.Type) bool {
@@ -43,36 +42,105 @@ func (c typeSchemaValidatorContext) validateObjectSchema() {
 }
 
 func (c typeSchemaValidatorContext) assertStruct(t reflect.Type) bool {
+	errorsBefore := len(*c.errors)
 	properties := c.schema.Properties
 	if properties == nil {
 		properties = make(map[string]*openapi3.SchemaRef, 0)
 	}
 	// TODO: add support for receiving in options how to extract type keys (to support schema for non-json serializers)
-	fields := structJsonFields(t)
+	fields := structJsonFields(t, c.catchAllFields)
+	patterns := c.patternProperties()
 
 	validatedFields := utils.NewSet[string]()
 	for name, field := range fields {
 
 		validatedFields.Add(name)
+		fieldContext := c.withField(field)
 		if property, ok := properties[name]; !ok {
-			if additionalProperties := additionalPropertiesSchema(c.schema); additionalProperties == nil {
-				c.err(fmt.Sprintf("field %q (%q) with type %s not found in object schema properties", field.Name, name, field.Type))
-			} else if err := c.WithSchema(*additionalProperties).WithType(field.Type).Validate(); err != nil {
-				c.err(fmt.Sprintf("field %q (%q) with type %s not found in object schema properties nor additonal properties", field.Name, name, field.Type))
+			if matchingPatterns := matchPatternProperties(patterns, name); len(matchingPatterns) > 0 {
+				for _, pattern := range matchingPatterns {
+					patternContext := fieldContext.withNestedSchema(*pattern.schema, RulePatternProperties, pattern.expression)
+					if err := patternContext.validate(); err != nil {
+						patternContext.err(RulePatternProperties, fieldIsIncompatibleWithPatternProperty(field.Name, name, field.Type, pattern.expression))
+					}
+				}
+			} else if additionalProperties := additionalPropertiesSchema(c.schema); additionalProperties == nil {
+				fieldContext.err(RuleAdditionalProperties, "field %q (%q) with type %s not found in object schema properties", field.Name, name, field.Type)
+			} else if additionalPropertiesContext := fieldContext.withAdditionalProperties(*additionalProperties); additionalPropertiesContext.validate() != nil {
+				additionalPropertiesContext.err(RuleAdditionalProperties, "field %q (%q) with type %s not found in object schema properties nor additonal properties", field.Name, name, field.Type)
 			}
-		} else if err := c.WithType(field.Type).WithSchema(*property.Value).Validate(); err != nil {
-			c.err(schemaPropertyIsIncompatibleWithFieldType(name, field.Name, field.Type))
+		} else if propertyContext := fieldContext.withSchemaRef(property, RuleProperties, name); propertyContext.validate() != nil {
+			propertyContext.err(RuleProperties, schemaPropertyIsIncompatibleWithFieldType(name, field.Name, field.Type))
+		} else if c.direction == ResponseDirection && c.forbidWriteOnlyFields && property.Value.WriteOnly {
+			propertyContext.err(RuleWriteOnly, writeOnlyPropertyIsMappedToFieldInResponseType(name, field.Name, t))
 		}
 	}
-	for name := range properties {
-		if !validatedFields.Has(name) {
-			c.err(schemaPropertyIsNotMappedToFieldInType(name, t))
+	for name, property := range properties {
+		if validatedFields.Has(name) || c.isPropertyOmittedInDirection(*property.Value) {
+			continue
 		}
+		c.withSchemaRef(property, RuleProperties, name).err(RuleProperties, schemaPropertyIsNotMappedToFieldInType(name, t))
 	}
+	c.assertCatchAllFields(t, patterns)
+
+	return len(*c.errors) == errorsBefore
+}
 
-	return len(*c.errors) == 0
+// assertCatchAllFields checks that the properties collected by catch-all map fields of the struct are allowed by the
+// schema, and, when catch-all fields are supported, that arbitrary properties allowed explicitly by the schema are not
+// silently dropped when decoding a request to a struct without a catch-all field.
+// Without catch-all fields support (e.g. encoding/json) a struct can't collect additional properties, so they are
+// dropped by design.
+func (c typeSchemaValidatorContext) assertCatchAllFields(t reflect.Type, patterns []patternProperty) {
+	catchAllFields := c.structCatchAllFields(t)
+	if len(catchAllFields) == 0 {
+		if c.catchAllFields && c.direction == RequestDirection && allowsExplicitAdditionalProperties(c.schema, patterns) {
+			c.err(RuleAdditionalProperties, additionalPropertiesAreDroppedByType(t))
+		}
+		return
+	}
+	if len(catchAllFields) > 1 {
+		c.err(RuleAdditionalProperties, multipleCatchAllFieldsInType(t))
+	}
+	for _, field := range catchAllFields {
+		mapType := field.Type
+		if mapType.Kind() == reflect.Pointer {
+			mapType = mapType.Elem()
+		}
+		c.withField(field).assertAdditionalPropertiesMap(mapType, patterns, fmt.Sprintf("catch-all field %q of type %s", field.Name, t))
+	}
 }
+
+// assertAdditionalPropertiesMap checks that a map type that can hold arbitrary keys is allowed by the schema
+// additionalProperties and patternProperties.
+func (c typeSchemaValidatorContext) assertAdditionalPropertiesMap(mapType reflect.Type, patterns []patternProperty, description string) {
+	additionalProperties := additionalPropertiesSchema(c.schema)
+	if additionalProperties == nil && len(patterns) == 0 {
+		c.err(RuleAdditionalProperties, mapCanProducePropertiesForbiddenBySchema(description))
+		return
+	}
+	valueContext := c.withElem(mapType.Elem())
+	if additionalProperties == nil {
+		c.err(RulePatternProperties, mapCanProducePropertiesNotMatchingPatternProperties(description))
+	} else if additionalPropertiesContext := valueContext.withAdditionalProperties(*additionalProperties); additionalPropertiesContext.validate() != nil {
+		additionalPropertiesContext.err(RuleAdditionalProperties, mapValueIsIncompatibleWithAdditionalProperties(description, mapType.Elem()))
+	}
+	for _, pattern := range patterns {
+		patternContext := valueContext.withNestedSchema(*pattern.schema, RulePatternProperties, pattern.expression)
+		if err := patternContext.validate(); err != nil {
+			patternContext.err(RulePatternProperties, mapValueIsIncompatibleWithPatternProperty(description, mapType.Elem(), pattern.expression))
+		}
+	}
+}
+
+// isPropertyOmittedInDirection checks if a property doesn't have to be mapped to a field in the validated direction.
+// readOnly properties are not sent in requests and writeOnly properties are not sent in responses.
+func (c typeSchemaValidatorContext) isPropertyOmittedInDirection(property openapi3.Schema) bool {
+	return (c.direction == RequestDirection && property.ReadOnly) || (c.direction == ResponseDirection && property.WriteOnly)
+}
+
 func (c typeSchemaValidatorContext) assertMap(t reflect.Type) bool {
+	errorsBefore := len(*c.errors)
 	keyType := t.Key()
 	mapValueType := t.Elem()
 
@@ -85,29 +153,32 @@ func (c typeSchemaValidatorContext) assertMap(t reflect.Type) bool {
 		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
 	default:
 		if !keyType.Implements(textMarshallerType) {
-			c.err("object schema with map type must have a string compatible type. %s key is not string compatible", keyType)
+			c.err(RuleType, "object schema with map type must have a string compatible type. %s key is not string compatible", keyType)
 		}
 	}
+	c.assertAdditionalPropertiesMap(t, c.patternProperties(), fmt.Sprintf("map type %s", t))
 	if c.schema.Properties != nil {
 
 		for name, property := range c.schema.Properties {
+			propertyContext := c.withElem(mapValueType).withSchemaRef(property, RuleProperties, name)
 			// check if property name is compatible with the map key type
 			keyName, _ := json.Marshal(name)
 			if err := json.Unmarshal(keyName, reflect.New(keyType).Interface()); err != nil {
-				c.err("schema property name %q is incompatible with map key type %s", name, keyType)
+				propertyContext.err(RuleProperties, "schema property name %q is incompatible with map key type %s", name, keyType)
 			}
 			// check if property schema is compatible with the map value type
-			if err := c.WithType(mapValueType).WithSchema(*property.Value).Validate(); err != nil {
-				c.err("schema property %q is incompatible with map value type %s", name, mapValueType)
+			if err := propertyContext.validate(); err != nil {
+				propertyContext.err(RuleProperties, "schema property %q is incompatible with map value type %s", name, mapValueType)
 			}
 		}
 	}
 
-	return len(*c.errors) == 0
+	return len(*c.errors) == errorsBefore
 }
 
-// structJsonFields Extract the struct fields that are serializable as JSON corresponding to their JSON key
-func structJsonFields(structType reflect.Type) map[string]reflect.StructField {
+// structJsonFields Extract the struct fields that are serializable as JSON corresponding to their JSON key.
+// Catch-all fields are excluded when catchAllFields is true, otherwise they are included as ordinary fields.
+func structJsonFields(structType reflect.Type, catchAllFields bool) map[string]reflect.StructField {
 	// this method cares only about the json keys of the current struct in following json/encoding rules.
 	// for simplification, we marshal an instance of the struct to json and unmarshal it to back to a map to get all json keys.
 	//
@@ -115,13 +186,15 @@ func structJsonFields(structType reflect.Type) map[string]reflect.StructField {
 	// this guarantee that we don't miss fields annotated with omitempty tag.
 	// the original field types can be later extracted from the original structType
 
-	// get all visible fields, embedded structs are excluded but their fields are included
+	// get all visible fields, embedded structs are excluded but their fields are included.
+	// catch-all fields are excluded as their keys are the properties that are not mapped to other fields.
 	visibleFields := utils.Filter(reflect.VisibleFields(structType), func(field reflect.StructField) bool {
-		return !field.Anonymous
+		return !isEmbeddedStruct(field) && !(catchAllFields && isCatchAllField(field))
 	})
-	// change field type to bool type
+	// change field type to bool type. embedded fields of other types are serialized as fields named after their type.
 	boolFields := utils.Map(visibleFields, func(field reflect.StructField) reflect.StructField {
 		field.Type = reflect.TypeOf(true)
+		field.Anonymous = false
 		return field
 	})
 	//instantiate the struct type and set field values to true to prevent them from being omitted by omitempty tag
@@ -146,13 +219,27 @@ func structJsonFields(structType reflect.Type) map[string]reflect.StructField {
 			if !found {
 				return name == key
 			}
-			name, _, _ = strings.Cut(tag, ",")
-			return name == key
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "" {
+				return name == key
+			}
+			return tagName == key
 		})
 	}
 	return fields
 }
 
+// withAdditionalProperties returns a context for validating the additionalProperties schema of the current schema.
+func (c typeSchemaValidatorContext) withAdditionalProperties(additionalProperties openapi3.Schema) typeSchemaValidatorContext {
+	if schemaRef := c.schema.AdditionalProperties.Schema; schemaRef != nil {
+		c.schemaPath = nestedSchemaPath(c.schemaPath, schemaRef.Ref, RuleAdditionalProperties)
+	} else {
+		c.schemaPath = JoinJSONPointer(c.schemaPath, RuleAdditionalProperties)
+	}
+	c.schema = additionalProperties
+	return c
+}
+
 func additionalPropertiesSchema(schema openapi3.Schema) *openapi3.Schema {
 	// if additional properties schema is defined explicitly return it
 	if schema.AdditionalProperties.Schema != nil {
@@ -167,3 +254,103 @@ func additionalPropertiesSchema(schema openapi3.Schema) *openapi3.Schema {
 	// return nil if additional properties are not allowed
 	return nil
 }
+
+// catchAllTagOptions are json tag options used by JSON libraries (e.g. encoding/json/v2) to mark a map field that
+// collects all object properties that are not mapped to other fields.
+var catchAllTagOptions = utils.NewSet("inline", "unknown")
+
+// isCatchAllField checks if a struct field is a map that collects all properties that are not mapped to other fields.
+// Catch-all fields are either embedded map types or map fields tagged with an "inline" or "unknown" json tag option.
+func isCatchAllField(field reflect.StructField) bool {
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Pointer {
+		fieldType = fieldType.Elem()
+	}
+	if !field.IsExported() || fieldType.Kind() != reflect.Map {
+		return false
+	}
+	if field.Anonymous {
+		return true
+	}
+	_, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+	for _, option := range strings.Split(options, ",") {
+		if catchAllTagOptions.Has(option) {
+			return true
+		}
+	}
+	return false
+}
+
+// structCatchAllFields returns all catch-all fields of the struct.
+// Returns nil if catch-all fields are not enabled for the validator.
+func (c typeSchemaValidatorContext) structCatchAllFields(structType reflect.Type) []reflect.StructField {
+	if !c.catchAllFields {
+		return nil
+	}
+	return utils.Filter(reflect.VisibleFields(structType), isCatchAllField)
+}
+
+// isEmbeddedStruct checks if a struct field is an embedded struct whose fields are promoted to the struct.
+func isEmbeddedStruct(field reflect.StructField) bool {
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Pointer {
+		fieldType = fieldType.Elem()
+	}
+	return field.Anonymous && fieldType.Kind() == reflect.Struct
+}
+
+// allowsExplicitAdditionalProperties checks if the schema explicitly describes properties that are not declared in its
+// properties with an additionalProperties schema or patternProperties.
+// additionalProperties that are allowed by default or with `true` are not considered explicit as they are commonly used
+// to keep the schema open for extension rather than to describe data the type is expected to hold.
+func allowsExplicitAdditionalProperties(schema openapi3.Schema, patterns []patternProperty) bool {
+	return len(patterns) > 0 || schema.AdditionalProperties.Schema != nil
+}
+
+// patternPropertiesKeyword is the JSON schema keyword for schemas of properties matching a regular expression.
+// It is not part of OpenAPI 3.0 so kin-openapi keeps it with the schema extensions.
+const patternPropertiesKeyword = "patternProperties"
+
+// patternProperty is a parsed entry of a schema patternProperties keyword.
+type patternProperty struct {
+	expression string
+	pattern    *regexp.Regexp
+	schema     *openapi3.Schema
+}
+
+// patternProperties parses the patternProperties of the schema sorted by their expression.
+// Only inline schemas are supported as references in extensions are not resolved by kin-openapi.
+func (c typeSchemaValidatorContext) patternProperties() []patternProperty {
+	extension, ok := c.schema.Extensions[patternPropertiesKeyword]
+	if !ok {
+		return nil
+	}
+	extensionJSON, err := json.Marshal(extension)
+	if err != nil {
+		c.err(RulePatternProperties, invalidPatternProperties(err))
+		return nil
+	}
+	schemas := make(map[string]*openapi3.Schema)
+	if err = json.Unmarshal(extensionJSON, &schemas); err != nil {
+		c.err(RulePatternProperties, invalidPatternProperties(err))
+		return nil
+	}
+	patterns := make([]patternProperty, 0, len(schemas))
+	for _, expression := range utils.Keys(schemas) {
+		pattern, err := regexp.Compile(expression)
+		if err != nil {
+			c.err(RulePatternProperties, invalidPatternProperties(err))
+			continue
+		}
+		patterns = append(patterns, patternProperty{expression: expression, pattern: pattern, schema: schemas[expression]})
+	}
+	sort.Slice(patterns, func(i, j int) bool { return patterns[i].expression < patterns[j].expression })
+	return patterns
+}
+
+// matchPatternProperties returns the pattern properties matching the property name.
+func matchPatternProperties(patterns []patternProperty, name string) []patternProperty {
+	return utils.Filter(patterns, func(pattern patternProperty) bool {
+		return pattern.pattern.MatchString(name)
+	})
+}
@@ -144,3 +144,279 @@ func TestObjectSchemaValidatorWithEmbeddedStruct(t *testing.T) {
 	expectTypeToBeIncompatible(t, validatorB.WithSchema(structCSchema), structBType, errTemplate, "structCSchema", "incompatible", structBType)
 	expectTypeToBeIncompatible(t, validatorC.WithSchema(structBSchema), structCType, errTemplate, "structBSchema", "incompatible", structCType)
 }
+
+func TestObjectSchemaValidatorWithReadOnlyAndWriteOnlyProperties(t *testing.T) {
+	readOnlySchema := openapi3.NewStringSchema()
+	readOnlySchema.ReadOnly = true
+	writeOnlySchema := openapi3.NewStringSchema()
+	writeOnlySchema.WriteOnly = true
+	schema := openapi3.NewObjectSchema().
+		WithProperty("id", readOnlySchema).
+		WithProperty("name", openapi3.NewStringSchema()).
+		WithProperty("password", writeOnlySchema)
+
+	type requestType struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}
+	type responseType struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	type fullType struct {
+		ID       string `json:"id"`
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}
+
+	testCases := []struct {
+		name                  string
+		goType                reflect.Type
+		direction             Direction
+		forbidWriteOnlyFields bool
+		errAssertion          func(require.TestingT, error, ...any)
+	}{
+		{name: "request type without readOnly property", goType: reflect.TypeOf(requestType{}), direction: RequestDirection, errAssertion: require.NoError},
+		{name: "request type without writeOnly property", goType: reflect.TypeOf(responseType{}), direction: RequestDirection, errAssertion: require.Error},
+		{name: "response type without writeOnly property", goType: reflect.TypeOf(responseType{}), direction: ResponseDirection, errAssertion: require.NoError},
+		{name: "response type without readOnly property", goType: reflect.TypeOf(requestType{}), direction: ResponseDirection, errAssertion: require.Error},
+		{name: "bidirectional type without readOnly property", goType: reflect.TypeOf(requestType{}), direction: Bidirectional, errAssertion: require.Error},
+		{name: "bidirectional type without writeOnly property", goType: reflect.TypeOf(responseType{}), direction: Bidirectional, errAssertion: require.Error},
+		{name: "bidirectional type with all properties", goType: reflect.TypeOf(fullType{}), direction: Bidirectional, errAssertion: require.NoError},
+		{name: "response type exposing writeOnly property", goType: reflect.TypeOf(fullType{}), direction: ResponseDirection, errAssertion: require.NoError},
+		{name: "response type exposing forbidden writeOnly property", goType: reflect.TypeOf(fullType{}), direction: ResponseDirection, forbidWriteOnlyFields: true, errAssertion: require.Error},
+		{name: "request type with forbidden writeOnly property", goType: reflect.TypeOf(fullType{}), direction: RequestDirection, forbidWriteOnlyFields: true, errAssertion: require.NoError},
+		{name: "nested response type without writeOnly property", goType: reflect.TypeOf([]responseType{}), direction: ResponseDirection, errAssertion: require.NoError},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testSchema := *schema
+			if testCase.goType.Kind() == reflect.Slice {
+				testSchema = *openapi3.NewArraySchema().WithItems(schema)
+			}
+			err := typeSchemaValidator(testCase.goType, testSchema).
+				WithDirection(testCase.direction).
+				WithForbiddenWriteOnlyFields(testCase.forbidWriteOnlyFields).
+				Validate()
+			testCase.errAssertion(t, err)
+		})
+	}
+}
+
+func TestObjectSchemaValidatorWithCatchAllFields(t *testing.T) {
+	type Inline struct {
+		Name  string         `json:"name"`
+		Extra map[string]any `json:",inline"`
+	}
+	type Unknown struct {
+		Name  string             `json:"name"`
+		Extra *map[string]string `json:",unknown"`
+	}
+	type Extra map[string]string
+	type Embedded struct {
+		Extra
+		Name string `json:"name"`
+	}
+	type MultipleCatchAll struct {
+		Name   string         `json:"name"`
+		Extra1 map[string]any `json:",inline"`
+		Extra2 map[string]any `json:",unknown"`
+	}
+	type NoCatchAll struct {
+		Name string `json:"name"`
+	}
+
+	schema := func(additionalProperties openapi3.AdditionalProperties) openapi3.Schema {
+		schema := openapi3.NewObjectSchema().WithProperty("name", openapi3.NewStringSchema())
+		schema.AdditionalProperties = additionalProperties
+		return *schema
+	}
+	noAdditionalProperties := schema(openapi3.AdditionalProperties{Has: utils.Ptr(false)})
+	anyAdditionalProperties := schema(openapi3.AdditionalProperties{Has: utils.Ptr(true)})
+	stringAdditionalProperties := schema(openapi3.AdditionalProperties{Schema: openapi3.NewStringSchema().NewRef()})
+	defaultAdditionalProperties := schema(openapi3.AdditionalProperties{})
+
+	testCases := []struct {
+		name         string
+		goType       reflect.Type
+		schema       openapi3.Schema
+		direction    Direction
+		errAssertion func(require.TestingT, error, ...any)
+	}{
+		{name: "inline catch-all with no additional properties", goType: utils.GetType[Inline](), schema: noAdditionalProperties, errAssertion: require.Error},
+		{name: "unknown catch-all with no additional properties", goType: utils.GetType[Unknown](), schema: noAdditionalProperties, errAssertion: require.Error},
+		{name: "embedded catch-all with no additional properties", goType: utils.GetType[Embedded](), schema: noAdditionalProperties, errAssertion: require.Error},
+		{name: "inline catch-all with any additional properties", goType: utils.GetType[Inline](), schema: anyAdditionalProperties, errAssertion: require.NoError},
+		{name: "inline catch-all with default additional properties", goType: utils.GetType[Inline](), schema: defaultAdditionalProperties, errAssertion: require.NoError},
+		{name: "string catch-all with string additional properties", goType: utils.GetType[Unknown](), schema: stringAdditionalProperties, errAssertion: require.NoError},
+		{name: "embedded string catch-all with string additional properties", goType: utils.GetType[Embedded](), schema: stringAdditionalProperties, errAssertion: require.NoError},
+		{name: "multiple catch-all fields", goType: utils.GetType[MultipleCatchAll](), schema: anyAdditionalProperties, errAssertion: require.Error},
+		{name: "request without catch-all with any additional properties", goType: utils.GetType[NoCatchAll](), schema: anyAdditionalProperties, direction: RequestDirection, errAssertion: require.NoError},
+		{name: "request without catch-all with string additional properties", goType: utils.GetType[NoCatchAll](), schema: stringAdditionalProperties, direction: RequestDirection, errAssertion: require.Error},
+		{name: "request without catch-all with default additional properties", goType: utils.GetType[NoCatchAll](), schema: defaultAdditionalProperties, direction: RequestDirection, errAssertion: require.NoError},
+		{name: "request with catch-all with any additional properties", goType: utils.GetType[Inline](), schema: anyAdditionalProperties, direction: RequestDirection, errAssertion: require.NoError},
+		{name: "response without catch-all with any additional properties", goType: utils.GetType[NoCatchAll](), schema: anyAdditionalProperties, direction: ResponseDirection, errAssertion: require.NoError},
+		{name: "map with no additional properties", goType: utils.GetType[map[string]string](), schema: noAdditionalProperties, errAssertion: require.Error},
+		{name: "map with string additional properties", goType: utils.GetType[map[string]string](), schema: stringAdditionalProperties, errAssertion: require.NoError},
+		{name: "any map with string additional properties", goType: utils.GetType[map[string]any](), schema: stringAdditionalProperties, errAssertion: require.NoError},
+		{name: "int map with string additional properties", goType: utils.GetType[map[string]int](), schema: stringAdditionalProperties, errAssertion: require.Error},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := typeSchemaValidator(testCase.goType, testCase.schema).WithDirection(testCase.direction).WithCatchAllFields(true).Validate()
+			testCase.errAssertion(t, err)
+		})
+	}
+}
+
+// Without catch-all fields (e.g. encoding/json), maps tagged with "inline" or "unknown" and embedded maps are serialized
+// as ordinary fields named after the field.
+func TestObjectSchemaValidatorWithoutCatchAllFields(t *testing.T) {
+	type Inline struct {
+		Name  string            `json:"name"`
+		Extra map[string]string `json:",inline"`
+	}
+	type Extra map[string]string
+	type Embedded struct {
+		Extra
+		Name string `json:"name"`
+	}
+	type NoCatchAll struct {
+		Name string `json:"name"`
+	}
+
+	schema := func(additionalProperties openapi3.AdditionalProperties) openapi3.Schema {
+		schema := openapi3.NewObjectSchema().WithProperty("name", openapi3.NewStringSchema())
+		schema.AdditionalProperties = additionalProperties
+		return *schema
+	}
+	withExtraProperty := openapi3.NewObjectSchema().
+		WithProperty("name", openapi3.NewStringSchema()).
+		WithProperty("Extra", openapi3.NewObjectSchema().WithAdditionalProperties(openapi3.NewStringSchema()))
+
+	testCases := []struct {
+		name         string
+		goType       reflect.Type
+		schema       openapi3.Schema
+		direction    Direction
+		errAssertion func(require.TestingT, error, ...any)
+	}{
+		{name: "inline field with string additional properties", goType: utils.GetType[Inline](), schema: schema(openapi3.AdditionalProperties{Schema: openapi3.NewStringSchema().NewRef()}), errAssertion: require.Error},
+		{name: "embedded map with string additional properties", goType: utils.GetType[Embedded](), schema: schema(openapi3.AdditionalProperties{Schema: openapi3.NewStringSchema().NewRef()}), errAssertion: require.Error},
+		{name: "request with inline field with object additional properties", goType: utils.GetType[Inline](), schema: schema(openapi3.AdditionalProperties{Schema: openapi3.NewObjectSchema().NewRef()}), direction: RequestDirection, errAssertion: require.NoError},
+		{name: "inline field with no additional properties", goType: utils.GetType[Inline](), schema: schema(openapi3.AdditionalProperties{Has: utils.Ptr(false)}), errAssertion: require.Error},
+		{name: "inline field mapped to property", goType: utils.GetType[Inline](), schema: *withExtraProperty, errAssertion: require.NoError},
+		{name: "request with string additional properties", goType: utils.GetType[NoCatchAll](), schema: schema(openapi3.AdditionalProperties{Schema: openapi3.NewStringSchema().NewRef()}), direction: RequestDirection, errAssertion: require.NoError},
+		{name: "embedded map mapped to property", goType: utils.GetType[Embedded](), schema: *withExtraProperty, errAssertion: require.NoError},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := typeSchemaValidator(testCase.goType, testCase.schema).WithDirection(testCase.direction).Validate()
+			testCase.errAssertion(t, err)
+		})
+	}
+}
+
+func TestObjectSchemaValidatorWithPatternProperties(t *testing.T) {
+	type WithExtensionFields struct {
+		Name      string `json:"name"`
+		Extension string `json:"x-extension"`
+	}
+	type WithIncompatibleExtensionField struct {
+		Name      string `json:"name"`
+		Extension int    `json:"x-extension"`
+	}
+	type WithCatchAll struct {
+		Name       string            `json:"name"`
+		Extensions map[string]string `json:",inline"`
+	}
+
+	schema := func(additionalProperties openapi3.AdditionalProperties) openapi3.Schema {
+		schema := openapi3.NewObjectSchema().WithProperty("name", openapi3.NewStringSchema())
+		schema.AdditionalProperties = additionalProperties
+		schema.Extensions = map[string]any{
+			"patternProperties": map[string]any{
+				"^x-": map[string]any{"type": "string"},
+			},
+		}
+		return *schema
+	}
+	noAdditionalProperties := schema(openapi3.AdditionalProperties{Has: utils.Ptr(false)})
+	defaultAdditionalProperties := schema(openapi3.AdditionalProperties{})
+	invalidPattern := schema(openapi3.AdditionalProperties{})
+	invalidPattern.Extensions = map[string]any{"patternProperties": map[string]any{"^(x-": map[string]any{}}}
+
+	testCases := []struct {
+		name         string
+		goType       reflect.Type
+		schema       openapi3.Schema
+		direction    Direction
+		errAssertion func(require.TestingT, error, ...any)
+	}{
+		{name: "field matching pattern property", goType: utils.GetType[WithExtensionFields](), schema: noAdditionalProperties, errAssertion: require.NoError},
+		{name: "incompatible field matching pattern property", goType: utils.GetType[WithIncompatibleExtensionField](), schema: defaultAdditionalProperties, errAssertion: require.Error},
+		{name: "catch-all with no additional properties", goType: utils.GetType[WithCatchAll](), schema: noAdditionalProperties, errAssertion: require.Error},
+		{name: "catch-all with pattern properties", goType: utils.GetType[WithCatchAll](), schema: defaultAdditionalProperties, errAssertion: require.NoError},
+		{name: "request without catch-all with pattern properties", goType: utils.GetType[WithExtensionFields](), schema: noAdditionalProperties, direction: RequestDirection, errAssertion: require.Error},
+		{name: "map with pattern properties", goType: utils.GetType[map[string]string](), schema: defaultAdditionalProperties, errAssertion: require.NoError},
+		{name: "map with pattern properties and no additional properties", goType: utils.GetType[map[string]string](), schema: noAdditionalProperties, errAssertion: require.Error},
+		{name: "invalid pattern", goType: utils.GetType[WithExtensionFields](), schema: invalidPattern, errAssertion: require.Error},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := typeSchemaValidator(testCase.goType, testCase.schema).WithDirection(testCase.direction).WithCatchAllFields(true).Validate()
+			testCase.errAssertion(t, err)
+		})
+	}
+}
+
+func TestObjectSchemaValidatorWithRecursiveTypes(t *testing.T) {
+	type Comment struct {
+		Text    string    `json:"text"`
+		Replies []Comment `json:"replies"`
+	}
+	type OrgUnit struct {
+		Name     string              `json:"name"`
+		Parent   *OrgUnit            `json:"parent"`
+		Children map[string]*OrgUnit `json:"children"`
+	}
+	type InvalidComment struct {
+		Text    int              `json:"text"`
+		Replies []InvalidComment `json:"replies"`
+	}
+
+	commentSchemaRef := &openapi3.SchemaRef{Ref: "#/components/schemas/Comment"}
+	commentSchemaRef.Value = openapi3.NewObjectSchema().
+		WithProperty("text", openapi3.NewStringSchema()).
+		WithProperty("replies", openapi3.NewArraySchema().WithItems(openapi3.NewSchema()))
+	commentSchemaRef.Value.Properties["replies"].Value.Items = commentSchemaRef
+
+	orgUnitSchemaRef := &openapi3.SchemaRef{Ref: "#/components/schemas/OrgUnit"}
+	orgUnitSchemaRef.Value = openapi3.NewObjectSchema().WithProperty("name", openapi3.NewStringSchema())
+	orgUnitSchemaRef.Value.Properties["parent"] = orgUnitSchemaRef
+	orgUnitSchemaRef.Value.Properties["children"] = openapi3.NewObjectSchema().WithAdditionalProperties(openapi3.NewSchema()).NewRef()
+	orgUnitSchemaRef.Value.Properties["children"].Value.AdditionalProperties.Schema = orgUnitSchemaRef
+
+	testCases := []struct {
+		name         string
+		goType       reflect.Type
+		schema       *openapi3.SchemaRef
+		errAssertion func(require.TestingT, error, ...any)
+	}{
+		{name: "recursive slice", goType: utils.GetType[Comment](), schema: commentSchemaRef, errAssertion: require.NoError},
+		{name: "recursive pointer and map", goType: utils.GetType[OrgUnit](), schema: orgUnitSchemaRef, errAssertion: require.NoError},
+		{name: "recursive type incompatible with recursive schema", goType: utils.GetType[InvalidComment](), schema: commentSchemaRef, errAssertion: require.Error},
+		{name: "recursive types with different recursive schemas", goType: utils.GetType[Comment](), schema: orgUnitSchemaRef, errAssertion: require.Error},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := typeSchemaValidator(testCase.goType, *testCase.schema.Value).WithSchemaPath(testCase.schema.Ref).Validate()
+			testCase.errAssertion(t, err)
+		})
+	}
+}
@@ -1,14 +1,16 @@
 package schema_validator
 
+import "strconv"
+
 func (c typeSchemaValidatorContext) validateSchemaAllOf() {
 	if c.schema.AllOf == nil {
 		return
 	}
 	errors := len(*c.errors)
-	for _, option := range c.schema.AllOf {
-		_ = c.WithSchema(*option.Value).Validate()
+	for index, option := range c.schema.AllOf {
+		_ = c.withSchemaRef(option, RuleAllOf, strconv.Itoa(index)).validate()
 	}
 	if len(*c.errors) > errors {
-		c.err(schemaAllOfPropertyIncompatibleWithType(len(*c.errors)-errors, len(c.schema.AllOf), c.goType))
+		c.err(RuleAllOf, schemaAllOfPropertyIncompatibleWithType(len(*c.errors)-errors, len(c.schema.AllOf), c.goType))
 	}
 }
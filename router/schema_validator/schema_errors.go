@@ -0,0 +1,65 @@
+package schema_validator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rules reported by SchemaError are named after the schema keyword that the type failed to comply with.
+const (
+	RuleType                 = "type"
+	RuleFormat               = "format"
+	RuleProperties           = "properties"
+	RuleAdditionalProperties = "additionalProperties"
+	RulePatternProperties    = "patternProperties"
+	RuleWriteOnly            = "writeOnly"
+	RuleAllOf                = "allOf"
+	RuleOneOf                = "oneOf"
+	RuleAnyOf                = "anyOf"
+	RuleNot                  = "not"
+	RuleDiscriminator        = "discriminator"
+)
+
+// rootSchemaPath is the JSON pointer of the validated schema when no other path is configured with WithSchemaPath.
+const rootSchemaPath = "#"
+
+// SchemaError describes a single incompatibility found between a reflect.Type and an openapi3.Schema.
+type SchemaError struct {
+	// SchemaPath is the JSON pointer of the incompatible schema (e.g. "#/components/schemas/Task/properties/status").
+	// Schemas defined with a $ref are pointed to by their reference.
+	SchemaPath string
+	// TypePath is the path of the incompatible type from the validated type (e.g. "models.Task.Status").
+	// Elements of slices, arrays and maps are marked with "[]".
+	TypePath string
+	// Rule is the schema keyword that the type failed to comply with (e.g. RuleType, RuleProperties).
+	Rule string
+	// Message describes the incompatibility.
+	Message string
+}
+
+func (e SchemaError) Error() string {
+	location := make([]string, 0, 2)
+	if e.SchemaPath != "" {
+		location = append(location, fmt.Sprintf("schema: %s", e.SchemaPath))
+	}
+	if e.TypePath != "" {
+		location = append(location, fmt.Sprintf("type: %s", e.TypePath))
+	}
+	if len(location) == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%s (%s)", e.Message, strings.Join(location, ", "))
+}
+
+// JoinJSONPointer appends the reference tokens to the JSON pointer escaping them as defined by RFC 6901.
+func JoinJSONPointer(pointer string, tokens ...string) string {
+	var builder strings.Builder
+	builder.WriteString(pointer)
+	for _, token := range tokens {
+		builder.WriteByte('/')
+		builder.WriteString(jsonPointerEscaper.Replace(token))
+	}
+	return builder.String()
+}
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
@@ -0,0 +1,69 @@
+package schema_validator
+
+import (
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/piiano/cellotape/router/utils"
+)
+
+func TestJoinJSONPointer(t *testing.T) {
+	assert.Equal(t, "#", JoinJSONPointer("#"))
+	assert.Equal(t, "#/paths/~1tasks~1{id}/get", JoinJSONPointer("#", "paths", "/tasks/{id}", "get"))
+	assert.Equal(t, "#/content/application~1json/schema", JoinJSONPointer("#/content", "application/json", "schema"))
+	assert.Equal(t, "#/properties/a~0b", JoinJSONPointer("#", "properties", "a~b"))
+}
+
+func TestSchemaErrorPaths(t *testing.T) {
+	type Task struct {
+		Summary string `json:"summary"`
+		Status  int    `json:"status"`
+	}
+	type Page struct {
+		Results []Task `json:"results"`
+	}
+
+	taskSchema := openapi3.NewObjectSchema().
+		WithProperty("summary", openapi3.NewStringSchema()).
+		WithPropertyRef("status", &openapi3.SchemaRef{Ref: "#/components/schemas/Status", Value: openapi3.NewStringSchema()})
+	pageSchema := openapi3.NewObjectSchema().
+		WithProperty("results", openapi3.NewArraySchema().WithItems(taskSchema))
+
+	validator := NewTypeSchemaValidator(utils.GetType[Page](), *pageSchema).WithSchemaPath("#/components/schemas/Page")
+	require.Error(t, validator.Validate())
+
+	statusErrors := utils.Filter(validator.SchemaErrors(), func(err SchemaError) bool {
+		return err.SchemaPath == "#/components/schemas/Status"
+	})
+	require.NotEmpty(t, statusErrors)
+	assert.Equal(t, "schema_validator.Page.Results[].Status", statusErrors[0].TypePath)
+	assert.Equal(t, RuleType, statusErrors[0].Rule)
+	assert.Contains(t, statusErrors[0].Error(), "(schema: #/components/schemas/Status, type: schema_validator.Page.Results[].Status)")
+
+	propertyErrors := utils.Filter(validator.SchemaErrors(), func(err SchemaError) bool {
+		return err.Rule == RuleProperties
+	})
+	require.NotEmpty(t, propertyErrors)
+	assert.Equal(t, "#/components/schemas/Status", propertyErrors[0].SchemaPath)
+
+	resultsErrors := utils.Filter(validator.SchemaErrors(), func(err SchemaError) bool {
+		return err.SchemaPath == "#/components/schemas/Page/properties/results"
+	})
+	require.NotEmpty(t, resultsErrors)
+	assert.Equal(t, "schema_validator.Page.Results", resultsErrors[0].TypePath)
+}
+
+func TestSchemaErrorWithDefaultSchemaPath(t *testing.T) {
+	type Task struct {
+		Summary int `json:"summary"`
+	}
+	schema := openapi3.NewObjectSchema().WithProperty("summary", openapi3.NewStringSchema())
+	validator := NewTypeSchemaValidator(utils.GetType[*Task](), *schema)
+	require.Error(t, validator.Validate())
+	require.NotEmpty(t, validator.SchemaErrors())
+	assert.Equal(t, "#/properties/summary", validator.SchemaErrors()[0].SchemaPath)
+	assert.Equal(t, "schema_validator.Task.Summary", validator.SchemaErrors()[0].TypePath)
+}
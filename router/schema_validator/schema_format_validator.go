@@ -79,4 +79,43 @@ const (
 	passwordFormat = "password"
 )
 
-// TODO add validation for formats
+var (
+	// isCustomTextCompatible matches types that serialize to a string with encoding.TextMarshaler excluding types that
+	// are bound to a specific format (e.g. uuid.UUID for "uuid" and netip.Addr for "ipv4" and "ipv6").
+	isCustomTextCompatible = allOf(isTextCompatible, not(convertibleTo(uuidType)), not(typeIs(netipAddrType, netIPType)))
+	isStringOrCustomText   = anyOf(isString, isCustomTextCompatible)
+
+	// stringFormatTypeAssertions maps string formats to an assertion of the go types that can be serialized to them.
+	// Note that some standard types that look like a natural fit are not serialized as a string by encoding/json and are
+	// therefore incompatible.
+	// e.g. time.Duration is serialized as an integer and url.URL is serialized as an object.
+	// For these formats, use a string or a custom type that implements encoding.TextMarshaler and encoding.TextUnmarshaler.
+	stringFormatTypeAssertions = map[string]typeAssertion{
+		ipv4Format:                isIPCompatible,
+		ipv6Format:                isIPCompatible,
+		dateFormat:                isStringOrCustomText,
+		durationFormat:            isStringOrCustomText,
+		emailFormat:               isStringOrCustomText,
+		idnEmailFormat:            isStringOrCustomText,
+		hostnameFormat:            isStringOrCustomText,
+		idnHostnameFormat:         isStringOrCustomText,
+		uriFormat:                 isStringOrCustomText,
+		uriReferenceFormat:        isStringOrCustomText,
+		iriFormat:                 isStringOrCustomText,
+		iriReferenceFormat:        isStringOrCustomText,
+		uriTemplateFormat:         isStringOrCustomText,
+		jsonPointerFormat:         isStringOrCustomText,
+		relativeJsonPointerFormat: isStringOrCustomText,
+		regexFormat:               isStringOrCustomText,
+		passwordFormat:            isString,
+	}
+)
+
+// stringFormatTypeAssertion returns the assertion of go types compatible with the string format.
+// Formats with no specific assertion are expected to be serialized from a string.
+func stringFormatTypeAssertion(format string) typeAssertion {
+	if assertion, ok := stringFormatTypeAssertions[format]; ok {
+		return assertion
+	}
+	return isString
+}
@@ -5,8 +5,8 @@ func (c typeSchemaValidatorContext) validateSchemaNot() {
 		return
 	}
 	errors := len(*c.errors)
-	if err := c.WithSchema(*c.schema.Not.Value).Validate(); err == nil {
-		c.err("schema with not property is incompatible with type %s", c.goType)
+	if err := c.withSchemaRef(c.schema.Not, RuleNot).validate(); err == nil {
+		c.err(RuleNot, "schema with not property is incompatible with type %s", c.goType)
 		return
 	}
 	*c.errors = (*c.errors)[:errors]
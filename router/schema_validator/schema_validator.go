@@ -12,6 +12,20 @@ import (
 
 var ErrSchemaIncompatibleWithType = errors.New("schema is incompatible with type")
 
+// Direction describes whether a type is used for decoding requests, encoding responses or both.
+// The direction determines how readOnly and writeOnly schema properties are validated.
+type Direction int
+
+const (
+	// Bidirectional validates the type is compatible with the schema regardless of readOnly and writeOnly properties.
+	// This is the default Direction.
+	Bidirectional Direction = iota
+	// RequestDirection validates a type used to decode requests. readOnly properties don't have to be mapped to a field.
+	RequestDirection
+	// ResponseDirection validates a type used to encode responses. writeOnly properties don't have to be mapped to a field.
+	ResponseDirection
+)
+
 // TypeSchemaValidator helps validate reflect.Type and openapi3.Schema compatibility using the validation Options.
 type TypeSchemaValidator interface {
 	// WithType immutably returns a new TypeSchemaValidator with the specified reflect.Type to validate.
@@ -37,31 +51,135 @@ type TypeSchemaValidator interface {
 	validateNumberSchema()
 }
 
+// ConfigurableTypeSchemaValidator is a TypeSchemaValidator with additional validation options and structured errors.
+// The validators returned by NewTypeSchemaValidator and NewEmptyTypeSchemaValidator implement it.
+// The router checks if the TypeSchemaValidator of a content type implements it to validate the type according to its
+// direction and to report the structured errors. Other validators validate the type regardless of its direction.
+type ConfigurableTypeSchemaValidator interface {
+	TypeSchemaValidator
+	// WithDirection immutably returns a new ConfigurableTypeSchemaValidator that validates the type for the specified
+	// Direction.
+	WithDirection(Direction) ConfigurableTypeSchemaValidator
+	// WithForbiddenWriteOnlyFields immutably returns a new ConfigurableTypeSchemaValidator that when used with
+	// ResponseDirection reports fields mapped to writeOnly properties as errors.
+	WithForbiddenWriteOnlyFields(bool) ConfigurableTypeSchemaValidator
+	// WithCatchAllFields immutably returns a new ConfigurableTypeSchemaValidator that validates embedded maps and map
+	// fields tagged with an "inline" or "unknown" json tag option as catch-all fields that hold the properties not
+	// mapped to other fields.
+	// Enable it only for content types that encode and decode such fields as catch-all fields (e.g. encoding/json/v2).
+	// Otherwise, they are validated as ordinary fields like encoding/json does.
+	WithCatchAllFields(bool) ConfigurableTypeSchemaValidator
+	// WithSchemaPath immutably returns a new ConfigurableTypeSchemaValidator that reports errors relative to the
+	// specified JSON pointer of the validated schema (e.g. "#/components/schemas/Task").
+	WithSchemaPath(string) ConfigurableTypeSchemaValidator
+	// SchemaErrors returns all compatability errors found by Validate with their schema and type paths.
+	SchemaErrors() []SchemaError
+	// Warnings returns the issues found by Validate that don't make the type incompatible with the schema but may
+	// cause unexpected behaviour at runtime.
+	Warnings() []SchemaError
+}
+
 // NewEmptyTypeSchemaValidator returns a new TypeSchemaValidator that have no reflect.Type or openapi3.Schema configured yet.
-func NewEmptyTypeSchemaValidator() TypeSchemaValidator {
+func NewEmptyTypeSchemaValidator() ConfigurableTypeSchemaValidator {
 	return typeSchemaValidatorContext{
-		errors: new([]string),
+		errors:     new([]SchemaError),
+		warnings:   new([]SchemaError),
+		schemaPath: rootSchemaPath,
+		inProgress: utils.NewSet[inProgressValidation](),
 	}
 }
 
 // NewTypeSchemaValidator returns a new TypeSchemaValidator that helps validate reflect.Type and openapi3.Schema compatibility using the validation Options.
-func NewTypeSchemaValidator(goType reflect.Type, schema openapi3.Schema) TypeSchemaValidator {
+func NewTypeSchemaValidator(goType reflect.Type, schema openapi3.Schema) ConfigurableTypeSchemaValidator {
 	return typeSchemaValidatorContext{
-		errors: new([]string),
-		schema: schema,
-		goType: goType,
+		errors:     new([]SchemaError),
+		warnings:   new([]SchemaError),
+		schema:     schema,
+		goType:     goType,
+		schemaPath: rootSchemaPath,
+		inProgress: utils.NewSet[inProgressValidation](),
 	}
 }
 
 // typeSchemaValidatorContext an internal struct that implementation TypeSchemaValidator
 type typeSchemaValidatorContext struct {
-	errors *[]string
-	schema openapi3.Schema
-	goType reflect.Type
+	errors                *[]SchemaError
+	warnings              *[]SchemaError
+	schema                openapi3.Schema
+	goType                reflect.Type
+	direction             Direction
+	forbidWriteOnlyFields bool
+	catchAllFields        bool
+	// schemaPath is the JSON pointer of the schema reported with errors.
+	schemaPath string
+	// typePath is the path of the type reported with errors. It is initialized with the validated type name.
+	typePath string
+	// inProgress holds the validations of the current recursion to detect cycles of recursive types and schemas.
+	inProgress utils.Set[inProgressValidation]
+}
+
+// inProgressValidation identifies the validation of a type with a schema.
+// Schemas are identified by their JSON pointer which is their $ref when they are referenced.
+type inProgressValidation struct {
+	goType     reflect.Type
+	schemaPath string
 }
 
-func (c typeSchemaValidatorContext) err(format string, args ...any) {
-	*c.errors = append(*c.errors, fmt.Sprintf(format, args...))
+// err reports an error for the rule with the current schema and type paths.
+func (c typeSchemaValidatorContext) err(rule string, format string, args ...any) {
+	*c.errors = append(*c.errors, SchemaError{
+		SchemaPath: c.schemaPath,
+		TypePath:   c.typePath,
+		Rule:       rule,
+		Message:    fmt.Sprintf(format, args...),
+	})
+}
+
+// warn reports a warning for the rule with the current schema and type paths.
+func (c typeSchemaValidatorContext) warn(rule string, format string, args ...any) {
+	*c.warnings = append(*c.warnings, SchemaError{
+		SchemaPath: c.schemaPath,
+		TypePath:   c.typePath,
+		Rule:       rule,
+		Message:    fmt.Sprintf(format, args...),
+	})
+}
+
+// withSchemaRef returns a context for validating a nested schema.
+// The schema path of the nested schema is its reference if it has one, or the tokens appended to the current path.
+func (c typeSchemaValidatorContext) withSchemaRef(schemaRef *openapi3.SchemaRef, tokens ...string) typeSchemaValidatorContext {
+	c.schema = *schemaRef.Value
+	c.schemaPath = nestedSchemaPath(c.schemaPath, schemaRef.Ref, tokens...)
+	return c
+}
+
+// withNestedSchema returns a context for validating a nested schema that has no reference of its own.
+func (c typeSchemaValidatorContext) withNestedSchema(schema openapi3.Schema, tokens ...string) typeSchemaValidatorContext {
+	c.schema = schema
+	c.schemaPath = JoinJSONPointer(c.schemaPath, tokens...)
+	return c
+}
+
+// withField returns a context for validating the type of a struct field.
+func (c typeSchemaValidatorContext) withField(field reflect.StructField) typeSchemaValidatorContext {
+	c.goType = field.Type
+	c.typePath = c.typePath + "." + field.Name
+	return c
+}
+
+// withElem returns a context for validating the element type of a slice, array or map.
+func (c typeSchemaValidatorContext) withElem(elemType reflect.Type) typeSchemaValidatorContext {
+	c.goType = elemType
+	c.typePath = c.typePath + "[]"
+	return c
+}
+
+// nestedSchemaPath returns the reference of a nested schema if it has one, or the tokens appended to the parent path.
+func nestedSchemaPath(parentPath string, ref string, tokens ...string) string {
+	if ref != "" {
+		return ref
+	}
+	return JoinJSONPointer(parentPath, tokens...)
 }
 
 func (c typeSchemaValidatorContext) WithType(goType reflect.Type) TypeSchemaValidator {
@@ -77,22 +195,70 @@ func (c typeSchemaValidatorContext) WithSchemaAndType(schema openapi3.Schema, go
 	c.goType = goType
 	return c
 }
+func (c typeSchemaValidatorContext) WithDirection(direction Direction) ConfigurableTypeSchemaValidator {
+	c.direction = direction
+	return c
+}
+func (c typeSchemaValidatorContext) WithForbiddenWriteOnlyFields(forbid bool) ConfigurableTypeSchemaValidator {
+	c.forbidWriteOnlyFields = forbid
+	return c
+}
+func (c typeSchemaValidatorContext) WithCatchAllFields(catchAllFields bool) ConfigurableTypeSchemaValidator {
+	c.catchAllFields = catchAllFields
+	return c
+}
+func (c typeSchemaValidatorContext) WithSchemaPath(schemaPath string) ConfigurableTypeSchemaValidator {
+	c.schemaPath = schemaPath
+	return c
+}
 func (c typeSchemaValidatorContext) Errors() []string {
+	return utils.Map(*c.errors, SchemaError.Error)
+}
+func (c typeSchemaValidatorContext) SchemaErrors() []SchemaError {
 	return *c.errors
 }
+func (c typeSchemaValidatorContext) Warnings() []SchemaError {
+	return *c.warnings
+}
 
+// Validate fails if there are any errors, including errors from previous validations with the same errors.
 func (c typeSchemaValidatorContext) Validate() error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	if len(*c.errors) > 0 {
+		return fmt.Errorf("%w %s", ErrSchemaIncompatibleWithType, c.goType)
+	}
+	return nil
+}
+
+// validate fails only if errors were found by this validation.
+// It is used for validating nested schemas and types as the errors are shared with the parent validation.
+func (c typeSchemaValidatorContext) validate() error {
 	if isAny(c.goType) {
 		return nil
 	}
+	if c.goType.Kind() == reflect.Pointer && !utils.IsMultiType(c.goType) {
+		c.goType = c.goType.Elem()
+		return c.validate()
+	}
+	if c.typePath == "" {
+		c.typePath = c.goType.String()
+	}
+	// A recursive type reaches the same referenced schema again while the schema is still validated.
+	// The type is assumed compatible with the schema at this point as any incompatibility is reported by the
+	// validation that is already in progress.
+	validation := inProgressValidation{goType: c.goType, schemaPath: c.schemaPath}
+	if !c.inProgress.Add(validation) {
+		return nil
+	}
+	defer c.inProgress.Remove(validation)
+	errorsBefore := len(*c.errors)
 	if utils.IsMultiType(c.goType) {
 		if _, err := utils.ExtractMultiTypeTypes(c.goType); err != nil {
-			c.err(err.Error())
+			c.err(RuleType, err.Error())
 		}
 	}
-	if c.goType.Kind() == reflect.Pointer && !utils.IsMultiType(c.goType) {
-		return c.WithType(c.goType.Elem()).Validate()
-	}
 
 	// Test global schema validation properties
 	c.validateSchemaAllOf()
@@ -108,9 +274,9 @@ func (c typeSchemaValidatorContext) Validate() error {
 	c.validateNumberSchema()
 	c.validateIntegerSchema()
 
-	if len(*c.errors) > 0 {
+	if len(*c.errors) > errorsBefore {
 		err := fmt.Errorf("%w %s", ErrSchemaIncompatibleWithType, c.goType)
-		c.err(err.Error())
+		c.err(RuleType, err.Error())
 		return err
 	}
 
@@ -158,6 +158,6 @@ func typeValidator(goType reflect.Type) TypeSchemaValidator {
 func schemaValidator(schema openapi3.Schema) TypeSchemaValidator {
 	return emptyValidator().WithSchema(schema)
 }
-func typeSchemaValidator(goType reflect.Type, schema openapi3.Schema) TypeSchemaValidator {
-	return typeValidator(goType).WithSchema(schema)
+func typeSchemaValidator(goType reflect.Type, schema openapi3.Schema) ConfigurableTypeSchemaValidator {
+	return NewTypeSchemaValidator(goType, schema)
 }
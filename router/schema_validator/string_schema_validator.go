@@ -4,7 +4,7 @@ import "github.com/getkin/kin-openapi/openapi3"
 
 func (c typeSchemaValidatorContext) validateStringSchema() {
 	if c.schema.Type.Is(openapi3.TypeString) && !isSerializedFromString(c.goType) {
-		c.err(schemaTypeIsIncompatibleWithType(c.schema, c.goType))
+		c.err(RuleType, schemaTypeIsIncompatibleWithType(c.schema, c.goType))
 	}
 
 	// if schema type is not string and not empty
@@ -12,7 +12,7 @@ func (c typeSchemaValidatorContext) validateStringSchema() {
 		// and go type is a string
 		if isString(c.goType) {
 			// can't have a go type string for the remaining schema types: boolean, number, integer, array, object
-			c.err(schemaTypeIsIncompatibleWithType(c.schema, c.goType))
+			c.err(RuleType, schemaTypeIsIncompatibleWithType(c.schema, c.goType))
 		}
 
 		// if schema type is not string and not empty other string validations has no meaning. return early.
@@ -28,7 +28,7 @@ func (c typeSchemaValidatorContext) validateStringSchema() {
 	// if schema format is "byte" expect type to be compatible with []byte
 	if c.schema.Format == byteFormat {
 		if (c.schema.Type.Is(openapi3.TypeString) || isSerializedFromString(c.goType)) && !isSliceOfBytes(c.goType) {
-			c.err(schemaTypeWithFormatIsIncompatibleWithType(c.schema, c.goType))
+			c.err(RuleFormat, schemaTypeWithFormatIsIncompatibleWithType(c.schema, c.goType))
 		}
 		return
 	}
@@ -36,7 +36,7 @@ func (c typeSchemaValidatorContext) validateStringSchema() {
 	// if schema format is "uuid" expect type to be compatible with UUID
 	if c.schema.Format == uuidFormat {
 		if (c.schema.Type.Is(openapi3.TypeString) || isSerializedFromString(c.goType)) && !isUUIDCompatible(c.goType) {
-			c.err(schemaTypeWithFormatIsIncompatibleWithType(c.schema, c.goType))
+			c.err(RuleFormat, schemaTypeWithFormatIsIncompatibleWithType(c.schema, c.goType))
 		}
 		return
 	}
@@ -44,14 +44,14 @@ func (c typeSchemaValidatorContext) validateStringSchema() {
 	// if schema format is "date-time" or "time" expect type to be compatible with Time
 	if isTimeFormat(c.schema) {
 		if (c.schema.Type.Is(openapi3.TypeString) || isSerializedFromString(c.goType)) && !isTimeCompatible(c.goType) {
-			c.err(schemaTypeWithFormatIsIncompatibleWithType(c.schema, c.goType))
+			c.err(RuleFormat, schemaTypeWithFormatIsIncompatibleWithType(c.schema, c.goType))
 		}
 		return
 	}
 
-	// if schema format is any other string format expect go type to be string
-	if isSchemaStringFormat(c.schema) && (c.schema.Type.Is(openapi3.TypeString) || isSerializedFromString(c.goType)) && !isString(c.goType) {
-		c.err(schemaTypeWithFormatIsIncompatibleWithType(c.schema, c.goType))
+	// if schema format is any other string format expect go type to be compatible with the format
+	if isSchemaStringFormat(c.schema) && (c.schema.Type.Is(openapi3.TypeString) || isSerializedFromString(c.goType)) && !stringFormatTypeAssertion(c.schema.Format)(c.goType) {
+		c.err(RuleFormat, schemaTypeWithFormatIsIncompatibleWithType(c.schema, c.goType))
 		return
 	}
 }
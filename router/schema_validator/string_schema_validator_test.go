@@ -132,3 +132,52 @@ func TestStringSchemaValidatorWithOtherFormats(t *testing.T) {
 		})
 	}
 }
+
+type customTextType struct{ value string }
+
+func (c customTextType) MarshalText() ([]byte, error) { return []byte(c.value), nil }
+func (c *customTextType) UnmarshalText(text []byte) error {
+	c.value = string(text)
+	return nil
+}
+
+func TestIPFormatSchemaValidator(t *testing.T) {
+	for _, format := range []string{ipv4Format, ipv6Format} {
+		t.Run(format, func(t *testing.T) {
+			validator := schemaValidator(*openapi3.NewStringSchema().WithFormat(format))
+			errTemplate := "expect string schema with ip format to be %s with %s type"
+			for _, compatibleType := range []reflect.Type{stringType, netipAddrType, netIPType, reflect.PointerTo(netipAddrType)} {
+				expectTypeToBeCompatible(t, validator, compatibleType, errTemplate, "compatible", compatibleType)
+			}
+			for _, incompatibleType := range []reflect.Type{intType, uuidType, timeType, sliceOfBytesType, reflect.TypeOf(customTextType{})} {
+				expectTypeToBeIncompatible(t, validator, incompatibleType, errTemplate, "incompatible", incompatibleType)
+			}
+		})
+	}
+}
+
+func TestCustomTextFormatSchemaValidator(t *testing.T) {
+	customType := reflect.TypeOf(customTextType{})
+	formats := []string{dateFormat, durationFormat, emailFormat, hostnameFormat, uriFormat, jsonPointerFormat, regexFormat}
+	for _, format := range formats {
+		t.Run(format, func(t *testing.T) {
+			validator := schemaValidator(*openapi3.NewStringSchema().WithFormat(format))
+			errTemplate := "expect string schema with %s format to be %s with %s type"
+			expectTypeToBeCompatible(t, validator, stringType, errTemplate, format, "compatible", stringType)
+			expectTypeToBeCompatible(t, validator, customType, errTemplate, format, "compatible", customType)
+			expectTypeToBeCompatible(t, validator, reflect.PointerTo(customType), errTemplate, format, "compatible", reflect.PointerTo(customType))
+			for _, incompatibleType := range []reflect.Type{int64Type, uuidType, timeType, netipAddrType, reflect.TypeOf(time.Duration(0))} {
+				expectTypeToBeIncompatible(t, validator, incompatibleType, errTemplate, format, "incompatible", incompatibleType)
+			}
+		})
+	}
+}
+
+func TestCustomTextTypeIsIncompatibleWithObjectAndArraySchemas(t *testing.T) {
+	errTemplate := "expect %s schema to be incompatible with %s type"
+	for _, schema := range []*openapi3.Schema{openapi3.NewObjectSchema(), openapi3.NewArraySchema()} {
+		for _, textType := range []reflect.Type{reflect.TypeOf(customTextType{}), netipAddrType, netIPType} {
+			expectTypeToBeIncompatible(t, schemaValidator(*schema), textType, errTemplate, schema.Type, textType)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/piiano/cellotape/router/utils"
+)
+
+// SecurityScheme is a security scheme of the spec that a request of an operation is authenticated with.
+type SecurityScheme struct {
+	// Name is the name of the security scheme in the spec components.
+	Name string
+	// Scheme is the definition of the security scheme in the spec.
+	Scheme *openapi3.SecurityScheme
+	// Scopes are the scopes the operation security requirement lists for the scheme.
+	Scopes []string
+}
+
+// Authenticator authenticates a request with a security scheme of its operation.
+// It returns nil if the request is authenticated and an error describing why it is not otherwise.
+//
+// Authenticators are added to the router for each security scheme name with OpenAPIRouter.WithAuthenticator.
+type Authenticator func(ctx *Context, scheme SecurityScheme) error
+
+// Credential returns the credential the request sends for the security scheme.
+//
+//   - apiKey - the value of the header, query param or cookie defined by the scheme.
+//   - http - the credentials of the Authorization header with the scheme of the security scheme (e.g. the token of
+//     "Bearer <token>" or the base64 encoded credentials of "Basic <credentials>").
+//   - oauth2 and openIdConnect - the token of a "Bearer <token>" Authorization header.
+//
+// Returns false if the request doesn't send a credential for the scheme.
+func (s SecurityScheme) Credential(request *http.Request) (string, bool) {
+	if s.Scheme == nil {
+		return "", false
+	}
+	switch s.Scheme.Type {
+	case "apiKey":
+		var value string
+		switch s.Scheme.In {
+		case "header":
+			value = request.Header.Get(s.Scheme.Name)
+		case "query":
+			value = request.URL.Query().Get(s.Scheme.Name)
+		case "cookie":
+			if cookie, err := request.Cookie(s.Scheme.Name); err == nil {
+				value = cookie.Value
+			}
+		}
+		return value, value != ""
+	case "http":
+		return authorizationCredentials(request, s.Scheme.Scheme)
+	case "oauth2", "openIdConnect":
+		return authorizationCredentials(request, "bearer")
+	}
+	return "", false
+}
+
+// authorizationCredentials returns the credentials of the Authorization header if it uses the authentication scheme.
+func authorizationCredentials(request *http.Request, scheme string) (string, bool) {
+	authorization := request.Header.Get("Authorization")
+	authScheme, credentials, found := strings.Cut(authorization, " ")
+	if !found || !strings.EqualFold(authScheme, scheme) {
+		return "", false
+	}
+	credentials = strings.TrimSpace(credentials)
+	return credentials, credentials != ""
+}
+
+// SetPrincipal attaches the authenticated principal of the request (e.g. the user, its tenant and granted scopes) to
+// the context, so it can be retrieved by the following handlers with Principal.
+//
+// When a security scheme requires oauth2 or openIdConnect scopes, the principal must implement ScopedPrincipal and
+// be granted all the required scopes for the request to be authenticated.
+func (c *Context) SetPrincipal(principal any) {
+	c.principal = principal
+}
+
+// Principal returns the authenticated principal attached to the context with Context.SetPrincipal.
+// Returns false if no principal is attached or if it is not of type T.
+func Principal[T any](c *Context) (T, bool) {
+	principal, ok := c.principal.(T)
+	return principal, ok
+}
+
+// ScopedPrincipal is a principal that exposes its granted scopes.
+// The router checks the scopes of principals attached by authenticators against the scopes the operation security
+// requirements declare for oauth2 and openIdConnect security schemes.
+type ScopedPrincipal interface {
+	Scopes() []string
+}
+
+// checkScopes checks that the principal attached to the context is granted all the scopes required by the scheme.
+// Returns an InsufficientScopeErr with the scopes that are not granted otherwise.
+func checkScopes(ctx *Context, scheme SecurityScheme) error {
+	if len(scheme.Scopes) == 0 || scheme.Scheme == nil ||
+		(scheme.Scheme.Type != "oauth2" && scheme.Scheme.Type != "openIdConnect") {
+		return nil
+	}
+	principal, ok := ctx.principal.(ScopedPrincipal)
+	if !ok {
+		return InsufficientScopeErr{
+			Scopes: scheme.Scopes,
+			Err:    fmt.Errorf("principal %T doesn't expose the granted scopes required by the operation", ctx.principal),
+		}
+	}
+	granted := utils.NewSet(principal.Scopes()...)
+	missing := utils.Filter(scheme.Scopes, func(scope string) bool { return !granted.Has(scope) })
+	if len(missing) > 0 {
+		return InsufficientScopeErr{Scopes: missing}
+	}
+	return nil
+}
+
+// InsufficientScopeErr is the error of a security scheme when the authenticated principal is not granted all the
+// scopes the operation requires.
+type InsufficientScopeErr struct {
+	// Scopes are the required scopes that are not granted to the principal.
+	Scopes []string
+	// Err is the reason the scopes of the principal couldn't be checked, if any.
+	Err error
+}
+
+func (e InsufficientScopeErr) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("missing required scopes %q", e.Scopes)
+}
+
+func (e InsufficientScopeErr) Unwrap() error {
+	return e.Err
+}
+
+// UnauthorizedErr is the error returned when a request doesn't satisfy any of the security requirements of its
+// operation. The router responds to it with status 401.
+type UnauthorizedErr struct {
+	// Err joins the errors of the authenticators for every security requirement that wasn't satisfied.
+	Err error
+	// Challenges are the WWW-Authenticate challenges of the security schemes of the operation requirements.
+	Challenges []string
+	Context    *Context
+}
+
+func (e UnauthorizedErr) Error() string {
+	return fmt.Sprintf("unauthorized request. %s", e.Err)
+}
+
+func (e UnauthorizedErr) Unwrap() error {
+	return e.Err
+}
+
+// ForbiddenErr is the error returned when a request is authenticated by all the schemes of a security requirement of
+// its operation but the principal is not granted the scopes the requirement requires, and no other requirement is
+// satisfied. The router responds to it with status 403 and the RFC 6750 insufficient_scope error.
+type ForbiddenErr struct {
+	// Err joins the errors of the authenticators for every security requirement that wasn't satisfied.
+	Err error
+	// Scopes are the required scopes that are not granted to the principal.
+	Scopes  []string
+	Context *Context
+}
+
+func (e ForbiddenErr) Error() string {
+	return fmt.Sprintf("forbidden request. %s", e.Err)
+}
+
+func (e ForbiddenErr) Unwrap() error {
+	return e.Err
+}
+
+// insufficientScopeChallenge returns the WWW-Authenticate challenge of the RFC 6750 insufficient_scope error.
+func insufficientScopeChallenge(scopes []string) string {
+	return fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, strings.Join(scopes, " "))
+}
+
+// authenticationChallenges returns the WWW-Authenticate challenges of the schemes of the security requirements.
+// http schemes are challenged with their scheme, oauth2 and openIdConnect schemes with the RFC 6750 Bearer challenge
+// and apiKey schemes with a non-standard APIKey challenge that describes where the key is sent.
+func authenticationChallenges(requirements []securityRequirement) []string {
+	challenges := make([]string, 0)
+	added := utils.NewSet[string]()
+	for _, requirement := range requirements {
+		for _, scheme := range requirement {
+			if scheme.Scheme == nil {
+				continue
+			}
+			var challenge string
+			switch scheme.Scheme.Type {
+			case "http":
+				challenge = authScheme(scheme.Scheme.Scheme)
+				if strings.EqualFold(scheme.Scheme.Scheme, "basic") {
+					challenge = fmt.Sprintf(`%s realm="%s"`, challenge, scheme.Name)
+				}
+			case "oauth2", "openIdConnect":
+				challenge = "Bearer"
+				if len(scheme.Scopes) > 0 {
+					challenge = fmt.Sprintf(`Bearer scope="%s"`, strings.Join(scheme.Scopes, " "))
+				}
+			case "apiKey":
+				challenge = fmt.Sprintf(`APIKey in="%s", name="%s"`, scheme.Scheme.In, scheme.Scheme.Name)
+			default:
+				continue
+			}
+			if added.Add(challenge) {
+				challenges = append(challenges, challenge)
+			}
+		}
+	}
+	return challenges
+}
+
+// authScheme returns the authentication scheme of an http security scheme with its first letter capitalized
+// (e.g. "Bearer" for "bearer").
+func authScheme(scheme string) string {
+	if scheme == "" {
+		return scheme
+	}
+	return strings.ToUpper(scheme[:1]) + strings.ToLower(scheme[1:])
+}
+
+// securityRequirement is a security requirement of an operation with the authenticators of all its schemes.
+// A request satisfies the requirement if it is authenticated with all its schemes.
+type securityRequirement []authenticatedScheme
+
+type authenticatedScheme struct {
+	SecurityScheme
+	// authenticator is nil if the security scheme has no authenticator or is not defined in the spec
+	authenticator Authenticator
+}
+
+// operationSecurityRequirements returns the security requirements of the operation. Operations without their own
+// security requirements use the security requirements of the spec.
+func (s OpenAPISpec) operationSecurityRequirements(specOp SpecOperation) openapi3.SecurityRequirements {
+	if specOp.Operation != nil && specOp.Security != nil {
+		return *specOp.Security
+	}
+	return s.Security
+}
+
+// securitySchemes returns the security schemes defined in the spec components.
+func (s OpenAPISpec) securitySchemes() openapi3.SecuritySchemes {
+	if s.Components == nil {
+		return nil
+	}
+	return s.Components.SecuritySchemes
+}
+
+// securityHandler returns a handler that authenticates the requests of the operation with its security requirements.
+// Returns nil if the operation is not secured.
+// Requirements with a security scheme that has no authenticator are kept and can't be satisfied by any request, unless
+// Options.HandleAllSecuritySchemes is Ignore, in which case the scheme is skipped and left to the application to
+// authenticate (e.g. in its own middleware).
+func (oa openapi) securityHandler(specOp SpecOperation) Handler {
+	specRequirements := oa.spec.operationSecurityRequirements(specOp)
+	if len(specRequirements) == 0 {
+		return nil
+	}
+	schemes := oa.spec.securitySchemes()
+	requirements := make([]securityRequirement, 0, len(specRequirements))
+	for _, specRequirement := range specRequirements {
+		requirement := make(securityRequirement, 0, len(specRequirement))
+		names := utils.Keys(specRequirement)
+		sort.Strings(names)
+		for _, name := range names {
+			scheme := SecurityScheme{Name: name, Scopes: specRequirement[name]}
+			if schemeRef := schemes[name]; schemeRef != nil {
+				scheme.Scheme = schemeRef.Value
+			}
+			authenticator := oa.authenticators[name]
+			if authenticator == nil && scheme.Scheme != nil && oa.options.HandleAllSecuritySchemes == Ignore {
+				continue
+			}
+			requirement = append(requirement, authenticatedScheme{
+				SecurityScheme: scheme,
+				authenticator:  authenticator,
+			})
+		}
+		requirements = append(requirements, requirement)
+	}
+	return NewHandler(func(c *Context, _ Request[utils.Nil, utils.Nil, utils.Nil]) (Response[utils.Nil], error) {
+		if err := authenticate(c, requirements); err != nil {
+			return Error[utils.Nil](err)
+		}
+		_, err := c.Next()
+		return Error[utils.Nil](err)
+	})
+}
+
+// authenticate checks that the request satisfies at least one of the security requirements.
+// An empty security requirement is satisfied by any request.
+// Returns a ForbiddenErr if a requirement authenticated the request but the principal lacks its scopes, and an
+// UnauthorizedErr otherwise.
+func authenticate(ctx *Context, requirements []securityRequirement) error {
+	errs := make([]error, 0, len(requirements))
+	for _, requirement := range requirements {
+		err := authenticateRequirement(ctx, requirement)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	// an authenticated principal that isn't granted the required scopes is forbidden rather than unauthorized
+	err := errors.Join(errs...)
+	var scopeErr InsufficientScopeErr
+	if errors.As(err, &scopeErr) {
+		return ForbiddenErr{Err: err, Scopes: scopeErr.Scopes, Context: ctx}
+	}
+	return UnauthorizedErr{Err: err, Challenges: authenticationChallenges(requirements), Context: ctx}
+}
+
+// authenticateRequirement checks that the request is authenticated with all the schemes of the requirement and that
+// the principal is granted the scopes required by each scheme.
+// A requirement with a scheme that has no authenticator is never satisfied.
+// The principal attached by the authenticators of a requirement that is not satisfied is discarded.
+func authenticateRequirement(ctx *Context, requirement securityRequirement) error {
+	principal := ctx.principal
+	for _, scheme := range requirement {
+		if scheme.authenticator == nil || scheme.Scheme == nil {
+			ctx.principal = principal
+			return fmt.Errorf("security scheme %q: %s", scheme.Name, unresolvedSecurityScheme())
+		}
+		err := scheme.authenticator(ctx, scheme.SecurityScheme)
+		if err == nil {
+			err = checkScopes(ctx, scheme.SecurityScheme)
+		}
+		if err != nil {
+			ctx.principal = principal
+			return fmt.Errorf("security scheme %q: %w", scheme.Name, err)
+		}
+	}
+	return nil
+}
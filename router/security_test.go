@@ -0,0 +1,352 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// securityTestSpec returns a spec with operations that have global, AND, OR, no and optional security requirements.
+func securityTestSpec() OpenAPISpec {
+	withSecurity := func(id string, requirements ...openapi3.SecurityRequirement) *openapi3.Operation {
+		operation := testSpecOperation(id)
+		security := append(openapi3.SecurityRequirements{}, requirements...)
+		operation.Security = &security
+		return operation
+	}
+	spec := newTestSpec("test",
+		openapi3.WithPath("/global", &openapi3.PathItem{Get: testSpecOperation("global")}),
+		openapi3.WithPath("/and", &openapi3.PathItem{Get: withSecurity("and",
+			openapi3.NewSecurityRequirement().Authenticate("bearer").Authenticate("apiKey"))}),
+		openapi3.WithPath("/or", &openapi3.PathItem{Get: withSecurity("or",
+			openapi3.NewSecurityRequirement().Authenticate("apiKey"),
+			openapi3.NewSecurityRequirement().Authenticate("oauth", "read"))}),
+		openapi3.WithPath("/public", &openapi3.PathItem{Get: withSecurity("public")}),
+		openapi3.WithPath("/optional", &openapi3.PathItem{Get: withSecurity("optional",
+			openapi3.NewSecurityRequirement(),
+			openapi3.NewSecurityRequirement().Authenticate("apiKey"))}),
+	)
+	spec.Security = *openapi3.NewSecurityRequirements().With(openapi3.NewSecurityRequirement().Authenticate("bearer"))
+	spec.Components = &openapi3.Components{SecuritySchemes: openapi3.SecuritySchemes{
+		"bearer": {Value: &openapi3.SecurityScheme{Type: "http", Scheme: "bearer"}},
+		"apiKey": {Value: &openapi3.SecurityScheme{Type: "apiKey", In: "header", Name: "X-API-Key"}},
+		"oauth": {Value: &openapi3.SecurityScheme{Type: "oauth2", Flows: &openapi3.OAuthFlows{
+			ClientCredentials: &openapi3.OAuthFlow{
+				TokenURL: "https://example.com/token",
+				Scopes:   map[string]string{"read": "read access"},
+			},
+		}}},
+	}}
+	return spec
+}
+
+func securityTestRouter(options Options) OpenAPIRouter {
+	return okOperationsRouter(securityTestSpec(), options, "global", "and", "or", "public", "optional")
+}
+
+type testPrincipal struct {
+	id     string
+	scopes []string
+}
+
+func (p testPrincipal) Scopes() []string {
+	return p.scopes
+}
+
+// credentialAuthenticator authenticates requests that send the expected credential for the scheme and attaches a
+// principal with the granted scopes.
+func credentialAuthenticator(expected string, grantedScopes ...string) Authenticator {
+	return func(ctx *Context, scheme SecurityScheme) error {
+		credential, found := scheme.Credential(ctx.Request)
+		if !found || credential != expected {
+			return errors.New("invalid credential")
+		}
+		ctx.SetPrincipal(testPrincipal{id: credential, scopes: grantedScopes})
+		return nil
+	}
+}
+
+func TestSecurityRequirements(t *testing.T) {
+	handler, err := securityTestRouter(DefaultTestOptions()).
+		WithAuthenticator("bearer", credentialAuthenticator("token")).
+		WithAuthenticator("apiKey", credentialAuthenticator("key")).
+		WithAuthenticator("oauth", credentialAuthenticator("oauth-token", "read")).
+		AsHandler()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		path    string
+		headers map[string]string
+		status  int
+	}{
+		{path: "/global", status: 401},
+		{path: "/global", headers: map[string]string{"Authorization": "Bearer token"}, status: 200},
+		{path: "/global", headers: map[string]string{"Authorization": "Bearer other"}, status: 401},
+		{path: "/global", headers: map[string]string{"Authorization": "Basic token"}, status: 401},
+		{path: "/and", headers: map[string]string{"Authorization": "Bearer token"}, status: 401},
+		{path: "/and", headers: map[string]string{"X-API-Key": "key"}, status: 401},
+		{path: "/and", headers: map[string]string{"Authorization": "Bearer token", "X-API-Key": "key"}, status: 200},
+		{path: "/or", status: 401},
+		{path: "/or", headers: map[string]string{"X-API-Key": "key"}, status: 200},
+		{path: "/or", headers: map[string]string{"Authorization": "Bearer oauth-token"}, status: 200},
+		{path: "/public", status: 200},
+		{path: "/optional", status: 200},
+		{path: "/optional", headers: map[string]string{"X-API-Key": "other"}, status: 200},
+	}
+	for _, testCase := range testCases {
+		request := httptest.NewRequest(http.MethodGet, testCase.path, nil)
+		for key, value := range testCase.headers {
+			request.Header.Set(key, value)
+		}
+		writer := httptest.NewRecorder()
+		handler.ServeHTTP(writer, request)
+		assert.Equalf(t, testCase.status, writer.Code, "%s %v", testCase.path, testCase.headers)
+		if testCase.status == 401 {
+			assert.Equal(t, "Unauthorized", writer.Body.String())
+		}
+	}
+}
+
+func TestSecurityMissingAuthenticator(t *testing.T) {
+	_, err := securityTestRouter(DefaultTestOptions()).
+		WithAuthenticator("bearer", credentialAuthenticator("token")).
+		AsHandler()
+	require.Error(t, err)
+
+	report, _ := securityTestRouter(DefaultTestOptions()).
+		WithAuthenticator("bearer", credentialAuthenticator("token")).
+		Validate()
+	var results []ValidationResult
+	for _, result := range report.Results {
+		if result.Check == CheckHandleAllSecuritySchemes {
+			results = append(results, result)
+		}
+	}
+	require.Len(t, results, 4)
+	for _, result := range results {
+		assert.Equal(t, ErrorSeverity, result.Severity)
+		// the source position of the handlers implemented by okOperationsRouter
+		assert.Contains(t, result.File, "helpers_test.go")
+	}
+	assert.Equal(t, "and", results[0].OperationID)
+	assert.Equal(t, missingAuthenticatorForSecurityScheme("apiKey", "and"), results[0].Message)
+	assert.Equal(t, "or", results[1].OperationID)
+	assert.Equal(t, missingAuthenticatorForSecurityScheme("apiKey", "or"), results[1].Message)
+	assert.Equal(t, missingAuthenticatorForSecurityScheme("oauth", "or"), results[2].Message)
+	assert.Equal(t, missingAuthenticatorForSecurityScheme("apiKey", "optional"), results[3].Message)
+}
+
+func TestSecurityIgnoreMissingAuthenticator(t *testing.T) {
+	options := DefaultTestOptions()
+	options.HandleAllSecuritySchemes = Ignore
+	handler, err := securityTestRouter(options).
+		WithAuthenticator("bearer", credentialAuthenticator("token")).
+		AsHandler()
+	require.NoError(t, err)
+
+	// security schemes without an authenticator are left to the application to authenticate
+	assertSecurityStatuses(t, handler, []securityStatusTestCase{
+		{path: "/and", status: 401},
+		{path: "/and", headers: map[string]string{"Authorization": "Bearer token"}, status: 200},
+		{path: "/or", status: 200},
+		{path: "/global", status: 401},
+		{path: "/global", headers: map[string]string{"Authorization": "Bearer token"}, status: 200},
+		{path: "/optional", status: 200},
+		{path: "/public", status: 200},
+	})
+}
+
+func TestSecurityWarnMissingAuthenticator(t *testing.T) {
+	options := DefaultTestOptions()
+	options.HandleAllSecuritySchemes = PrintWarning
+	handler, err := securityTestRouter(options).
+		WithAuthenticator("bearer", credentialAuthenticator("token")).
+		AsHandler()
+	require.NoError(t, err)
+
+	// security requirements with a security scheme without an authenticator are never satisfied
+	assertSecurityStatuses(t, handler, []securityStatusTestCase{
+		{path: "/and", status: 401},
+		{path: "/and", headers: map[string]string{"Authorization": "Bearer token", "X-API-Key": "key"}, status: 401},
+		{path: "/or", headers: map[string]string{"X-API-Key": "key"}, status: 401},
+		{path: "/global", status: 401},
+		{path: "/global", headers: map[string]string{"Authorization": "Bearer token"}, status: 200},
+		{path: "/optional", status: 200},
+		{path: "/public", status: 200},
+	})
+}
+
+type securityStatusTestCase struct {
+	path    string
+	headers map[string]string
+	status  int
+}
+
+func assertSecurityStatuses(t *testing.T, handler http.Handler, testCases []securityStatusTestCase) {
+	for _, testCase := range testCases {
+		request := httptest.NewRequest(http.MethodGet, testCase.path, nil)
+		for key, value := range testCase.headers {
+			request.Header.Set(key, value)
+		}
+		writer := httptest.NewRecorder()
+		handler.ServeHTTP(writer, request)
+		assert.Equalf(t, testCase.status, writer.Code, "%s %v", testCase.path, testCase.headers)
+	}
+}
+
+func TestSecurityUnauthorizedChallenges(t *testing.T) {
+	handler, err := securityTestRouter(DefaultTestOptions()).
+		WithAuthenticator("bearer", credentialAuthenticator("token")).
+		WithAuthenticator("apiKey", credentialAuthenticator("key")).
+		WithAuthenticator("oauth", credentialAuthenticator("oauth-token", "read")).
+		AsHandler()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		path       string
+		challenges []string
+	}{
+		{path: "/global", challenges: []string{"Bearer"}},
+		{path: "/and", challenges: []string{`APIKey in="header", name="X-API-Key"`, "Bearer"}},
+		{path: "/or", challenges: []string{`APIKey in="header", name="X-API-Key"`, `Bearer scope="read"`}},
+	}
+	for _, testCase := range testCases {
+		writer := httptest.NewRecorder()
+		handler.ServeHTTP(writer, httptest.NewRequest(http.MethodGet, testCase.path, nil))
+		assert.Equal(t, 401, writer.Code)
+		assert.Equal(t, testCase.challenges, writer.Header().Values("WWW-Authenticate"), testCase.path)
+	}
+
+	assert.Equal(t, []string{`Basic realm="basic"`}, authenticationChallenges([]securityRequirement{{
+		{SecurityScheme: SecurityScheme{Name: "basic", Scheme: &openapi3.SecurityScheme{Type: "http", Scheme: "basic"}}},
+	}}))
+}
+
+func TestAuthenticatorForNonExistingSecurityScheme(t *testing.T) {
+	_, err := securityTestRouter(DefaultTestOptions()).
+		WithAuthenticator("bearer", credentialAuthenticator("token")).
+		WithAuthenticator("apiKey", credentialAuthenticator("key")).
+		WithAuthenticator("oauth", credentialAuthenticator("oauth-token", "read")).
+		WithAuthenticator("unknown", credentialAuthenticator("")).
+		AsHandler()
+	require.Error(t, err)
+}
+
+func TestSecuritySchemeCredential(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/?key=query-key", nil)
+	request.Header.Set("X-API-Key", "header-key")
+	request.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	request.AddCookie(&http.Cookie{Name: "session", Value: "cookie-key"})
+
+	testCases := []struct {
+		scheme     openapi3.SecurityScheme
+		credential string
+		found      bool
+	}{
+		{scheme: openapi3.SecurityScheme{Type: "apiKey", In: "header", Name: "X-API-Key"}, credential: "header-key", found: true},
+		{scheme: openapi3.SecurityScheme{Type: "apiKey", In: "query", Name: "key"}, credential: "query-key", found: true},
+		{scheme: openapi3.SecurityScheme{Type: "apiKey", In: "cookie", Name: "session"}, credential: "cookie-key", found: true},
+		{scheme: openapi3.SecurityScheme{Type: "apiKey", In: "header", Name: "X-Other"}},
+		{scheme: openapi3.SecurityScheme{Type: "http", Scheme: "basic"}, credential: "dXNlcjpwYXNz", found: true},
+		{scheme: openapi3.SecurityScheme{Type: "http", Scheme: "bearer"}},
+		{scheme: openapi3.SecurityScheme{Type: "oauth2"}},
+	}
+	for _, testCase := range testCases {
+		scheme := testCase.scheme
+		credential, found := SecurityScheme{Name: "test", Scheme: &scheme}.Credential(request)
+		assert.Equal(t, testCase.credential, credential)
+		assert.Equal(t, testCase.found, found)
+	}
+}
+
+func TestPrincipal(t *testing.T) {
+	options := DefaultTestOptions()
+	options.MustHandleAllOperations = Ignore
+	handler, err := NewOpenAPIRouterWithOptions(securityTestSpec(), options).
+		WithAuthenticator("apiKey", func(ctx *Context, _ SecurityScheme) error {
+			// a principal attached by a requirement that is not satisfied is discarded
+			ctx.SetPrincipal(testPrincipal{id: "api-key"})
+			return errors.New("invalid credential")
+		}).
+		WithAuthenticator("oauth", credentialAuthenticator("oauth-token", "read", "write")).
+		WithOperation("or", HandlerFunc[Nil, Nil, Nil, OKResponse[string]](func(c *Context, _ Request[Nil, Nil, Nil]) (Response[OKResponse[string]], error) {
+			principal, ok := Principal[testPrincipal](c)
+			require.True(t, ok)
+			_, ok = Principal[string](c)
+			require.False(t, ok)
+			return SendOK(OKResponse[string]{OK: principal.id}), nil
+		})).
+		AsHandler()
+	require.NoError(t, err)
+
+	request := httptest.NewRequest(http.MethodGet, "/or", nil)
+	request.Header.Set("Authorization", "Bearer oauth-token")
+	writer := httptest.NewRecorder()
+	handler.ServeHTTP(writer, request)
+	assert.Equal(t, 200, writer.Code)
+	assert.Equal(t, `"oauth-token"`, writer.Body.String())
+}
+
+func TestPrincipalScopes(t *testing.T) {
+	testCases := []struct {
+		authenticator Authenticator
+		status        int
+	}{
+		{authenticator: credentialAuthenticator("oauth-token", "read"), status: 200},
+		{authenticator: credentialAuthenticator("oauth-token", "write"), status: 403},
+		{authenticator: credentialAuthenticator("oauth-token"), status: 403},
+		{authenticator: func(ctx *Context, _ SecurityScheme) error {
+			ctx.SetPrincipal("principal without scopes")
+			return nil
+		}, status: 403},
+		{authenticator: credentialAuthenticator("other-token", "read"), status: 401},
+	}
+	for _, testCase := range testCases {
+		handler, err := securityTestRouter(DefaultTestOptions()).
+			WithAuthenticator("bearer", credentialAuthenticator("token")).
+			WithAuthenticator("apiKey", credentialAuthenticator("key")).
+			WithAuthenticator("oauth", testCase.authenticator).
+			AsHandler()
+		require.NoError(t, err)
+
+		request := httptest.NewRequest(http.MethodGet, "/or", nil)
+		request.Header.Set("Authorization", "Bearer oauth-token")
+		writer := httptest.NewRecorder()
+		handler.ServeHTTP(writer, request)
+		assert.Equal(t, testCase.status, writer.Code)
+		if testCase.status == 403 {
+			assert.Equal(t, "Forbidden", writer.Body.String())
+			assert.Equal(t, `Bearer error="insufficient_scope", scope="read"`, writer.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func TestAuthenticateInsufficientScope(t *testing.T) {
+	oauthScheme := &openapi3.SecurityScheme{Type: "oauth2"}
+	requirements := []securityRequirement{
+		{{SecurityScheme: SecurityScheme{Name: "apiKey", Scheme: &openapi3.SecurityScheme{Type: "apiKey"}}, authenticator: credentialAuthenticator("key")}},
+		{{SecurityScheme: SecurityScheme{Name: "oauth", Scheme: oauthScheme, Scopes: []string{"read", "write"}}, authenticator: credentialAuthenticator("token", "read")}},
+	}
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("Authorization", "Bearer token")
+	ctx := testContext()
+	ctx.Request = request
+
+	err := authenticate(ctx, requirements)
+	var forbiddenErr ForbiddenErr
+	require.ErrorAs(t, err, &forbiddenErr)
+	assert.Equal(t, []string{"write"}, forbiddenErr.Scopes)
+	var scopeErr InsufficientScopeErr
+	require.ErrorAs(t, err, &scopeErr)
+	assert.Equal(t, []string{"write"}, scopeErr.Scopes)
+	assert.Nil(t, ctx.principal)
+
+	request.Header.Set("Authorization", "Bearer other")
+	err = authenticate(ctx, requirements)
+	require.ErrorAs(t, err, new(UnauthorizedErr))
+}
@@ -0,0 +1,232 @@
+package router
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/invopop/yaml"
+
+	"github.com/piiano/cellotape/router/utils"
+)
+
+const (
+	defaultSpecJSONPath = "/openapi.json"
+	defaultSpecYAMLPath = "/openapi.yaml"
+	defaultSpecDocsPath = "/docs"
+	internalExtension   = "x-internal"
+	componentsRefPrefix = "#/components/"
+)
+
+//go:embed docs.html
+var docsPageTemplate string
+
+var docsPage = template.Must(template.New("docs").Parse(docsPageTemplate))
+
+// specOperationMethods are the keys of the operations of a path item in the spec document.
+var specOperationMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// registerSpecRoutes registers the routes serving the spec as JSON and YAML documents and the docs page.
+func registerSpecRoutes(oa openapi, router *routesHandler) error {
+	options := *oa.options.ServeSpec
+	document, err := oa.servedSpecDocument(options.StripInternalOperations)
+	if err != nil {
+		return err
+	}
+	jsonDocument, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return err
+	}
+	yamlDocument, err := yaml.JSONToYAML(jsonDocument)
+	if err != nil {
+		return err
+	}
+	jsonPath := defaultPath(options.JSONPath, defaultSpecJSONPath)
+	specRoutes := map[string]RouteHandler{
+		jsonPath: staticRouteHandler("application/json", jsonDocument),
+		defaultPath(options.YAMLPath, defaultSpecYAMLPath): staticRouteHandler("application/yaml", yamlDocument),
+	}
+	if !options.DisableDocs {
+		var docs bytes.Buffer
+		title := "API"
+		if oa.spec.Info != nil && oa.spec.Info.Title != "" {
+			title = oa.spec.Info.Title
+		}
+		if err = docsPage.Execute(&docs, map[string]string{"Title": title, "SpecPath": jsonPath}); err != nil {
+			return err
+		}
+		specRoutes[defaultPath(options.DocsPath, defaultSpecDocsPath)] = staticRouteHandler("text/html; charset=utf-8", docs.Bytes())
+	}
+	paths := utils.Keys(specRoutes)
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err = router.handle(http.MethodGet, path, specRoutes[path]); err != nil {
+			return fmt.Errorf("failed serving the spec on %s: %w", path, err)
+		}
+		oa.logger().Infof("serve the spec on GET %s", path)
+	}
+	return nil
+}
+
+// servedSpecDocument returns the spec as a generic JSON document.
+// When stripInternal is true, the operations marked with x-internal and the excluded operations are removed, with the
+// components that only the removed operations referenced.
+func (oa openapi) servedSpecDocument(stripInternal bool) (map[string]any, error) {
+	spec := openapi3.T(oa.spec)
+	data, err := spec.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var document map[string]any
+	if err = json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	if !stripInternal {
+		return document, nil
+	}
+	referencedBefore := referencedComponents(document)
+	excludeOperations := utils.NewSet(oa.options.ExcludeOperations...)
+	paths, _ := document["paths"].(map[string]any)
+	for path, value := range paths {
+		pathItem, ok := value.(map[string]any)
+		if !ok {
+			continue
+		}
+		if pathItem[internalExtension] == true {
+			delete(paths, path)
+			continue
+		}
+		operations := 0
+		for _, method := range specOperationMethods {
+			operation, ok := pathItem[method].(map[string]any)
+			if !ok {
+				continue
+			}
+			if operationID, _ := operation["operationId"].(string); operation[internalExtension] == true || excludeOperations.Has(operationID) {
+				delete(pathItem, method)
+				continue
+			}
+			operations++
+		}
+		if operations == 0 {
+			delete(paths, path)
+		}
+	}
+	removeUnreferencedComponents(document, referencedBefore)
+	return document, nil
+}
+
+// componentRef is a component of the spec document referenced with a $ref or by a security requirement.
+type componentRef struct {
+	section string
+	name    string
+}
+
+// referencedComponents returns the components referenced from the document outside its components, and the
+// components referenced by them.
+func referencedComponents(document map[string]any) utils.Set[componentRef] {
+	refs := make([]componentRef, 0)
+	for key, value := range document {
+		if key != "components" {
+			collectComponentRefs(value, &refs)
+		}
+	}
+	if security, ok := document["security"].([]any); ok {
+		collectSecuritySchemeRefs(security, &refs)
+	}
+	components, _ := document["components"].(map[string]any)
+	referenced := utils.NewSet[componentRef]()
+	for len(refs) > 0 {
+		ref := refs[len(refs)-1]
+		refs = refs[:len(refs)-1]
+		if !referenced.Add(ref) {
+			continue
+		}
+		if section, ok := components[ref.section].(map[string]any); ok {
+			collectComponentRefs(section[ref.name], &refs)
+		}
+	}
+	return referenced
+}
+
+// collectComponentRefs appends the components referenced by the $refs and security requirements in the value.
+func collectComponentRefs(value any, refs *[]componentRef) {
+	switch typedValue := value.(type) {
+	case map[string]any:
+		if ref, ok := typedValue["$ref"].(string); ok && strings.HasPrefix(ref, componentsRefPrefix) {
+			if section, name, found := strings.Cut(ref[len(componentsRefPrefix):], "/"); found {
+				*refs = append(*refs, componentRef{section: section, name: unescapeJSONPointer(name)})
+			}
+		}
+		if security, ok := typedValue["security"].([]any); ok {
+			collectSecuritySchemeRefs(security, refs)
+		}
+		for _, item := range typedValue {
+			collectComponentRefs(item, refs)
+		}
+	case []any:
+		for _, item := range typedValue {
+			collectComponentRefs(item, refs)
+		}
+	}
+}
+
+// collectSecuritySchemeRefs appends the security schemes required by the security requirements.
+func collectSecuritySchemeRefs(security []any, refs *[]componentRef) {
+	for _, requirement := range security {
+		if requirement, ok := requirement.(map[string]any); ok {
+			for name := range requirement {
+				*refs = append(*refs, componentRef{section: "securitySchemes", name: name})
+			}
+		}
+	}
+}
+
+// unescapeJSONPointer unescapes a reference token of a JSON pointer.
+func unescapeJSONPointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
+
+// removeUnreferencedComponents removes the components that were referenced before stripping operations from the
+// document and are no longer referenced. Components that were never referenced are kept.
+func removeUnreferencedComponents(document map[string]any, referencedBefore utils.Set[componentRef]) {
+	components, ok := document["components"].(map[string]any)
+	if !ok {
+		return
+	}
+	referenced := referencedComponents(document)
+	for ref := range referencedBefore {
+		if referenced.Has(ref) {
+			continue
+		}
+		if section, ok := components[ref.section].(map[string]any); ok {
+			delete(section, ref.name)
+			if len(section) == 0 {
+				delete(components, ref.section)
+			}
+		}
+	}
+}
+
+// defaultPath returns the path or the default path if it is empty.
+func defaultPath(path string, defaultPath string) string {
+	if path == "" {
+		return defaultPath
+	}
+	return path
+}
+
+// staticRouteHandler responds to every request with the content.
+func staticRouteHandler(contentType string, content []byte) RouteHandler {
+	return func(writer http.ResponseWriter, _ *http.Request, _ PathParams) {
+		writer.Header().Set("Content-Type", contentType)
+		writer.WriteHeader(http.StatusOK)
+		_, _ = writer.Write(content)
+	}
+}
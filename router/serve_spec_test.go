@@ -0,0 +1,225 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/invopop/yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/piiano/cellotape/router/utils"
+)
+
+// serveSpecTestSpec returns a spec with internal operations, an internal path and an operation that is excluded.
+func serveSpecTestSpec() OpenAPISpec {
+	deleteTasks := testSpecOperation("deleteTasks")
+	deleteTasks.Extensions = map[string]any{"x-internal": true}
+	return newTestSpec("Tasks API",
+		openapi3.WithPath("/tasks", &openapi3.PathItem{Get: testSpecOperation("getTasks"), Delete: deleteTasks}),
+		openapi3.WithPath("/admin", &openapi3.PathItem{
+			Extensions: map[string]any{"x-internal": true},
+			Get:        testSpecOperation("getAdmin"),
+		}),
+		openapi3.WithPath("/legacy", &openapi3.PathItem{Get: testSpecOperation("getLegacy")}),
+	)
+}
+
+func serveSpecTestHandler(t *testing.T, serveSpec ServeSpecOptions) http.Handler {
+	options := DefaultTestOptions()
+	options.ServeSpec = &serveSpec
+	options.ExcludeOperations = []string{"getLegacy"}
+	handler, err := okOperationsRouter(serveSpecTestSpec(), options, "getTasks", "deleteTasks", "getAdmin").AsHandler()
+	require.NoError(t, err)
+	return handler
+}
+
+func getServedSpec(t *testing.T, handler http.Handler, path string) (*httptest.ResponseRecorder, map[string]any) {
+	writer := httptest.NewRecorder()
+	handler.ServeHTTP(writer, httptest.NewRequest(http.MethodGet, path, nil))
+	require.Equal(t, 200, writer.Code)
+	data := writer.Body.Bytes()
+	if writer.Header().Get("Content-Type") == "application/yaml" {
+		var err error
+		data, err = yaml.YAMLToJSON(data)
+		require.NoError(t, err)
+	}
+	var document map[string]any
+	require.NoError(t, json.Unmarshal(data, &document))
+	return writer, document
+}
+
+func TestServeSpec(t *testing.T) {
+	handler := serveSpecTestHandler(t, ServeSpecOptions{})
+
+	writer, document := getServedSpec(t, handler, "/openapi.json")
+	assert.Equal(t, "application/json", writer.Header().Get("Content-Type"))
+	assert.Equal(t, "Tasks API", document["info"].(map[string]any)["title"])
+	assert.Len(t, document["paths"], 3)
+
+	writer, yamlDocument := getServedSpec(t, handler, "/openapi.yaml")
+	assert.Equal(t, "application/yaml", writer.Header().Get("Content-Type"))
+	assert.Equal(t, document, yamlDocument)
+
+	writer = httptest.NewRecorder()
+	handler.ServeHTTP(writer, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, 200, writer.Code)
+	assert.Equal(t, "text/html; charset=utf-8", writer.Header().Get("Content-Type"))
+	assert.Contains(t, writer.Body.String(), "<title>Tasks API</title>")
+	assert.Contains(t, writer.Body.String(), `const specPath = "/openapi.json";`)
+	assert.Contains(t, writer.Body.String(), "Try it out")
+	assert.NotContains(t, writer.Body.String(), "https://")
+}
+
+func TestServeSpecStripInternalOperations(t *testing.T) {
+	handler := serveSpecTestHandler(t, ServeSpecOptions{
+		JSONPath:                "/spec/openapi.json",
+		YAMLPath:                "/spec/openapi.yaml",
+		DisableDocs:             true,
+		StripInternalOperations: true,
+	})
+
+	_, document := getServedSpec(t, handler, "/spec/openapi.json")
+	paths := document["paths"].(map[string]any)
+	require.Len(t, paths, 1)
+	tasks := paths["/tasks"].(map[string]any)
+	assert.Contains(t, tasks, "get")
+	assert.NotContains(t, tasks, "delete")
+
+	_, yamlDocument := getServedSpec(t, handler, "/spec/openapi.yaml")
+	assert.Equal(t, document, yamlDocument)
+
+	writer := httptest.NewRecorder()
+	handler.ServeHTTP(writer, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, 404, writer.Code)
+
+	// the served spec is rendered from a copy and the router spec is not modified
+	writer = httptest.NewRecorder()
+	handler.ServeHTTP(writer, httptest.NewRequest(http.MethodDelete, "/tasks", nil))
+	assert.Equal(t, 200, writer.Code)
+}
+
+func TestServeSpecConflictingPath(t *testing.T) {
+	options := DefaultTestOptions()
+	options.MustHandleAllOperations = Ignore
+	options.ServeSpec = &ServeSpecOptions{JSONPath: "/tasks"}
+	_, err := okOperationsRouter(serveSpecTestSpec(), options, "getTasks").AsHandler()
+	require.Error(t, err)
+}
+
+func TestServeSpecWithExternalRefs(t *testing.T) {
+	spec, err := NewSpecFromFS(specsFS, "test_specs/split/openapi.yaml")
+	require.NoError(t, err)
+	options := DefaultTestOptions()
+	options.MustHandleAllOperations = Ignore
+	options.ServeSpec = &ServeSpecOptions{}
+	handler, err := NewOpenAPIRouterWithOptions(spec, options).AsHandler()
+	require.NoError(t, err)
+
+	writer := httptest.NewRecorder()
+	handler.ServeHTTP(writer, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, 200, writer.Code)
+	assert.NotContains(t, writer.Body.String(), ".yaml")
+
+	// the served spec is self-contained
+	servedSpec, err := NewSpecFromData(writer.Body.Bytes())
+	require.NoError(t, err)
+	specOp, ok := servedSpec.findSpecOperationByID("getTask")
+	require.True(t, ok)
+	require.Len(t, specOp.Parameters, 1)
+	assert.Equal(t, "id", specOp.Parameters[0].Value.Name)
+	schema := specOp.Responses.Value("200").Value.Content.Get("application/json").Schema.Value
+	assert.Contains(t, schema.Properties["owner"].Value.Properties, "name")
+}
+
+func TestServeSpecStripUnreferencedComponents(t *testing.T) {
+	spec, err := NewSpecFromData([]byte(`openapi: 3.0.3
+info:
+  title: Tasks API
+  version: 1.0.0
+paths:
+  /tasks:
+    get:
+      operationId: getTasks
+      responses:
+        "200":
+          $ref: "#/components/responses/Tasks"
+  /admin:
+    get:
+      operationId: getAdmin
+      x-internal: true
+      security:
+        - adminKey: []
+      parameters:
+        - $ref: "#/components/parameters/Tenant"
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Admin"
+components:
+  securitySchemes:
+    adminKey:
+      type: apiKey
+      in: header
+      name: X-Admin-Key
+  parameters:
+    Tenant:
+      name: tenant
+      in: query
+      schema:
+        $ref: "#/components/schemas/TenantID"
+  responses:
+    Tasks:
+      description: ok
+      content:
+        application/json:
+          schema:
+            $ref: "#/components/schemas/Task"
+  schemas:
+    Task:
+      type: object
+      properties:
+        owner:
+          $ref: "#/components/schemas/User"
+    User:
+      type: object
+    Admin:
+      type: object
+      properties:
+        user:
+          $ref: "#/components/schemas/User"
+        details:
+          $ref: "#/components/schemas/AdminDetails"
+    AdminDetails:
+      type: object
+    TenantID:
+      type: string
+    Standalone:
+      type: object
+`))
+	require.NoError(t, err)
+	oa := openapi{spec: spec, options: DefaultOptions()}
+
+	document, err := oa.servedSpecDocument(false)
+	require.NoError(t, err)
+	components := document["components"].(map[string]any)
+	assert.Len(t, components["schemas"], 6)
+	assert.Contains(t, components, "securitySchemes")
+
+	document, err = oa.servedSpecDocument(true)
+	require.NoError(t, err)
+	components = document["components"].(map[string]any)
+	assert.ElementsMatch(t, []string{"Task", "User", "Standalone"}, utils.Keys(components["schemas"].(map[string]any)))
+	assert.Contains(t, components["responses"], "Tasks")
+	assert.NotContains(t, components, "parameters")
+	assert.NotContains(t, components, "securitySchemes")
+
+	// the router spec is not modified
+	assert.Len(t, spec.Components.Schemas, 6)
+}
@@ -0,0 +1,97 @@
+package router
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/piiano/cellotape/router/utils"
+)
+
+// operationBasePaths returns the base paths the operation is registered under.
+// Operations are registered at the root unless Options.UseServersBasePaths is set.
+func (oa openapi) operationBasePaths(specOp SpecOperation) ([]string, error) {
+	if !oa.options.UseServersBasePaths {
+		return []string{""}, nil
+	}
+	return serversBasePaths(oa.spec.operationServers(specOp))
+}
+
+// operationServers returns the effective servers of the operation.
+// Servers defined on the operation override the servers of its path item, which override the servers of the spec.
+func (s OpenAPISpec) operationServers(specOp SpecOperation) openapi3.Servers {
+	if specOp.Operation != nil && specOp.Servers != nil && len(*specOp.Servers) > 0 {
+		return *specOp.Servers
+	}
+	if s.Paths != nil {
+		if pathItem := s.Paths.Value(specOp.Path); pathItem != nil && len(pathItem.Servers) > 0 {
+			return pathItem.Servers
+		}
+	}
+	return s.Servers
+}
+
+// serversBasePaths returns the distinct base paths of the servers URLs.
+// Without servers, the base path is the root as defined by the OpenAPI specification.
+func serversBasePaths(servers openapi3.Servers) ([]string, error) {
+	if len(servers) == 0 {
+		return []string{""}, nil
+	}
+	basePaths := make([]string, 0, len(servers))
+	found := utils.NewSet[string]()
+	for _, server := range servers {
+		serverPaths, err := serverBasePaths(server)
+		if err != nil {
+			return nil, err
+		}
+		for _, basePath := range serverPaths {
+			if found.Add(basePath) {
+				basePaths = append(basePaths, basePath)
+			}
+		}
+	}
+	return basePaths, nil
+}
+
+// serverBasePaths returns the base paths of the server URL for every value of its variables.
+// Variables with an enum are expanded to all the enum values, and other variables are expanded to their default value.
+// The root base path is returned as an empty string and other base paths are returned without a trailing slash.
+func serverBasePaths(server *openapi3.Server) ([]string, error) {
+	names, err := server.ParameterNames()
+	if err != nil {
+		return nil, fmt.Errorf("invalid server url %q: %w", server.URL, err)
+	}
+	urls := []string{server.URL}
+	for _, name := range names {
+		variable, found := server.Variables[name]
+		if !found || variable == nil {
+			return nil, fmt.Errorf("invalid server url %q: variable %q is not defined", server.URL, name)
+		}
+		values := variable.Enum
+		if len(values) == 0 {
+			values = []string{variable.Default}
+		}
+		expandedURLs := make([]string, 0, len(urls)*len(values))
+		for _, serverURL := range urls {
+			for _, value := range values {
+				expandedURLs = append(expandedURLs, strings.ReplaceAll(serverURL, "{"+name+"}", value))
+			}
+		}
+		urls = expandedURLs
+	}
+	basePaths := make([]string, 0, len(urls))
+	for _, serverURL := range urls {
+		parsedURL, err := url.Parse(serverURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid server url %q: %w", server.URL, err)
+		}
+		basePath := strings.TrimSuffix(parsedURL.Path, "/")
+		if basePath != "" && !strings.HasPrefix(basePath, "/") {
+			basePath = "/" + basePath
+		}
+		basePaths = append(basePaths, basePath)
+	}
+	return basePaths, nil
+}
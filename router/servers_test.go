@@ -0,0 +1,199 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServersBasePaths(t *testing.T) {
+	testCases := []struct {
+		servers   openapi3.Servers
+		basePaths []string
+	}{
+		{servers: nil, basePaths: []string{""}},
+		{servers: openapi3.Servers{{URL: "/"}}, basePaths: []string{""}},
+		{servers: openapi3.Servers{{URL: "https://api.example.com"}}, basePaths: []string{""}},
+		{servers: openapi3.Servers{{URL: "https://api.example.com/v2/"}}, basePaths: []string{"/v2"}},
+		{servers: openapi3.Servers{{URL: "v2"}}, basePaths: []string{"/v2"}},
+		{
+			servers:   openapi3.Servers{{URL: "https://api.example.com/v2"}, {URL: "http://localhost:8080/v2"}, {URL: "/v1"}},
+			basePaths: []string{"/v2", "/v1"},
+		},
+		{
+			servers: openapi3.Servers{{
+				URL: "https://{env}.example.com/{version}/api",
+				Variables: map[string]*openapi3.ServerVariable{
+					"env":     {Default: "api", Enum: []string{"api", "staging"}},
+					"version": {Default: "v2", Enum: []string{"v1", "v2"}},
+				},
+			}},
+			basePaths: []string{"/v1/api", "/v2/api"},
+		},
+		{
+			servers: openapi3.Servers{{
+				URL:       "/{basePath}",
+				Variables: map[string]*openapi3.ServerVariable{"basePath": {Default: "v3"}},
+			}},
+			basePaths: []string{"/v3"},
+		},
+	}
+	for _, testCase := range testCases {
+		basePaths, err := serversBasePaths(testCase.servers)
+		require.NoError(t, err)
+		assert.Equal(t, testCase.basePaths, basePaths)
+	}
+}
+
+func TestServersBasePathsUndefinedVariable(t *testing.T) {
+	_, err := serversBasePaths(openapi3.Servers{{URL: "/{version}"}})
+	require.Error(t, err)
+}
+
+const serversTestSpec = `openapi: 3.0.3
+info:
+  title: test
+  version: 1.0.0
+servers:
+  - url: https://api.example.com/{version}
+    variables:
+      version:
+        default: v2
+        enum: [v1, v2]
+paths:
+  /greet:
+    get:
+      operationId: greet
+      responses:
+        "200":
+          description: ok
+`
+
+func TestUseServersBasePaths(t *testing.T) {
+	spec, err := NewSpecFromData([]byte(serversTestSpec))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		useServersBasePaths bool
+		path                string
+		status              int
+	}{
+		{useServersBasePaths: false, path: "/greet", status: 200},
+		{useServersBasePaths: false, path: "/v2/greet", status: 404},
+		{useServersBasePaths: true, path: "/greet", status: 404},
+		{useServersBasePaths: true, path: "/v1/greet", status: 200},
+		{useServersBasePaths: true, path: "/v2/greet", status: 200},
+	}
+	for _, testCase := range testCases {
+		options := DefaultTestOptions()
+		options.UseServersBasePaths = testCase.useServersBasePaths
+		handler, err := NewOpenAPIRouterWithOptions(spec, options).
+			WithOperation("greet", HandlerFunc[Nil, Nil, Nil, OKResponse[Nil]](func(_ *Context, _ Request[Nil, Nil, Nil]) (Response[OKResponse[Nil]], error) {
+				return SendOK(OKResponse[Nil]{}), nil
+			})).
+			AsHandler()
+		require.NoError(t, err)
+
+		writer := httptest.NewRecorder()
+		handler.ServeHTTP(writer, httptest.NewRequest(http.MethodGet, testCase.path, nil))
+		assert.Equalf(t, testCase.status, writer.Code, "%s with UseServersBasePaths=%t", testCase.path, testCase.useServersBasePaths)
+	}
+}
+
+const operationServersTestSpec = `openapi: 3.0.3
+info:
+  title: test
+  version: 1.0.0
+servers:
+  - url: /v2
+paths:
+  /greet:
+    get:
+      operationId: greet
+      responses:
+        "200":
+          description: ok
+    post:
+      operationId: greetLegacy
+      servers:
+        - url: /v1
+      responses:
+        "200":
+          description: ok
+  /status:
+    servers:
+      - url: /
+      - url: /v2
+    get:
+      operationId: status
+      responses:
+        "200":
+          description: ok
+`
+
+func TestOperationServers(t *testing.T) {
+	spec, err := NewSpecFromData([]byte(operationServersTestSpec))
+	require.NoError(t, err)
+	options := DefaultTestOptions()
+	options.UseServersBasePaths = true
+	router := okOperationsRouter(spec, options, "greet", "greetLegacy", "status")
+	handler, err := router.AsHandler()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{method: http.MethodGet, path: "/v2/greet", status: 200},
+		{method: http.MethodGet, path: "/v1/greet", status: 405},
+		{method: http.MethodPost, path: "/v1/greet", status: 200},
+		{method: http.MethodPost, path: "/v2/greet", status: 405},
+		{method: http.MethodGet, path: "/status", status: 200},
+		{method: http.MethodGet, path: "/v2/status", status: 200},
+	}
+	for _, testCase := range testCases {
+		writer := httptest.NewRecorder()
+		handler.ServeHTTP(writer, httptest.NewRequest(testCase.method, testCase.path, nil))
+		assert.Equalf(t, testCase.status, writer.Code, "%s %s", testCase.method, testCase.path)
+	}
+}
+
+const conflictingServersTestSpec = `openapi: 3.0.3
+info:
+  title: test
+  version: 1.0.0
+paths:
+  /v1/tasks/archived:
+    get:
+      operationId: getArchivedTasks
+      responses:
+        "200":
+          description: ok
+  /tasks/archived:
+    get:
+      operationId: getLegacyArchivedTasks
+      servers:
+        - url: /v1
+      responses:
+        "200":
+          description: ok
+`
+
+func TestConflictingOperationServers(t *testing.T) {
+	spec, err := NewSpecFromData([]byte(conflictingServersTestSpec))
+	require.NoError(t, err)
+	options := DefaultTestOptions()
+	options.UseServersBasePaths = true
+	router := okOperationsRouter(spec, options, "getArchivedTasks", "getLegacyArchivedTasks")
+	report, err := router.Validate()
+	require.Error(t, err)
+	require.Len(t, report.Results, 1)
+	assert.Equal(t, CheckRoutes, report.Results[0].Check)
+	assert.Equal(t, "getLegacyArchivedTasks", report.Results[0].OperationID)
+	assert.Equal(t, conflictingRoutes("GET", "/v1/tasks/archived", "getLegacyArchivedTasks", "/v1/tasks/archived", "getArchivedTasks"), report.Results[0].Message)
+}
@@ -1,18 +1,34 @@
 package router
 
 import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"strings"
+
 	"github.com/getkin/kin-openapi/openapi3"
 
+	"github.com/piiano/cellotape/router/schema_validator"
 	"github.com/piiano/cellotape/router/utils"
 )
 
 type OpenAPISpec openapi3.T
 
+// NewSpecFromFile loads the spec from the file at the path.
+// External $refs to other files are resolved relative to the file that references them and are bundled to the
+// components of the spec, so the spec is self-contained when it is served.
+// Only local files are read. Loading a spec with an http(s) $ref fails instead of fetching it over the network.
 func NewSpecFromFile(path string) (OpenAPISpec, error) {
-	spec, err := openapi3.NewLoader().LoadFromFile(path)
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	loader.ReadFromURIFunc = openapi3.ReadFromFile
+	spec, err := loader.LoadFromFile(path)
 	if err != nil {
 		return OpenAPISpec{}, err
 	}
+	spec.InternalizeRefs(context.Background(), nil)
 
 	return OpenAPISpec(*spec), nil
 }
@@ -25,6 +41,44 @@ func NewSpecFromData(data []byte) (OpenAPISpec, error) {
 	return OpenAPISpec(*spec), nil
 }
 
+// NewSpecFromFS loads the spec from the file at the path in the file system (e.g. an embed.FS).
+// External $refs to other files are resolved relative to the file that references them and are read from the same
+// file system, so a spec split to multiple files can be embedded as a directory.
+// Like with NewSpecFromFile, the external $refs are bundled to the components of the spec.
+func NewSpecFromFS(fsys fs.FS, path string) (OpenAPISpec, error) {
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	files := make([]string, 0)
+	loader.ReadFromURIFunc = readFromFS(fsys, &files)
+	spec, err := loader.LoadFromURI(&url.URL{Path: path})
+	if err != nil {
+		// the loader errors don't tell which $ref is broken, so look for it in the files that were read
+		if refErr := findBrokenRef(fsys, files); refErr != nil {
+			return OpenAPISpec{}, refErr
+		}
+		return OpenAPISpec{}, err
+	}
+	spec.InternalizeRefs(context.Background(), nil)
+	return OpenAPISpec(*spec), nil
+}
+
+// readFromFS returns an openapi3.ReadFromURIFunc that reads the files of the spec from the file system and appends
+// their paths to files.
+func readFromFS(fsys fs.FS, files *[]string) openapi3.ReadFromURIFunc {
+	return func(_ *openapi3.Loader, location *url.URL) ([]byte, error) {
+		if location.Scheme != "" || location.Host != "" {
+			return nil, fmt.Errorf("failed reading %s: %w", location, openapi3.ErrURINotSupported)
+		}
+		file := path.Clean(strings.TrimPrefix(location.Path, "/"))
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading %s: %w", file, err)
+		}
+		*files = append(*files, file)
+		return data, nil
+	}
+}
+
 func NewSpec() OpenAPISpec {
 	spec, _ := NewSpecFromData([]byte("{}"))
 	return spec
@@ -56,6 +110,12 @@ func (s *OpenAPISpec) findSpecOperationByID(id string) (SpecOperation, bool) {
 	return SpecOperation{}, false
 }
 
+// operationJSONPointer returns the JSON pointer of the operation with the id in the spec.
+func (s *OpenAPISpec) operationJSONPointer(id string) string {
+	specOp, _ := s.findSpecOperationByID(id)
+	return schema_validator.JoinJSONPointer("#", "paths", specOp.Path, strings.ToLower(specOp.Method))
+}
+
 // findSpecContentTypes find all content types declared in the spec for both request body and responses
 func (s *OpenAPISpec) findSpecContentTypes(excludeOperations utils.Set[string]) []string {
 	contentTypes := make([]string, 0)
@@ -0,0 +1,149 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/invopop/yaml"
+)
+
+// BrokenRefErr is returned when loading a spec from a file system with a $ref that can't be resolved.
+type BrokenRefErr struct {
+	// File is the path of the file with the broken $ref.
+	File string
+	// Pointer is the JSON pointer of the $ref in the file.
+	Pointer string
+	// Ref is the value of the broken $ref.
+	Ref string
+	Err error
+}
+
+func (e BrokenRefErr) Error() string {
+	return fmt.Sprintf("%s#%s: failed resolving $ref %q: %s", e.File, e.Pointer, e.Ref, e.Err)
+}
+
+func (e BrokenRefErr) Unwrap() error {
+	return e.Err
+}
+
+// refsResolver resolves the $refs of spec files in a file system.
+type refsResolver struct {
+	fsys      fs.FS
+	documents map[string]any
+}
+
+// findBrokenRef returns a BrokenRefErr for the first $ref in the files that can't be resolved.
+// Returns nil if all the $refs are resolved.
+func findBrokenRef(fsys fs.FS, files []string) error {
+	resolver := refsResolver{fsys: fsys, documents: make(map[string]any)}
+	for _, file := range files {
+		document, err := resolver.document(file)
+		if err != nil {
+			return err
+		}
+		if err = resolver.checkRefs(file, "", document); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// document returns the parsed content of the YAML or JSON file.
+func (r refsResolver) document(file string) (any, error) {
+	if document, ok := r.documents[file]; ok {
+		return document, nil
+	}
+	data, err := fs.ReadFile(r.fsys, file)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading %s: %w", file, err)
+	}
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing %s: %w", file, err)
+	}
+	var document any
+	if err = json.Unmarshal(jsonData, &document); err != nil {
+		return nil, fmt.Errorf("failed parsing %s: %w", file, err)
+	}
+	r.documents[file] = document
+	return document, nil
+}
+
+// checkRefs resolves the $refs of the value at the pointer in the file and its nested values.
+func (r refsResolver) checkRefs(file string, pointer string, value any) error {
+	switch value := value.(type) {
+	case map[string]any:
+		if ref, ok := value["$ref"].(string); ok {
+			if err := r.resolve(file, ref); err != nil {
+				return BrokenRefErr{File: file, Pointer: pointer + "/$ref", Ref: ref, Err: err}
+			}
+		}
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			escaped := strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+			if err := r.checkRefs(file, pointer+"/"+escaped, value[key]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for i, item := range value {
+			if err := r.checkRefs(file, pointer+"/"+strconv.Itoa(i), item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolve returns an error if the $ref in the file doesn't reference an existing value.
+// References to remote URLs are not resolved.
+func (r refsResolver) resolve(file string, ref string) error {
+	location, err := url.Parse(ref)
+	if err != nil {
+		return err
+	}
+	if location.Scheme != "" || location.Host != "" {
+		return nil
+	}
+	target := file
+	if location.Path != "" {
+		target = path.Join(path.Dir(file), location.Path)
+	}
+	value, err := r.document(target)
+	if err != nil {
+		return err
+	}
+	if location.Fragment == "" {
+		return nil
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(location.Fragment, "/"), "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch current := value.(type) {
+		case map[string]any:
+			next, ok := current[token]
+			if !ok {
+				return fmt.Errorf("%q not found in %s", token, target)
+			}
+			value = next
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(current) {
+				return fmt.Errorf("%q not found in %s", token, target)
+			}
+			value = current[i]
+		default:
+			return fmt.Errorf("%q not found in %s", token, target)
+		}
+	}
+	return nil
+}
@@ -2,7 +2,12 @@ package router
 
 import (
 	"bytes"
-	_ "embed"
+	"embed"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/getkin/kin-openapi/openapi3"
@@ -15,6 +20,9 @@ import (
 //go:embed test_specs/openapi.yaml
 var specData []byte
 
+//go:embed test_specs
+var specsFS embed.FS
+
 func TestNewSpec(t *testing.T) {
 	spec := NewSpec()
 	assert.NotNil(t, spec)
@@ -38,6 +46,74 @@ func TestNewSpecFromFile(t *testing.T) {
 	require.NotNil(t, err)
 }
 
+func TestNewSpecFromFileWithExternalRefs(t *testing.T) {
+	spec, err := NewSpecFromFile("test_specs/split/openapi.yaml")
+	require.NoError(t, err)
+	_, ok := spec.findSpecOperationByID("getTask")
+	assert.True(t, ok)
+}
+
+func TestNewSpecFromFileWithRemoteRef(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		requests++
+		_, _ = writer.Write([]byte("type: string"))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "openapi.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`openapi: 3.0.3
+info:
+  title: remote ref
+  version: 1.0.0
+paths:
+  /tasks:
+    get:
+      operationId: getTasks
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: "`+server.URL+`/schema.yaml"
+`), 0o600))
+
+	_, err := NewSpecFromFile(path)
+	require.ErrorIs(t, err, openapi3.ErrURINotSupported)
+	assert.Zero(t, requests)
+}
+
+func TestNewSpecFromFS(t *testing.T) {
+	spec, err := NewSpecFromFS(specsFS, "test_specs/split/openapi.yaml")
+	require.NoError(t, err)
+	specOp, ok := spec.findSpecOperationByID("getTask")
+	require.True(t, ok)
+	require.Len(t, specOp.Parameters, 1)
+	assert.Equal(t, "id", specOp.Parameters[0].Value.Name)
+	schema := specOp.Responses.Value("200").Value.Content.Get("application/json").Schema.Value
+	assert.Contains(t, schema.Properties, "id")
+	assert.Contains(t, schema.Properties["owner"].Value.Properties, "name")
+
+	_, err = NewSpecFromFS(specsFS, "test_specs/no_such_file.yaml")
+	require.Error(t, err)
+}
+
+func TestNewSpecFromFSBrokenRef(t *testing.T) {
+	_, err := NewSpecFromFS(specsFS, "test_specs/broken_ref/openapi.yaml")
+	var brokenRefErr BrokenRefErr
+	require.True(t, errors.As(err, &brokenRefErr))
+	assert.Equal(t, "test_specs/broken_ref/openapi.yaml", brokenRefErr.File)
+	assert.Equal(t, "/paths/~1tasks/get/responses/200/content/application~1json/schema/$ref", brokenRefErr.Pointer)
+	assert.Equal(t, "schemas.yaml#/Tasks", brokenRefErr.Ref)
+
+	_, err = NewSpecFromFS(specsFS, "test_specs/broken_ref/missing_file.yaml")
+	require.True(t, errors.As(err, &brokenRefErr))
+	assert.Equal(t, "test_specs/broken_ref/missing_file.yaml", brokenRefErr.File)
+	assert.Equal(t, "/paths/~1tasks/$ref", brokenRefErr.Pointer)
+	assert.Equal(t, "paths/tasks.yaml", brokenRefErr.Ref)
+}
+
 func TestFindSpecOperationByIDFail(t *testing.T) {
 	spec, err := NewSpecFromData(bytes.NewBufferString("{}").Bytes())
 	require.NoError(t, err)
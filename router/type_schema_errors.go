@@ -0,0 +1,56 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/piiano/cellotape/router/schema_validator"
+)
+
+// TypeSchemaError is a structured error describing an incompatibility found by AsHandler between a type declared by a
+// handler and its schema in the spec.
+// Use errors.As on the error returned from AsHandler to inspect it programmatically.
+type TypeSchemaError struct {
+	schema_validator.SchemaError
+	// OperationID is the id of the operation the handler is registered for.
+	OperationID string
+	// Location describes where the type is used in the operation (e.g. "request body", "200 response").
+	Location string
+	// SourcePosition is the position of the handler function in the sources in the form of "file:line".
+	// It is empty if the position is unknown.
+	SourcePosition string
+}
+
+func (e TypeSchemaError) Error() string {
+	position := ""
+	if e.SourcePosition != "" {
+		position = fmt.Sprintf(" - %s", e.SourcePosition)
+	}
+	return fmt.Sprintf("operation %q %s%s: %s", e.OperationID, e.Location, position, e.SchemaError)
+}
+
+// Unwrap returns the underlying schema_validator.SchemaError.
+func (e TypeSchemaError) Unwrap() error {
+	return e.SchemaError
+}
+
+// TypeSchemaErrors are all the TypeSchemaError that failed the validation of AsHandler.
+// Use errors.As on the error returned from AsHandler to get all of them.
+type TypeSchemaErrors []TypeSchemaError
+
+func (e TypeSchemaErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Unwrap returns the errors as a slice to allow errors.As and errors.Is to match each one of them.
+func (e TypeSchemaErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
@@ -0,0 +1,83 @@
+package router
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/piiano/cellotape/router/schema_validator"
+	"github.com/piiano/cellotape/router/utils"
+)
+
+func TestAsHandlerTypeSchemaErrors(t *testing.T) {
+	type task struct {
+		Summary string `json:"summary"`
+		Status  int    `json:"status"`
+	}
+	type responses struct {
+		OK task `status:"200"`
+	}
+	fn := HandlerFunc[task, utils.Nil, utils.Nil, responses](func(*Context, Request[task, utils.Nil, utils.Nil]) (Response[responses], error) {
+		return SendOKJSON(responses{}), nil
+	})
+	spec, err := NewSpecFromData([]byte(`
+  { "openapi": "3.0.3", "info": { "title": "test", "version": "1.0.0" }, "paths": { "/tasks": { "post": {
+    "operationId": "createTask",
+    "requestBody": { "content": { "application/json": { "schema": { "$ref": "#/components/schemas/Task" } } } },
+    "responses":{ "200": { "description": "ok", "content": { "application/json": { "schema": {
+      "type": "object",
+      "properties": { "summary": { "type": "string" }, "status": { "type": "string" } }
+    } } } } }
+  } } },
+  "components": { "schemas": { "Task": {
+    "type": "object",
+    "properties": { "summary": { "type": "string" }, "status": { "type": "string" } }
+  } } } }`))
+	require.NoError(t, err)
+	options := DefaultOptions()
+	options.LogOutput = bytes.NewBuffer([]byte{})
+	_, err = NewOpenAPIRouterWithOptions(spec, options).WithOperation("createTask", fn).AsHandler()
+	require.Error(t, err)
+
+	var typeSchemaErrors TypeSchemaErrors
+	require.ErrorAs(t, err, &typeSchemaErrors)
+
+	requestBodyErrors := utils.Filter(typeSchemaErrors, func(e TypeSchemaError) bool {
+		return e.Location == "request body" && e.Rule == schema_validator.RuleProperties
+	})
+	require.Len(t, requestBodyErrors, 1)
+	assert.Equal(t, "createTask", requestBodyErrors[0].OperationID)
+	assert.Equal(t, "#/components/schemas/Task/properties/status", requestBodyErrors[0].SchemaPath)
+	assert.Equal(t, "router.task.Status", requestBodyErrors[0].TypePath)
+	assert.Contains(t, requestBodyErrors[0].SourcePosition, "type_schema_errors_test.go:")
+
+	responseErrors := utils.Filter(typeSchemaErrors, func(e TypeSchemaError) bool {
+		return e.Location == "200 response" && e.Rule == schema_validator.RuleProperties
+	})
+	require.Len(t, responseErrors, 1)
+	assert.Equal(t, "#/paths/~1tasks/post/responses/200/content/application~1json/schema/properties/status", responseErrors[0].SchemaPath)
+
+	var typeSchemaError TypeSchemaError
+	require.ErrorAs(t, err, &typeSchemaError)
+	var schemaError schema_validator.SchemaError
+	require.ErrorAs(t, err, &schemaError)
+}
+
+func TestTypeSchemaErrorMessage(t *testing.T) {
+	err := TypeSchemaError{
+		SchemaError: schema_validator.SchemaError{
+			SchemaPath: "#/components/schemas/Task/properties/status",
+			TypePath:   "models.Task.Status",
+			Rule:       schema_validator.RuleType,
+			Message:    "string schema is incompatible with type int",
+		},
+		OperationID:    "createTask",
+		Location:       "request body",
+		SourcePosition: "handlers.go:10",
+	}
+	assert.Equal(t, `operation "createTask" request body - handlers.go:10: string schema is incompatible with type int (schema: #/components/schemas/Task/properties/status, type: models.Task.Status)`, err.Error())
+	assert.True(t, errors.Is(TypeSchemaErrors{err}, err))
+}
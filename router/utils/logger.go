@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"strings"
+
+	"github.com/invopop/jsonschema"
 )
 
 type LogLevel int
@@ -49,6 +51,11 @@ func (l *LogLevel) UnmarshalText(data []byte) error {
 	return nil
 }
 
+// JSONSchema describes log levels with their text values in the options schema.
+func (LogLevel) JSONSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{Type: "string", Enum: []any{"error", "warn", "info", "off"}}
+}
+
 // Logger act as a regular logger that counts logged errors and warnings.
 type Logger interface {
 	Log(LogLevel, any)
@@ -90,9 +97,21 @@ func NewLoggerWithLevel(out io.Writer, level LogLevel) Logger {
 	}
 }
 
+// NewLoggerWithHook creates a logger like NewLoggerWithLevel that also calls the hook with every logged message
+// regardless of the log level.
+// Loggers created with NewCounter share the same hook.
+func NewLoggerWithHook(out io.Writer, level LogLevel, hook func(LogLevel, string)) Logger {
+	return &logger{
+		output: out,
+		level:  level,
+		hook:   hook,
+	}
+}
+
 type logger struct {
 	output io.Writer
 	level  LogLevel
+	hook   func(LogLevel, string)
 	LogCounters
 }
 
@@ -140,9 +159,12 @@ func (l *logger) Logf(level LogLevel, format string, args ...any) {
 }
 func (l *logger) Log(level LogLevel, arg any) {
 	write := func(string) {}
-
+	message := strings.Trim(fmt.Sprint(arg), "\n")
+	if l.hook != nil {
+		l.hook(level, message)
+	}
 	if l.level != Off && l.level >= level {
-		write = func(levelStr string) { fmt.Fprintln(l.output, levelStr, strings.Trim(fmt.Sprint(arg), "\n")) }
+		write = func(levelStr string) { fmt.Fprintln(l.output, levelStr, message) }
 	}
 	switch level {
 	case Info:
@@ -164,7 +186,7 @@ func (l *logger) MustHaveNoErrors() error {
 }
 
 func (l *logger) NewCounter() Logger {
-	return &logger{output: l.output, level: l.level}
+	return &logger{output: l.output, level: l.level, hook: l.hook}
 }
 
 func (l *logger) MustHaveNoWarnings() error {
@@ -3,6 +3,7 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -114,6 +115,20 @@ func TestLogger(t *testing.T) {
 	}
 }
 
+func TestLoggerWithHook(t *testing.T) {
+	var hooked []string
+	var output strings.Builder
+	l := NewLoggerWithHook(&output, Error, func(level LogLevel, message string) {
+		hooked = append(hooked, fmt.Sprintf("%d %s", level, message))
+	})
+	l.Warnf("warn %d\n", 1)
+	l.NewCounter().Error("error 1")
+
+	assert.Equal(t, []string{"1 warn 1", "0 error 1"}, hooked)
+	assert.Equal(t, "[Error] error 1\n", output.String())
+	assert.Equal(t, LogCounters{Warnings: 1}, l.Counters())
+}
+
 func TestLogLevelMarshalText(t *testing.T) {
 	logLevels := []LogLevel{Info, Warn, Error, Off}
 	jsonBytes, err := json.Marshal(logLevels)
@@ -5,9 +5,27 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/exp/slices"
+)
+
+var (
+	ErrInvalidUseOfMultiType = errors.New("invalid use of MultiType")
+	// ErrMissingDiscriminator is returned when unmarshalling a MultiType with a discriminator from a JSON value
+	// that has no discriminator property.
+	ErrMissingDiscriminator = errors.New("missing discriminator property")
+	// ErrUnknownDiscriminator is returned when unmarshalling a MultiType with a discriminator from a JSON value
+	// with a discriminator value that is not mapped to any of the MultiType fields.
+	ErrUnknownDiscriminator = errors.New("unknown discriminator value")
 )
 
-var ErrInvalidUseOfMultiType = errors.New("invalid use of MultiType")
+// discriminatorTag is the struct tag used on MultiType fields to map discriminator values to the field.
+// The tag value has the form "<propertyName>=<value>[,<value>...]".
+// e.g. `discriminator:"petType=cat,kitten"`
+const discriminatorTag = "discriminator"
 
 var multiTypeReflectType = GetType[multiType]()
 
@@ -15,6 +33,20 @@ func IsMultiType(t reflect.Type) bool {
 	return t.Implements(multiTypeReflectType)
 }
 
+// ExtractMultiTypeDiscriminator returns the Discriminator declared with discriminator tags on the MultiType fields.
+// Returns nil Discriminator if the MultiType has no discriminator tags.
+func ExtractMultiTypeDiscriminator(mtType reflect.Type) (*Discriminator, error) {
+	multiTypeDiscriminator := reflect.New(mtType).Elem().MethodByName("MultiTypeDiscriminator")
+
+	returnValues := multiTypeDiscriminator.Call([]reflect.Value{})
+
+	if err := returnValues[1].Interface(); err != nil {
+		return nil, err.(error)
+	}
+
+	return returnValues[0].Interface().(*Discriminator), nil
+}
+
 func ExtractMultiTypeTypes(mtType reflect.Type) ([]reflect.Type, error) {
 	multiTypeTypes := reflect.New(mtType).Elem().MethodByName("MultiTypeTypes")
 
@@ -29,6 +61,7 @@ func ExtractMultiTypeTypes(mtType reflect.Type) ([]reflect.Type, error) {
 
 type multiType interface {
 	MultiTypeTypes() ([]reflect.Type, error)
+	MultiTypeDiscriminator() (*Discriminator, error)
 	json.Marshaler
 	json.Unmarshaler
 }
@@ -48,8 +81,103 @@ func (o *MultiType[T]) MultiTypeTypes() ([]reflect.Type, error) {
 	}), nil
 }
 
-func (o *MultiType[T]) fields() ([]reflect.StructField, error) {
+// Discriminator describes how a MultiType selects the field to unmarshal a JSON object into based on the value of a
+// discriminator property (as described by the OpenAPI discriminator object).
+type Discriminator struct {
+	// PropertyName is the name of the JSON property holding the discriminator value.
+	PropertyName string
+	// Mapping maps each discriminator value to the type of the MultiType field it selects.
+	Mapping map[string]reflect.Type
+	// fields maps each discriminator value to the MultiType field it selects.
+	fields map[string]reflect.StructField
+}
+
+func (o *MultiType[T]) MultiTypeDiscriminator() (*Discriminator, error) {
+	info := o.info()
+	if info.fieldsErr != nil {
+		return nil, info.fieldsErr
+	}
+
+	return info.discriminator, info.discriminatorErr
+}
+
+// multiTypeInfo is the parsed fields and discriminator of the struct of a MultiType.
+// It is parsed once for each MultiType and shared by all its values, so it must not be modified.
+type multiTypeInfo struct {
+	fields           []reflect.StructField
+	fieldsErr        error
+	discriminator    *Discriminator
+	discriminatorErr error
+}
+
+// multiTypeInfos caches the multiTypeInfo of every MultiType struct type.
+var multiTypeInfos sync.Map
+
+// info returns the cached multiTypeInfo of the MultiType.
+func (o *MultiType[T]) info() *multiTypeInfo {
 	structType := GetType[T]()
+	if info, found := multiTypeInfos.Load(structType); found {
+		return info.(*multiTypeInfo)
+	}
+	info := &multiTypeInfo{}
+	info.fields, info.fieldsErr = multiTypeFields(structType)
+	if info.fieldsErr == nil {
+		info.discriminator, info.discriminatorErr = discriminatorFromFields(info.fields)
+	}
+	actual, _ := multiTypeInfos.LoadOrStore(structType, info)
+	return actual.(*multiTypeInfo)
+}
+
+// discriminatorFromFields parses the discriminator tags of the MultiType fields.
+// Either all fields or none of the fields must have a discriminator tag.
+func discriminatorFromFields(fields []reflect.StructField) (*Discriminator, error) {
+	taggedFields := Filter(fields, func(field reflect.StructField) bool {
+		_, ok := field.Tag.Lookup(discriminatorTag)
+		return ok
+	})
+	if len(taggedFields) == 0 {
+		return nil, nil
+	}
+	if len(taggedFields) != len(fields) {
+		return nil, fmt.Errorf("%w. when using a discriminator all fields must have a %q tag",
+			ErrInvalidUseOfMultiType, discriminatorTag)
+	}
+
+	discriminator := &Discriminator{
+		Mapping: make(map[string]reflect.Type),
+		fields:  make(map[string]reflect.StructField),
+	}
+	for _, field := range fields {
+		propertyName, values, _ := strings.Cut(field.Tag.Get(discriminatorTag), "=")
+		if propertyName == "" || values == "" {
+			return nil, fmt.Errorf("%w. discriminator tag of field %q must have the form \"<property>=<value>[,<value>...]\"",
+				ErrInvalidUseOfMultiType, field.Name)
+		}
+		if discriminator.PropertyName != "" && discriminator.PropertyName != propertyName {
+			return nil, fmt.Errorf("%w. all discriminator tags must use the same property name (found %q and %q)",
+				ErrInvalidUseOfMultiType, discriminator.PropertyName, propertyName)
+		}
+		discriminator.PropertyName = propertyName
+		for _, value := range strings.Split(values, ",") {
+			if _, exist := discriminator.Mapping[value]; exist {
+				return nil, fmt.Errorf("%w. discriminator value %q is mapped to multiple fields",
+					ErrInvalidUseOfMultiType, value)
+			}
+			discriminator.Mapping[value] = field.Type
+			discriminator.fields[value] = field
+		}
+	}
+
+	return discriminator, nil
+}
+
+func (o *MultiType[T]) fields() ([]reflect.StructField, error) {
+	info := o.info()
+	return info.fields, info.fieldsErr
+}
+
+// multiTypeFields returns the fields of the struct of a MultiType after checking they are valid MultiType fields.
+func multiTypeFields(structType reflect.Type) ([]reflect.StructField, error) {
 	if structType.Kind() != reflect.Struct {
 		return []reflect.StructField{},
 			fmt.Errorf("%w. expecting generic argument to be a struct",
@@ -67,6 +195,10 @@ func (o *MultiType[T]) fields() ([]reflect.StructField, error) {
 	fields := Map(Entries(fieldsMap), func(e Entry[string, reflect.StructField]) reflect.StructField {
 		return e.Value
 	})
+	// keep the declaration order to try the fields of values without a discriminator in a stable order
+	sort.Slice(fields, func(i, j int) bool {
+		return slices.Compare(fields[i].Index, fields[j].Index) < 0
+	})
 
 	if len(fields) == 0 {
 		return nil, fmt.Errorf("%w. must have at least one field", ErrInvalidUseOfMultiType)
@@ -115,12 +247,26 @@ func (o *MultiType[T]) MarshalJSON() ([]byte, error) {
 }
 
 func (o *MultiType[T]) UnmarshalJSON(bytes []byte) error {
-	fields, err := o.fields()
-	if err != nil {
+	info := o.info()
+	if info.fieldsErr != nil {
 		return fmt.Errorf("can't unmarshal JSON to value due to %w", ErrInvalidUseOfMultiType)
 	}
+	if info.discriminatorErr != nil {
+		return fmt.Errorf("can't unmarshal JSON to value due to %w", info.discriminatorErr)
+	}
+	fields, discriminator := info.fields, info.discriminator
+	var err error
 	structValue := reflect.ValueOf(&o.Values).Elem()
 
+	// with a discriminator the field is selected by the discriminator value instead of trying every field
+	if discriminator != nil {
+		field, err := discriminator.field(bytes)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(bytes, structValue.FieldByIndex(field.Index).Addr().Interface())
+	}
+
 	for _, field := range fields {
 		fieldValue := structValue.FieldByIndex(field.Index)
 		value := fieldValue.Addr().Interface()
@@ -138,3 +284,33 @@ func (o *MultiType[T]) UnmarshalJSON(bytes []byte) error {
 
 	return err
 }
+
+// field finds the MultiType field selected by the discriminator value of the JSON object.
+func (d *Discriminator) field(bytes []byte) (reflect.StructField, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(bytes, &object); err != nil {
+		return reflect.StructField{}, err
+	}
+	rawValue, ok := object[d.PropertyName]
+	if !ok {
+		return reflect.StructField{}, fmt.Errorf("%w %q", ErrMissingDiscriminator, d.PropertyName)
+	}
+	var value string
+	if err := json.Unmarshal(rawValue, &value); err != nil {
+		return reflect.StructField{}, fmt.Errorf("%w %s for property %q. discriminator value must be a string",
+			ErrUnknownDiscriminator, rawValue, d.PropertyName)
+	}
+	field, ok := d.fields[value]
+	if !ok {
+		return reflect.StructField{}, fmt.Errorf("%w %q for property %q. expected one of %q",
+			ErrUnknownDiscriminator, value, d.PropertyName, d.values())
+	}
+	return field, nil
+}
+
+// values returns the sorted discriminator values.
+func (d *Discriminator) values() []string {
+	values := Keys(d.Mapping)
+	sort.Strings(values)
+	return values
+}
@@ -237,3 +237,116 @@ func TestUnmarshalMultiType(t *testing.T) {
 	require.Error(t, err)
 	require.IsType(t, &json.SyntaxError{}, err)
 }
+
+type Cat struct {
+	PetType string `json:"petType"`
+	Meow    bool   `json:"meow"`
+}
+
+type Dog struct {
+	PetType string `json:"petType"`
+	Bark    bool   `json:"bark"`
+}
+
+type Pet struct {
+	Cat *Cat `discriminator:"petType=cat,kitten"`
+	Dog *Dog `discriminator:"petType=dog"`
+}
+
+func TestMultiTypeDiscriminator(t *testing.T) {
+	discriminator, err := ExtractMultiTypeDiscriminator(reflect.TypeOf(&MultiType[Pet]{}))
+	require.NoError(t, err)
+	require.Equal(t, "petType", discriminator.PropertyName)
+	require.Equal(t, map[string]reflect.Type{
+		"cat":    GetType[*Cat](),
+		"kitten": GetType[*Cat](),
+		"dog":    GetType[*Dog](),
+	}, discriminator.Mapping)
+
+	// the discriminator is parsed once for each MultiType
+	cached, err := ExtractMultiTypeDiscriminator(reflect.TypeOf(&MultiType[Pet]{}))
+	require.NoError(t, err)
+	require.Same(t, discriminator, cached)
+
+	discriminator, err = ExtractMultiTypeDiscriminator(reflect.TypeOf(&MultiType[Answer]{}))
+	require.NoError(t, err)
+	require.Nil(t, discriminator)
+}
+
+func TestBadMultiTypeDiscriminator(t *testing.T) {
+	testCases := []struct {
+		name string
+		mt   multiType
+	}{
+		{
+			name: "partially tagged fields",
+			mt: &MultiType[struct {
+				Cat *Cat `discriminator:"petType=cat"`
+				Dog *Dog
+			}]{},
+		},
+		{
+			name: "different property names",
+			mt: &MultiType[struct {
+				Cat *Cat `discriminator:"petType=cat"`
+				Dog *Dog `discriminator:"kind=dog"`
+			}]{},
+		},
+		{
+			name: "duplicate values",
+			mt: &MultiType[struct {
+				Cat *Cat `discriminator:"petType=pet"`
+				Dog *Dog `discriminator:"petType=pet"`
+			}]{},
+		},
+		{
+			name: "missing values",
+			mt: &MultiType[struct {
+				Cat *Cat `discriminator:"petType"`
+				Dog *Dog `discriminator:"petType=dog"`
+			}]{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := ExtractMultiTypeDiscriminator(reflect.TypeOf(testCase.mt))
+			require.ErrorIs(t, err, ErrInvalidUseOfMultiType)
+
+			err = json.Unmarshal([]byte(`{"petType":"cat"}`), testCase.mt)
+			require.ErrorIs(t, err, ErrInvalidUseOfMultiType)
+		})
+	}
+}
+
+func TestUnmarshalMultiTypeWithDiscriminator(t *testing.T) {
+	pet := &MultiType[Pet]{}
+	err := json.Unmarshal([]byte(`{"petType":"kitten","meow":true}`), pet)
+	require.NoError(t, err)
+	require.Equal(t, &Cat{PetType: "kitten", Meow: true}, pet.Values.Cat)
+	require.Nil(t, pet.Values.Dog)
+
+	pet = &MultiType[Pet]{}
+	// a dog that meows is still a dog when the discriminator says so
+	err = json.Unmarshal([]byte(`{"petType":"dog","meow":true}`), pet)
+	require.NoError(t, err)
+	require.Equal(t, &Dog{PetType: "dog"}, pet.Values.Dog)
+	require.Nil(t, pet.Values.Cat)
+
+	pet = &MultiType[Pet]{}
+	err = json.Unmarshal([]byte(`{"petType":"bird"}`), pet)
+	require.ErrorIs(t, err, ErrUnknownDiscriminator)
+	require.ErrorContains(t, err, `"bird"`)
+
+	pet = &MultiType[Pet]{}
+	err = json.Unmarshal([]byte(`{"petType":42}`), pet)
+	require.ErrorIs(t, err, ErrUnknownDiscriminator)
+
+	pet = &MultiType[Pet]{}
+	err = json.Unmarshal([]byte(`{"meow":true}`), pet)
+	require.ErrorIs(t, err, ErrMissingDiscriminator)
+
+	pet = &MultiType[Pet]{}
+	err = json.Unmarshal([]byte(`"cat"`), pet)
+	require.Error(t, err)
+}
@@ -0,0 +1,243 @@
+package router
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/piiano/cellotape/router/utils"
+)
+
+// ValidationSeverity is the severity of a ValidationResult.
+type ValidationSeverity string
+
+const (
+	// ErrorSeverity results fail the validation of the router with the spec.
+	ErrorSeverity ValidationSeverity = "error"
+	// WarningSeverity results are printed as warnings and don't fail the validation of the router with the spec.
+	WarningSeverity ValidationSeverity = "warning"
+)
+
+// ValidationCheck identifies the check of the router validation that reported a ValidationResult.
+// Checks controlled by an option are named after the option.
+type ValidationCheck string
+
+const (
+	// CheckSpecValidation validates the spec itself.
+	CheckSpecValidation ValidationCheck = "specValidation"
+	// CheckOperationExists checks that operations implemented by the router are defined in the spec.
+	CheckOperationExists ValidationCheck = "operationExists"
+	// CheckDuplicateOperationHandlers checks that every operation is implemented once.
+	CheckDuplicateOperationHandlers ValidationCheck = "duplicateOperationHandlers"
+	// CheckExcludeOperations checks that operations excluded with Options.ExcludeOperations are not implemented.
+	CheckExcludeOperations ValidationCheck = "excludeOperations"
+	// CheckRuntimeValidationOptions checks that the runtime validation options of operations are valid.
+	CheckRuntimeValidationOptions ValidationCheck = "runtimeValidationOptions"
+	// CheckHandleAllContentTypes is the check controlled by Options.HandleAllContentTypes.
+	CheckHandleAllContentTypes ValidationCheck = "handleAllContentTypes"
+	// CheckHandleAllSecuritySchemes is the check controlled by Options.HandleAllSecuritySchemes.
+	CheckHandleAllSecuritySchemes ValidationCheck = "handleAllSecuritySchemes"
+	// CheckServers checks that the base paths of the spec servers can be resolved when Options.UseServersBasePaths is set.
+	CheckServers ValidationCheck = "servers"
+	// CheckRoutes checks that the routes of the implemented operations don't conflict.
+	CheckRoutes ValidationCheck = "routes"
+	// CheckMustHandleAllOperations is the check controlled by Options.MustHandleAllOperations.
+	CheckMustHandleAllOperations ValidationCheck = "mustHandleAllOperations"
+	// CheckValidateRequestBody is the check controlled by OperationValidationOptions.ValidateRequestBody.
+	CheckValidateRequestBody ValidationCheck = "validateRequestBody"
+	// CheckValidatePathParams is the check controlled by OperationValidationOptions.ValidatePathParams.
+	CheckValidatePathParams ValidationCheck = "validatePathParams"
+	// CheckValidateQueryParams is the check controlled by OperationValidationOptions.ValidateQueryParams.
+	CheckValidateQueryParams ValidationCheck = "validateQueryParams"
+	// CheckValidateResponses is the check controlled by OperationValidationOptions.ValidateResponses.
+	CheckValidateResponses ValidationCheck = "validateResponses"
+	// CheckHandleAllPathParams is the check controlled by OperationValidationOptions.HandleAllPathParams.
+	CheckHandleAllPathParams ValidationCheck = "handleAllPathParams"
+	// CheckHandleAllQueryParams is the check controlled by OperationValidationOptions.HandleAllQueryParams.
+	CheckHandleAllQueryParams ValidationCheck = "handleAllQueryParams"
+	// CheckHandleAllOperationResponses is the check controlled by OperationValidationOptions.HandleAllOperationResponses.
+	CheckHandleAllOperationResponses ValidationCheck = "handleAllOperationResponses"
+)
+
+// ValidationResult is a single error or warning reported when validating the router with the spec.
+type ValidationResult struct {
+	// OperationID is the id of the operation the result is reported for.
+	// It is empty for results that are not specific to an operation.
+	OperationID string `json:"operationId,omitempty"`
+	// Check is the check that reported the result.
+	Check ValidationCheck `json:"check"`
+	// Severity is the severity of the result.
+	Severity ValidationSeverity `json:"severity"`
+	// Message is the message printed to the log for the result.
+	Message string `json:"message"`
+	// File and Line are the position in the sources of the handler the result is reported for.
+	// They are empty if the result is not reported for a handler or the position is unknown.
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+}
+
+// ValidationReport is a machine-readable report of the errors and warnings found when validating the router with the
+// spec.
+// It can be serialized to JSON with encoding/json or to SARIF with ValidationReport.SARIF.
+type ValidationReport struct {
+	Results []ValidationResult `json:"results"`
+}
+
+// Errors returns the number of results with ErrorSeverity.
+func (r ValidationReport) Errors() int {
+	return r.count(ErrorSeverity)
+}
+
+// Warnings returns the number of results with WarningSeverity.
+func (r ValidationReport) Warnings() int {
+	return r.count(WarningSeverity)
+}
+
+func (r ValidationReport) count(severity ValidationSeverity) int {
+	count := 0
+	for _, result := range r.Results {
+		if result.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// add adds a result logged with the level to the report.
+// Only errors and warnings are added, and nothing is added to a nil report.
+func (r *ValidationReport) add(level utils.LogLevel, check ValidationCheck, operationID string, position sourcePosition, message string) {
+	if r == nil {
+		return
+	}
+	result := ValidationResult{OperationID: operationID, Check: check, Message: message}
+	switch level {
+	case utils.Error:
+		result.Severity = ErrorSeverity
+	case utils.Warn:
+		result.Severity = WarningSeverity
+	default:
+		return
+	}
+	if position.ok {
+		result.File, result.Line = position.file, position.line
+	}
+	r.Results = append(r.Results, result)
+}
+
+// checkLogger returns a logger for the check that adds every error and warning it logs to the validation report.
+func (oa openapi) checkLogger(check ValidationCheck, operationID string, position sourcePosition) utils.Logger {
+	return utils.NewLoggerWithHook(oa.options.LogOutput, oa.options.LogLevel, func(level utils.LogLevel, message string) {
+		oa.report.add(level, check, operationID, position, message)
+	})
+}
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// SARIF serializes the report to a SARIF 2.1.0 log with a result for each ValidationResult.
+//
+// The check of a result is used as its rule id, and the operation id is added to the result properties.
+// Handler positions are reported relative to sourceRoot when it is not empty, so code scanning tools can match them
+// with the files of the repository.
+func (r ValidationReport) SARIF(sourceRoot string) ([]byte, error) {
+	rules := make([]sarifRule, 0)
+	ruleIDs := utils.NewSet[string]()
+	results := make([]sarifResult, 0, len(r.Results))
+	for _, result := range r.Results {
+		if ruleIDs.Add(string(result.Check)) {
+			rules = append(rules, sarifRule{ID: string(result.Check)})
+		}
+		sarifResult := sarifResult{
+			RuleID:  string(result.Check),
+			Level:   string(result.Severity),
+			Message: sarifMessage{Text: result.Message},
+		}
+		if result.OperationID != "" {
+			sarifResult.Properties = map[string]string{"operationId": result.OperationID}
+		}
+		if result.File != "" {
+			sarifResult.Locations = []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: sarifURI(sourceRoot, result.File)},
+				Region:           sarifRegion{StartLine: result.Line},
+			}}}
+		}
+		results = append(results, sarifResult)
+	}
+	return json.MarshalIndent(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "cellotape",
+				InformationURI: "https://github.com/piiano/cellotape",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}, "", "  ")
+}
+
+// sarifURI returns the file path relative to the source root if possible, with forward slashes as required by SARIF.
+func sarifURI(sourceRoot string, file string) string {
+	if sourceRoot != "" {
+		if relativePath, err := filepath.Rel(sourceRoot, file); err == nil {
+			file = relativePath
+		}
+	}
+	return filepath.ToSlash(file)
+}
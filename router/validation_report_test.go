@@ -0,0 +1,130 @@
+package router
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validationReportTestRouter() OpenAPIRouter {
+	greet := testSpecOperation("greet")
+	greet.Responses.Value("200").Value.WithJSONSchema(openapi3.NewIntegerSchema())
+	spec := newTestSpec("test",
+		openapi3.WithPath("/greet", &openapi3.PathItem{Get: greet}),
+		openapi3.WithPath("/other", &openapi3.PathItem{Get: testSpecOperation("other")}),
+	)
+	options := DefaultTestOptions()
+	options.MustHandleAllOperations = PrintWarning
+	return NewOpenAPIRouterWithOptions(spec, options).
+		WithOperation("greet", HandlerFunc[Nil, Nil, Nil, OKResponse[string]](func(_ *Context, _ Request[Nil, Nil, Nil]) (Response[OKResponse[string]], error) {
+			return SendOK(OKResponse[string]{OK: "hello"}), nil
+		}))
+}
+
+func TestValidationReport(t *testing.T) {
+	report, err := validationReportTestRouter().Validate()
+	require.Error(t, err)
+
+	assert.Equal(t, 1, report.Warnings())
+	assert.Positive(t, report.Errors())
+	require.NotEmpty(t, report.Results)
+
+	responseResult := report.Results[0]
+	assert.Equal(t, "greet", responseResult.OperationID)
+	assert.Equal(t, CheckValidateResponses, responseResult.Check)
+	assert.Equal(t, ErrorSeverity, responseResult.Severity)
+	assert.NotEmpty(t, responseResult.Message)
+	assert.Contains(t, responseResult.File, "validation_report_test.go")
+	assert.Positive(t, responseResult.Line)
+
+	missingOperationResult := report.Results[len(report.Results)-1]
+	assert.Equal(t, ValidationResult{
+		OperationID: "other",
+		Check:       CheckMustHandleAllOperations,
+		Severity:    WarningSeverity,
+		Message:     missingHandlerForOperationId("other"),
+	}, missingOperationResult)
+
+	_, asHandlerErr := validationReportTestRouter().AsHandler()
+	assert.Equal(t, asHandlerErr.Error(), err.Error())
+}
+
+func TestValidationReportWithInvalidSpec(t *testing.T) {
+	report, err := NewOpenAPIRouterWithOptions(NewSpec(), DefaultTestOptions()).Validate()
+	require.ErrorIs(t, err, ErrSpecValidation)
+	require.Len(t, report.Results, 1)
+	assert.Equal(t, CheckSpecValidation, report.Results[0].Check)
+	assert.Equal(t, ErrorSeverity, report.Results[0].Severity)
+}
+
+func TestValidationReportJSON(t *testing.T) {
+	spec, err := NewSpecFromData([]byte("openapi: 3.0.3\ninfo:\n  title: test\n  version: 1.0.0\npaths: {}\n"))
+	require.NoError(t, err)
+	report, err := NewOpenAPIRouterWithOptions(spec, DefaultTestOptions()).Validate()
+	require.NoError(t, err)
+
+	data, err := json.Marshal(report)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"results":[]}`, string(data))
+
+	report = ValidationReport{Results: []ValidationResult{{
+		OperationID: "greet",
+		Check:       CheckValidateResponses,
+		Severity:    ErrorSeverity,
+		Message:     "bad response",
+		File:        "/src/handlers.go",
+		Line:        12,
+	}}}
+	data, err = json.Marshal(report)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"results":[{"operationId":"greet","check":"validateResponses","severity":"error","message":"bad response","file":"/src/handlers.go","line":12}]}`, string(data))
+}
+
+func TestValidationReportSARIF(t *testing.T) {
+	report := ValidationReport{Results: []ValidationResult{
+		{
+			OperationID: "greet",
+			Check:       CheckValidateResponses,
+			Severity:    ErrorSeverity,
+			Message:     "bad response",
+			File:        "/src/api/handlers.go",
+			Line:        12,
+		},
+		{
+			Check:    CheckHandleAllContentTypes,
+			Severity: WarningSeverity,
+			Message:  "missing content type",
+		},
+	}}
+
+	data, err := report.SARIF("/src")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+  "$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+  "version": "2.1.0",
+  "runs": [{
+    "tool": {"driver": {
+      "name": "cellotape",
+      "informationUri": "https://github.com/piiano/cellotape",
+      "rules": [{"id": "validateResponses"}, {"id": "handleAllContentTypes"}]
+    }},
+    "results": [
+      {
+        "ruleId": "validateResponses",
+        "level": "error",
+        "message": {"text": "bad response"},
+        "locations": [{"physicalLocation": {"artifactLocation": {"uri": "api/handlers.go"}, "region": {"startLine": 12}}}],
+        "properties": {"operationId": "greet"}
+      },
+      {
+        "ruleId": "handleAllContentTypes",
+        "level": "warning",
+        "message": {"text": "missing content type"}
+      }
+    ]
+  }]
+}`, string(data))
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
 
 	"github.com/getkin/kin-openapi/openapi3"
 
@@ -24,32 +25,49 @@ var ErrSpecValidation = errors.New("spec validation failed")
 // validateOpenAPIRouter validates the entire OpenAPI Router structure built with the builder with the spec.
 // This takes into account various options defined and print to the logs relevant errors and warning based on the defined log level.
 func validateOpenAPIRouter(oa *openapi, flatOperations []operation) error {
+	oa.report = &ValidationReport{Results: []ValidationResult{}}
 	// Validate the spec itself
 	// This step is also crucial to prevent race conditions when accessing the spec concurrently later.
 	// When kin-openapi is used to validate a request, in runtime and it find a pattern validation, it will compile the regex and cache it.
 	// There is a race condition in how the compiled regex is cached if there are concurrent requests since the cache is not thread safe.
 	// This is a known issue in kin-openapi.
 	// Calling Validate on the spec has the side effect of compiling all regex in the spec and cache them so we don't have to worry about it later.
-	if err := (*openapi3.T)(&oa.spec).Validate(context.Background(), openapi3.DisableExamplesValidation()); err != nil {
+	//
+	// patternProperties is allowed although it's not part of OpenAPI 3.0 as it is supported by the type validations.
+	if err := (*openapi3.T)(&oa.spec).Validate(context.Background(), openapi3.DisableExamplesValidation(),
+		openapi3.AllowExtraSiblingFields("patternProperties")); err != nil {
+		oa.report.add(utils.Error, CheckSpecValidation, "", sourcePosition{}, err.Error())
 		return fmt.Errorf("%w: %w", ErrSpecValidation, err)
 	}
 
 	l := oa.logger()
+	oa.typeSchemaErrors = new(TypeSchemaErrors)
 	declaredOperation := utils.NewSet[string]()
 	excludeOperations := utils.NewSet(oa.options.ExcludeOperations...)
 	l.ErrorIfNotNil(validateContentTypes(*oa, excludeOperations))
+	l.ErrorIfNotNil(validateSecuritySchemes(*oa, flatOperations, excludeOperations))
+	l.ErrorIfNotNil(validateServers(*oa, flatOperations))
+	l.ErrorIfNotNil(validateRoutes(*oa, flatOperations))
 	for _, flatOp := range flatOperations {
 		if excludeOperations.Has(flatOp.id) {
-			l.Errorf(anExcludedOperationIsImplemented(flatOp.id))
+			checkLogger := oa.checkLogger(CheckExcludeOperations, flatOp.id, flatOp.sourcePosition)
+			checkLogger.Errorf(anExcludedOperationIsImplemented(flatOp.id))
+			l.AppendCounters(checkLogger.Counters())
 		}
 		if !declaredOperation.Add(flatOp.id) {
 			// multiple handlers for the same operation is always an error
-			l.Errorf(multipleHandlersFoundForOperationId(flatOp.id))
+			checkLogger := oa.checkLogger(CheckDuplicateOperationHandlers, flatOp.id, flatOp.sourcePosition)
+			checkLogger.Errorf(multipleHandlersFoundForOperationId(flatOp.id))
+			l.AppendCounters(checkLogger.Counters())
 		}
 		l.ErrorIfNotNil(validateOperation(*oa, flatOp))
 	}
 	l.ErrorIfNotNil(validateMustHandleAllOperations(oa, declaredOperation, excludeOperations))
-	return l.MustHaveNoErrorsf(failedValidatingTheRouterWithTheSpec(l.Warnings(), l.Errors()))
+	err := l.MustHaveNoErrorsf(failedValidatingTheRouterWithTheSpec(l.Warnings(), l.Errors()))
+	if err != nil && len(*oa.typeSchemaErrors) > 0 {
+		return fmt.Errorf("%w\n%w", err, *oa.typeSchemaErrors)
+	}
+	return err
 }
 
 // validateMustHandleAllOperations checks that all operations defined in the spec have an implementation on the router.
@@ -61,7 +79,9 @@ func validateMustHandleAllOperations(oa *openapi, declaredOperations utils.Set[s
 				continue
 			}
 			if !declaredOperations.Has(specOp.OperationID) {
-				l.Logf(utils.LogLevel(oa.options.MustHandleAllOperations), missingHandlerForOperationId(specOp.OperationID))
+				checkLogger := oa.checkLogger(CheckMustHandleAllOperations, specOp.OperationID, sourcePosition{})
+				checkLogger.Logf(utils.LogLevel(oa.options.MustHandleAllOperations), missingHandlerForOperationId(specOp.OperationID))
+				l.AppendCounters(checkLogger.Counters())
 			}
 		}
 	}
@@ -70,7 +90,7 @@ func validateMustHandleAllOperations(oa *openapi, declaredOperations utils.Set[s
 
 // validateContentTypes checks that all content types defined in the spec for request or responses have an implementation on the router.
 func validateContentTypes(oa openapi, excludeOperations utils.Set[string]) error {
-	log := oa.logger()
+	log := oa.checkLogger(CheckHandleAllContentTypes, "", sourcePosition{})
 	level := utils.LogLevel(oa.options.HandleAllContentTypes)
 	specContentTypes := oa.spec.findSpecContentTypes(excludeOperations)
 	for _, specContentType := range specContentTypes {
@@ -82,13 +102,73 @@ func validateContentTypes(oa openapi, excludeOperations utils.Set[string]) error
 	return log.MustHaveNoErrors()
 }
 
+// validateServers checks that the base paths of the implemented operations can be resolved from the spec servers.
+func validateServers(oa openapi, flatOperations []operation) error {
+	log := oa.checkLogger(CheckServers, "", sourcePosition{})
+	specOperations := oa.spec.Operations()
+	for _, flatOp := range flatOperations {
+		specOp, found := specOperations[flatOp.id]
+		if !found {
+			continue
+		}
+		if _, err := oa.operationBasePaths(specOp); err != nil {
+			operationLog := oa.checkLogger(CheckServers, flatOp.id, flatOp.sourcePosition)
+			operationLog.Errorf(invalidServersForOperation(flatOp.id, err))
+			log.AppendCounters(operationLog.Counters())
+		}
+	}
+	return log.MustHaveNoErrors()
+}
+
+// validateSecuritySchemes checks that all security schemes required by implemented operations are defined in the spec
+// and have an authenticator, and that all authenticators are for security schemes defined in the spec.
+func validateSecuritySchemes(oa openapi, flatOperations []operation, excludeOperations utils.Set[string]) error {
+	log := oa.checkLogger(CheckHandleAllSecuritySchemes, "", sourcePosition{})
+	level := utils.LogLevel(oa.options.HandleAllSecuritySchemes)
+	specSchemes := oa.spec.securitySchemes()
+	for _, name := range utils.Keys(oa.authenticators) {
+		if _, found := specSchemes[name]; !found {
+			log.Errorf(authenticatorForNonExistingSecurityScheme(name))
+		}
+	}
+	for _, flatOp := range flatOperations {
+		specOp, found := oa.spec.findSpecOperationByID(flatOp.id)
+		if !found || excludeOperations.Has(flatOp.id) {
+			continue
+		}
+		operationLog := oa.checkLogger(CheckHandleAllSecuritySchemes, flatOp.id, flatOp.sourcePosition)
+		reported := utils.NewSet[string]()
+		for _, requirement := range oa.spec.operationSecurityRequirements(specOp) {
+			for name := range requirement {
+				if !reported.Add(name) {
+					continue
+				}
+				if _, found = specSchemes[name]; !found {
+					operationLog.Errorf(undefinedSecuritySchemeRequiredByOperation(name, flatOp.id))
+				} else if _, found = oa.authenticators[name]; !found {
+					operationLog.Logf(level, missingAuthenticatorForSecurityScheme(name, flatOp.id))
+				}
+			}
+		}
+		log.AppendCounters(operationLog.Counters())
+	}
+	return log.MustHaveNoErrors()
+}
+
 // validateOperation perform a validation for an operation and its handlers chain for compliance with the spec.
 func validateOperation(oa openapi, operation operation) error {
 	l := oa.logger()
 	specOp, found := oa.spec.findSpecOperationByID(operation.id)
 	options := oa.options.operationValidationOptions(operation.id)
 	if !found {
-		return fmt.Errorf(handlerForNonExistingSpecOperation(operation.id, operation.sourcePosition))
+		message := handlerForNonExistingSpecOperation(operation.id, operation.sourcePosition)
+		oa.report.add(utils.Error, CheckOperationExists, operation.id, operation.sourcePosition, message)
+		return fmt.Errorf(message)
+	}
+	if percentage := options.RuntimeValidateResponsesSamplePercentage; percentage < 0 || percentage > 100 {
+		checkLogger := oa.checkLogger(CheckRuntimeValidationOptions, operation.id, operation.sourcePosition)
+		checkLogger.Errorf(invalidRuntimeValidateResponsesSamplePercentage(percentage, operation.id))
+		l.AppendCounters(checkLogger.Counters())
 	}
 	handlersChain := append(operation.handlers, operation.handler)
 	for _, chainHandler := range handlersChain {
@@ -109,7 +189,8 @@ func validateHandleAllPathParams(oa openapi, behaviour Behaviour, operation oper
 	declaredParams := utils.NewSet[string](utils.ConcatSlices[string](utils.Map(handlers, func(h handler) []string {
 		return utils.Keys(utils.StructKeys(h.request.pathParams, pathParamFieldTag))
 	})...)...)
-	return validateHandleAllParams(oa, behaviour, operation, specOp, pathParamInValue, declaredParams)
+	return validateHandleAllParams(oa.checkLogger(CheckHandleAllPathParams, operation.id, operation.sourcePosition),
+		behaviour, operation, specOp, pathParamInValue, declaredParams)
 }
 
 // validateHandleAllQueryParams checks that every query param defined in the operation is handled at least once in the handlers chain
@@ -118,12 +199,12 @@ func validateHandleAllQueryParams(oa openapi, behaviour Behaviour, operation ope
 	declaredParams := utils.NewSet[string](utils.ConcatSlices[string](utils.Map(handlers, func(h handler) []string {
 		return utils.Keys(utils.StructKeys(h.request.queryParams, queryParamFieldTag))
 	})...)...)
-	return validateHandleAllParams(oa, behaviour, operation, specOp, queryParamInValue, declaredParams)
+	return validateHandleAllParams(oa.checkLogger(CheckHandleAllQueryParams, operation.id, operation.sourcePosition),
+		behaviour, operation, specOp, queryParamInValue, declaredParams)
 }
 
 // validateHandleAllParams checks that every parameter defined in the operation is handled at least once in the handlers chain
-func validateHandleAllParams(oa openapi, behaviour Behaviour, operation operation, specOp SpecOperation, in string, declaredParams utils.Set[string]) utils.LogCounters {
-	l := oa.logger()
+func validateHandleAllParams(l utils.Logger, behaviour Behaviour, operation operation, specOp SpecOperation, in string, declaredParams utils.Set[string]) utils.LogCounters {
 	level := utils.LogLevel(behaviour)
 	for _, specParam := range specOp.Parameters {
 		if specParam.Value.In != in {
@@ -139,7 +220,7 @@ func validateHandleAllParams(oa openapi, behaviour Behaviour, operation operatio
 
 // validateHandleAllResponses checks that every response defined in the spec is handled at least once in the handlers chain
 func validateHandleAllResponses(oa openapi, behaviour Behaviour, operation operation, specOp SpecOperation) utils.LogCounters {
-	l := oa.logger()
+	l := oa.checkLogger(CheckHandleAllOperationResponses, operation.id, operation.sourcePosition)
 	level := utils.LogLevel(behaviour)
 	handlers := append(operation.handlers, operation.handler)
 	responseCodes := utils.NewSet[int](utils.ConcatSlices[int](utils.Map(handlers, func(h handler) []int {
@@ -161,7 +242,7 @@ func validateHandleAllResponses(oa openapi, behaviour Behaviour, operation opera
 // validateRequestBodyType check that a request body type declared on a handler is declared on the spec with a compatible schema.
 // a handler does not have to declare and handle the request body defined in the spec, but it can not declare request body which is not defined or incompatible.
 func validateRequestBodyType(oa openapi, behaviour Behaviour, handler handler, specBody *openapi3.RequestBodyRef, operationID string) utils.LogCounters {
-	l := oa.logger()
+	l := oa.checkLogger(CheckValidateRequestBody, operationID, handler.sourcePosition)
 	level := utils.LogLevel(behaviour)
 	bodyType := handler.request.requestBody
 	if bodyType == utils.NilType {
@@ -183,8 +264,15 @@ func validateRequestBodyType(oa openapi, behaviour Behaviour, handler handler, s
 			continue
 		}
 
-		if err := contentType.ValidateTypeSchema(l.NewCounter(), level, bodyType, *mediaType.Schema.Value); err != nil {
-			l.Logf(level, incompatibleRequestBodyType(operationID, bodyType))
+		requestBodyPath := refOrJSONPointer(specBody.Ref, oa.spec.operationJSONPointer(operationID), "requestBody")
+		schemaPath := refOrJSONPointer(mediaType.Schema.Ref, requestBodyPath, "content", mimeType, "schema")
+		schemaErrors, err := validateContentTypeSchema(l.NewCounter(), level, contentType, bodyType, *mediaType.Schema.Value,
+			schemaPath, schema_validator.RequestDirection, false)
+		if err != nil {
+			message := incompatibleRequestBodyType(operationID, bodyType)
+			l.Logf(level, message)
+			oa.collectTypeSchemaErrors(level, handler, operationID, "request body", schemaErrors,
+				schema_validator.SchemaError{SchemaPath: schemaPath, TypePath: bodyType.String(), Message: message})
 		}
 	}
 	return l.Counters()
@@ -193,38 +281,42 @@ func validateRequestBodyType(oa openapi, behaviour Behaviour, handler handler, s
 // validatePathParamsType check that all pathParamInValue params declared on a handler are available on the spec with a compatible schema.
 // a handler does not have to declare and handle all pathParamInValue parameters defined in the spec, but it can not declare parameters which are not defined.
 func validatePathParamsType(oa openapi, behaviour Behaviour, handler handler, specParameters openapi3.Parameters, operationId string) utils.LogCounters {
-	return validateParamsType(oa, behaviour, pathParamInValue, pathParamFieldTag, handler.request.pathParams, specParameters, operationId)
+	return validateParamsType(oa, CheckValidatePathParams, behaviour, handler, pathParamInValue, pathParamFieldTag, handler.request.pathParams, specParameters, operationId)
 }
 
 // validatePathParamsType check that all queryParamInValue params declared on a handler are available on the spec with a compatible schema.
 // a handler does not have to declare and handle all queryParamInValue parameters defined in the spec, but it can not declare parameters which are not defined.
 func validateQueryParamsType(oa openapi, behaviour Behaviour, handler handler, specParameters openapi3.Parameters, operationId string) utils.LogCounters {
-	return validateParamsType(oa, behaviour, queryParamInValue, queryParamFieldTag, handler.request.queryParams, specParameters, operationId)
+	return validateParamsType(oa, CheckValidateQueryParams, behaviour, handler, queryParamInValue, queryParamFieldTag, handler.request.queryParams, specParameters, operationId)
 }
 
 // validateParamsType check that all params declared on a handler are available on the spec with a compatible schema.
 // a handler does not have to declare and handle all parameters defined in the spec, but it can not declare parameters which are not defined.
-func validateParamsType(oa openapi, behaviour Behaviour, in string, tag string, paramsType reflect.Type, specParameters openapi3.Parameters, operationId string) utils.LogCounters {
-	l := oa.logger()
+func validateParamsType(oa openapi, check ValidationCheck, behaviour Behaviour, handler handler, in string, tag string, paramsType reflect.Type, specParameters openapi3.Parameters, operationId string) utils.LogCounters {
+	l := oa.checkLogger(check, operationId, handler.sourcePosition)
 	level := utils.LogLevel(behaviour)
 	if paramsType == utils.NilType {
 		return utils.LogCounters{}
 	}
 
-	validator := schema_validator.NewTypeSchemaValidator(utils.NilType, openapi3.Schema{})
-
 	for name, field := range utils.StructKeys(paramsType, tag) {
-		specParameter := specParameters.GetByInAndName(in, name)
+		index, specParameter := findSpecParameter(specParameters, in, name)
 		if specParameter == nil {
 			l.Logf(level, paramDefinedByHandlerButMissingInSpec(in, name, paramsType, operationId))
 			continue
 		}
+		parameterPath := refOrJSONPointer(specParameters[index].Ref, oa.spec.operationJSONPointer(operationId), "parameters", strconv.Itoa(index))
+		schemaPath := refOrJSONPointer(specParameter.Schema.Ref, parameterPath, "schema")
 		// TODO: schema validator check object schemas with json keys
-		if err := validator.WithType(field.Type).WithSchema(*specParameter.Schema.Value).Validate(); err != nil {
-			l.Logf(level, incompatibleParamType(operationId, in, name, field.Name, field.Type))
+		validator := schema_validator.NewTypeSchemaValidator(field.Type, *specParameter.Schema.Value).WithSchemaPath(schemaPath)
+		if err := validator.Validate(); err != nil {
+			message := incompatibleParamType(operationId, in, name, field.Name, field.Type)
+			l.Logf(level, message)
 			for _, errMessage := range validator.Errors() {
 				l.Log(level, errMessage)
 			}
+			oa.collectTypeSchemaErrors(level, handler, operationId, fmt.Sprintf("%s param %q", in, name), validator.SchemaErrors(),
+				schema_validator.SchemaError{SchemaPath: schemaPath, TypePath: field.Type.String(), Message: message})
 		}
 	}
 	return l.Counters()
@@ -233,8 +325,9 @@ func validateParamsType(oa openapi, behaviour Behaviour, in string, tag string,
 // validateResponseTypes check that all responses declared on a handler are available on the spec with a compatible schema.
 // a handler does not have to declare and handle all possible responses defined in the spec, but it can not declare responses which are not defined.
 func validateResponseTypes(oa openapi, behaviour Behaviour, handler handler, specOperation *openapi3.Operation, operationId string) utils.LogCounters {
-	l := oa.logger()
+	l := oa.checkLogger(CheckValidateResponses, operationId, handler.sourcePosition)
 	level := utils.LogLevel(behaviour)
+	forbidWriteOnlyFields := oa.options.operationValidationOptions(operationId).ForbidWriteOnlyPropertiesInResponses
 	for status, response := range handler.responses {
 		specResponse := specOperation.Responses.Status(status)
 		if specResponse == nil {
@@ -254,11 +347,62 @@ func validateResponseTypes(oa openapi, behaviour Behaviour, handler handler, spe
 				continue
 			}
 
-			if err := contentType.ValidateTypeSchema(l.NewCounter(), level, response.responseType, *mediaType.Schema.Value); err != nil {
-				l.Logf(level, incompatibleResponseType(operationId, status, response.responseType))
+			responsePath := refOrJSONPointer(specResponse.Ref, oa.spec.operationJSONPointer(operationId), "responses", strconv.Itoa(status))
+			schemaPath := refOrJSONPointer(mediaType.Schema.Ref, responsePath, "content", mimeType, "schema")
+			schemaErrors, err := validateContentTypeSchema(l.NewCounter(), level, contentType, response.responseType, *mediaType.Schema.Value,
+				schemaPath, schema_validator.ResponseDirection, forbidWriteOnlyFields)
+			if err != nil {
+				message := incompatibleResponseType(operationId, status, response.responseType)
+				l.Logf(level, message)
+				oa.collectTypeSchemaErrors(level, handler, operationId, fmt.Sprintf("%d response", status), schemaErrors,
+					schema_validator.SchemaError{SchemaPath: schemaPath, TypePath: response.responseType.String(), Message: message})
 			}
 		}
 		return l.Counters()
 	}
 	return l.Counters()
 }
+
+// findSpecParameter finds the parameter with the location and name in the spec parameters and returns it with its index.
+func findSpecParameter(specParameters openapi3.Parameters, in string, name string) (int, *openapi3.Parameter) {
+	for index, parameter := range specParameters {
+		if parameter != nil && parameter.Value != nil && parameter.Value.In == in && parameter.Value.Name == name {
+			return index, parameter.Value
+		}
+	}
+	return -1, nil
+}
+
+// refOrJSONPointer returns the reference of a spec component if it has one, or the tokens appended to the JSON pointer
+// of its parent.
+func refOrJSONPointer(ref string, parentPointer string, tokens ...string) string {
+	if ref != "" {
+		return ref
+	}
+	return schema_validator.JoinJSONPointer(parentPointer, tokens...)
+}
+
+// collectTypeSchemaErrors collects the structured errors of a handler type that fails the validation with the spec, so
+// they are available with errors.As on the error returned from AsHandler.
+// The fallback error is collected when the type validation doesn't report structured errors (e.g. custom ContentType).
+func (oa openapi) collectTypeSchemaErrors(level utils.LogLevel, h handler, operationID string, location string,
+	schemaErrors []schema_validator.SchemaError, fallback schema_validator.SchemaError) {
+	if oa.typeSchemaErrors == nil || level != utils.Error {
+		return
+	}
+	if len(schemaErrors) == 0 {
+		schemaErrors = []schema_validator.SchemaError{fallback}
+	}
+	sourcePosition := ""
+	if h.sourcePosition.ok {
+		sourcePosition = h.sourcePosition.String()
+	}
+	for _, schemaError := range schemaErrors {
+		*oa.typeSchemaErrors = append(*oa.typeSchemaErrors, TypeSchemaError{
+			SchemaError:    schemaError,
+			OperationID:    operationID,
+			Location:       location,
+			SourcePosition: sourcePosition,
+		})
+	}
+}
@@ -678,3 +678,99 @@ func DefaultTestOptions() Options {
 	options.LogOutput = bytes.NewBuffer([]byte{})
 	return options
 }
+
+func TestValidateReadOnlyAndWriteOnlyProperties(t *testing.T) {
+	readOnlySchema := openapi3.NewStringSchema()
+	readOnlySchema.ReadOnly = true
+	writeOnlySchema := openapi3.NewStringSchema()
+	writeOnlySchema.WriteOnly = true
+	userSchema := openapi3.NewObjectSchema().
+		WithProperty("id", readOnlySchema).
+		WithProperty("name", openapi3.NewStringSchema()).
+		WithProperty("password", writeOnlySchema)
+
+	type createUser struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}
+	type user struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	type userWithPassword struct {
+		ID       string `json:"id"`
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}
+
+	oa := openapi{
+		options:      DefaultTestOptions(),
+		contentTypes: DefaultContentTypes(),
+	}
+
+	counter := validateRequestBodyType(oa, PropagateError, handler{
+		request: requestTypes{requestBody: reflect.TypeOf(createUser{})},
+	}, &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().WithJSONSchema(userSchema)}, "")
+	assert.Equal(t, 0, counter.Errors)
+
+	counter = validateResponseTypes(oa, PropagateError, handler{
+		responses: handlerResponses{200: httpResponse{status: 200, responseType: reflect.TypeOf(user{})}},
+	}, &openapi3.Operation{Responses: testSpecResponse(200, "application/json", userSchema)}, "")
+	assert.Equal(t, 0, counter.Errors)
+
+	responseWithPassword := handler{
+		responses: handlerResponses{200: httpResponse{status: 200, responseType: reflect.TypeOf(userWithPassword{})}},
+	}
+	counter = validateResponseTypes(oa, PropagateError, responseWithPassword,
+		&openapi3.Operation{Responses: testSpecResponse(200, "application/json", userSchema)}, "")
+	assert.Equal(t, 0, counter.Errors)
+
+	oa.options.DefaultOperationValidation.ForbidWriteOnlyPropertiesInResponses = true
+	counter = validateResponseTypes(oa, PropagateError, responseWithPassword,
+		&openapi3.Operation{Responses: testSpecResponse(200, "application/json", userSchema)}, "")
+	assert.Equal(t, 1, counter.Errors)
+}
+
+func TestInvalidRuntimeValidateResponsesSamplePercentageErr(t *testing.T) {
+	spec, err := NewSpecFromData([]byte(`
+openapi: 3.0.3
+info:
+  title: test
+  version: 1.0.0
+paths:
+  /test:
+    get:
+      operationId: test
+      responses:
+        '204':
+          description: no content
+`))
+	require.NoError(t, err)
+	opImpl := operation{
+		id: "test",
+		handler: handler{
+			request: requestTypes{
+				requestBody: utils.NilType,
+				pathParams:  utils.NilType,
+				queryParams: utils.NilType,
+			},
+		},
+	}
+
+	for percentage, valid := range map[float64]bool{-1: false, 0: true, 25.5: true, 100: true, 101: false} {
+		options := DefaultTestOptions()
+		operationValidation := options.DefaultOperationValidation
+		operationValidation.HandleAllOperationResponses = Ignore
+		operationValidation.RuntimeValidateResponsesSamplePercentage = percentage
+		options.OperationValidations = map[string]OperationValidationOptions{"test": operationValidation}
+		err = validateOpenAPIRouter(&openapi{
+			spec:    spec,
+			options: options,
+		}, []operation{opImpl})
+		if valid {
+			require.NoError(t, err, percentage)
+		} else {
+			require.Error(t, err, percentage)
+		}
+	}
+}